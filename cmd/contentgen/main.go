@@ -0,0 +1,77 @@
+// Command contentgen downloads the Quran, doa and hadits collections from the
+// Muslim API and writes them as the dataset embedded by services.EmbeddedContentProvider.
+//
+//	go generate ./internal/services
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/ramadhan/amaliah-monitoring/internal/services"
+)
+
+func main() {
+	out := flag.String("out", "internal/services/content", "output directory")
+	withSurah := flag.Bool("surah", false, "also overwrite surah.json with the API's surah list")
+	flag.Parse()
+
+	api := services.NewMuslimAPIService()
+
+	if err := os.MkdirAll(filepath.Join(*out, "ayah"), 0755); err != nil {
+		log.Fatal(err)
+	}
+
+	doa, err := api.GetAllDoa()
+	if err != nil {
+		log.Fatalf("doa: %v", err)
+	}
+	writeJSON(filepath.Join(*out, "doa.json"), doa)
+
+	hadits, err := api.GetAllHadits()
+	if err != nil {
+		log.Fatalf("hadits: %v", err)
+	}
+	writeJSON(filepath.Join(*out, "hadits.json"), hadits)
+
+	if *withSurah {
+		surah, err := api.GetAllSurah()
+		if err != nil {
+			log.Fatalf("surah: %v", err)
+		}
+		writeJSON(filepath.Join(*out, "surah.json"), surah)
+	}
+
+	total := 0
+	for id := 1; id <= 114; id++ {
+		ayat, err := api.GetAyahBySurah(id)
+		if err != nil {
+			log.Fatalf("surah %d: %v", id, err)
+		}
+		for i := range ayat {
+			if ayat[i].Juz == "" {
+				n, _ := strconv.Atoi(ayat[i].Ayah)
+				ayat[i].Juz = strconv.Itoa(services.JuzOf(id, n))
+			}
+		}
+		writeJSON(filepath.Join(*out, "ayah", fmt.Sprintf("%03d.json", id)), ayat)
+		total += len(ayat)
+	}
+
+	log.Printf("wrote %d doa, %d hadits, %d ayat to %s", len(doa), len(hadits), total, *out)
+}
+
+func writeJSON(path string, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
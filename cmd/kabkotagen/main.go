@@ -0,0 +1,112 @@
+// Command kabkotagen rebuilds internal/services/kabkota.csv from the equran.id
+// location lists. Coordinates of rows already in the file are kept; new
+// kabupaten/kota are geocoded once through Nominatim, at most one request per
+// second as its usage policy requires.
+//
+//	go run ./cmd/kabkotagen
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ramadhan/amaliah-monitoring/internal/services"
+)
+
+func main() {
+	out := flag.String("out", "internal/services/kabkota.csv", "output file")
+	flag.Parse()
+
+	known := map[string]services.KabkotaLocation{}
+	for _, row := range services.Kabkotas() {
+		known[strings.ToLower(row.Kabkota)] = row
+	}
+
+	api := services.NewShalatService()
+	provinces, err := api.GetProvinsi()
+	if err != nil {
+		log.Fatalf("provinsi: %v", err)
+	}
+
+	var records [][]string
+	records = append(records, []string{"provinsi", "kabkota", "latitude", "longitude", "elevation"})
+	geocoded := 0
+	for _, prov := range provinces {
+		kabkotas, err := api.GetKabkota(prov)
+		if err != nil {
+			log.Fatalf("kabkota %s: %v", prov, err)
+		}
+		for _, kab := range kabkotas {
+			row, ok := known[strings.ToLower(kab)]
+			if !ok {
+				lat, lng, err := geocode(kab, prov)
+				if err != nil {
+					log.Printf("skip %s, %s: %v", kab, prov, err)
+					continue
+				}
+				row.Latitude, row.Longitude = lat, lng
+				geocoded++
+				time.Sleep(time.Second)
+			}
+			records = append(records, []string{
+				prov, kab,
+				strconv.FormatFloat(row.Latitude, 'f', 4, 64),
+				strconv.FormatFloat(row.Longitude, 'f', 4, 64),
+				strconv.FormatFloat(row.Elevation, 'f', 0, 64),
+			})
+		}
+	}
+
+	f, err := os.Create(*out)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+	w := csv.NewWriter(f)
+	if err := w.WriteAll(records); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("wrote %d kabupaten/kota (%d geocoded) to %s", len(records)-1, geocoded, *out)
+}
+
+func geocode(kabkota, provinsi string) (float64, float64, error) {
+	q := url.Values{
+		"format": {"json"},
+		"limit":  {"1"},
+		"q":      {fmt.Sprintf("%s, %s, Indonesia", kabkota, provinsi)},
+	}
+	req, err := http.NewRequest("GET", "https://nominatim.openstreetmap.org/search?"+q.Encode(), nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	req.Header.Set("User-Agent", "AmaliahRamadhanApp/1.0 (kabkotagen)")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer resp.Body.Close()
+
+	var results []struct {
+		Lat string `json:"lat"`
+		Lon string `json:"lon"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
+		return 0, 0, err
+	}
+	if len(results) == 0 {
+		return 0, 0, fmt.Errorf("not found")
+	}
+	lat, _ := strconv.ParseFloat(results[0].Lat, 64)
+	lng, _ := strconv.ParseFloat(results[0].Lon, 64)
+	return lat, lng, nil
+}
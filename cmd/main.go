@@ -116,6 +116,9 @@ func runApplication() {
 	// Initialize Handlers
 	h := handlers.NewHandler(db)
 
+	// Build the search index from whatever content is reachable
+	go h.SearchService.Warm()
+
 	// Routes
 	e.GET("/", h.Home)
 
@@ -138,6 +141,8 @@ func runApplication() {
 	// Public Routes - Jadwal Shalat & Imsakiyah
 	e.GET("/jadwal", h.ShowJadwal)
 	e.GET("/api/kabkota", h.GetKabkotaAPI)
+	e.GET("/jadwal/calendar.ics", h.LocationCalendarFeed)
+	e.GET("/calendar/:token", h.UserCalendarFeed)
 
 	// Auth Routes
 	e.GET("/login", h.ShowLogin)
@@ -154,13 +159,34 @@ func runApplication() {
 	user.Use(h.AuthMiddleware)
 	user.GET("/dashboard", h.UserDashboard)
 	user.GET("/jadwal", h.ShowUserJadwal)
+	user.POST("/calendar/reset", h.ResetCalendarToken)
 	user.GET("/prayers", h.ShowPrayers)
 	user.POST("/prayers", h.SavePrayers)
+	user.GET("/sunnah", h.ShowSunnah)
+	user.POST("/sunnah", h.SaveSunnah)
 	user.GET("/fasting", h.ShowFasting)
 	user.POST("/fasting", h.SaveFasting)
+	user.POST("/fasting/checkin", h.CheckInFasting)
+	user.GET("/fasting/checkin/delete/:meal", h.UndoFastingCheckin)
+	user.GET("/udzur", h.ShowUdzur)
+	user.POST("/udzur", h.AddUdzur)
+	user.POST("/udzur/end/:id", h.EndUdzur)
+	user.GET("/udzur/delete/:id", h.DeleteUdzur)
+	user.GET("/qadha", h.ShowQadha)
+	user.POST("/qadha", h.LogQadha)
+	user.GET("/qadha/delete/:id", h.DeleteQadha)
+	user.GET("/puasa-sunnah", h.ShowSunnahFasting)
+	user.POST("/puasa-sunnah", h.SaveSunnahFasting)
+	user.GET("/puasa-sunnah/delete/:date", h.DeleteSunnahFasting)
 	user.GET("/quran", h.ShowQuran)
 	user.POST("/quran", h.SaveQuran)
 	user.GET("/quran/delete/:id", h.DeleteQuran)
+	user.GET("/tahfidz", h.ShowTahfidz)
+	user.POST("/tahfidz", h.SaveHafalan)
+	user.POST("/tahfidz/review/:id", h.ReviewHafalan)
+	user.GET("/tahfidz/delete/:id", h.DeleteHafalan)
+	user.POST("/setoran", h.RequestSetoran)
+	user.GET("/setoran/cancel/:id", h.CancelSetoran)
 	user.GET("/amaliah", h.ShowAmaliah)
 	user.POST("/amaliah", h.SaveAmaliah)
 
@@ -168,6 +194,15 @@ func runApplication() {
 	user.GET("/doa", h.ShowDoa)
 	user.GET("/hadits", h.ShowHadits)
 	user.GET("/quran-indonesia", h.ShowQuranIndonesia)
+	user.GET("/search", h.ShowSearch)
+	user.GET("/favorites", h.ShowFavorites)
+	user.POST("/favorites/toggle", h.ToggleFavorite)
+	user.GET("/favorites/delete/:id", h.DeleteFavorite)
+	user.POST("/favorites/move/:id", h.MoveFavorite)
+	user.POST("/favorites/collection/:id", h.SetFavoriteCollection)
+	user.POST("/favorites/collections", h.CreateFavoriteCollection)
+	user.GET("/favorites/collections/delete/:id", h.DeleteFavoriteCollection)
+	user.POST("/quran/bookmark", h.SetQuranBookmark)
 
 	// Profile Routes
 	user.GET("/profile", h.ShowProfile)
@@ -182,18 +217,38 @@ func runApplication() {
 	school.GET("/admin", h.SchoolAdminDashboard)
 	school.POST("/admin/update", h.SchoolUpdate)
 	school.GET("/member/remove/:id", h.SchoolRemoveMember)
+	school.GET("/admin/stream", h.SchoolDashboardStream)
+	school.POST("/daily-content/pin", h.PinDailyContent)
+	school.GET("/daily-content/unpin/:id", h.UnpinDailyContent)
+	school.GET("/setoran", h.ShowSetoranTeacher)
+	school.POST("/setoran/grade/:id", h.GradeSetoran)
+	school.GET("/setoran/export", h.ExportSetoran)
+	school.GET("/entries", h.ShowEntryReview)
+	school.POST("/entries/approve/:id", h.ApproveLateEntry)
+	school.POST("/entries/reject/:id", h.RejectLateEntry)
+	school.POST("/entries/signoff", h.SignOffEntries)
+	school.GET("/entries/unlock/:id", h.UnlockEntries)
+	school.GET("/entries/export", h.ExportEntries)
+	school.GET("/qadha", h.ShowQadhaReport)
+	school.GET("/qadha/export", h.ExportQadhaReport)
 
 	// API Routes (protected)
 	user.POST("/api/location/autodetect", h.AutoDetectLocation)
 	user.GET("/api/imsakiyah", h.GetImsakiyahAPI)
+	user.GET("/api/search", h.SearchAPI)
+	user.GET("/api/daily-content", h.DailyContentAPI)
+	user.GET("/api/favorites", h.FavoritesAPI)
+	user.POST("/api/sync", h.SyncAPI)
 
 	// Admin Routes Group
 	admin := e.Group("/admin")
 	admin.Use(h.AdminMiddleware)
 	admin.GET("/dashboard", h.AdminDashboard)
+	admin.GET("/dashboard/stream", h.AdminDashboardStream)
 	admin.GET("/users", h.ManageUsers)
 	admin.POST("/users", h.CreateUser)
 	admin.GET("/users/search", h.SearchUsers)
+	admin.GET("/api/users", h.ListUsersAPI)
 	admin.GET("/users/template", h.DownloadUserTemplate)
 	admin.POST("/users/import", h.ImportUsers)
 	admin.GET("/users/edit/:id", h.EditUser)
@@ -206,6 +261,8 @@ func runApplication() {
 	admin.GET("/reports/generate", h.GenerateReport)
 	admin.GET("/reports/download", h.DownloadReport)
 	admin.GET("/statistics", h.ShowStatistics)
+	admin.GET("/cache", h.ShowCache)
+	admin.POST("/cache/refresh", h.RefreshCache)
 
 	// Class Management
 	admin.GET("/classes", h.ManageClasses)
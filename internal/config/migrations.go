@@ -4,7 +4,10 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"strings"
 
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+	"github.com/ramadhan/amaliah-monitoring/internal/mushaf"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -259,6 +262,365 @@ func RunMigrations(db *sql.DB) error {
 		log.Printf("Note: admin_requests migration: %v", err)
 	}
 
+	// Offline sync: fastings need an edit timestamp for last-writer-wins
+	if err := addColumnIfNotExists(db, "fastings", "updated_at", "TIMESTAMP"); err != nil {
+		log.Printf("Note: %v", err)
+	}
+
+	// Offline sync log, one row per client-generated entry
+	syncMigrations := []string{
+		`CREATE TABLE IF NOT EXISTS sync_entries (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			client_id VARCHAR(36) NOT NULL,
+			entity_type VARCHAR(20) NOT NULL,
+			entity_key VARCHAR(100) NOT NULL,
+			client_timestamp VARCHAR(40) NOT NULL,
+			status VARCHAR(20) NOT NULL,
+			message TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (user_id) REFERENCES users(id),
+			UNIQUE(user_id, client_id)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_sync_entries_entity ON sync_entries(user_id, entity_type, entity_key)`,
+	}
+	for _, m := range syncMigrations {
+		if _, err := db.Exec(m); err != nil {
+			log.Printf("Note: sync migration: %v", err)
+		}
+	}
+
+	// Private token for per-user calendar subscription URLs
+	if err := addColumnIfNotExists(db, "users", "calendar_token", "VARCHAR(64) DEFAULT ''"); err != nil {
+		log.Printf("Note: %v", err)
+	}
+
+	// Persistent cache for external schedule APIs (equran.id)
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS api_cache (
+		cache_key VARCHAR(255) PRIMARY KEY,
+		payload TEXT NOT NULL,
+		fetched_at TIMESTAMP NOT NULL,
+		expires_at TIMESTAMP NOT NULL
+	)`); err != nil {
+		log.Printf("Note: api_cache migration: %v", err)
+	}
+
+	// Days to shift the tabular Hijri calendar to match the school's isbat decision
+	if err := addColumnIfNotExists(db, "schools", "hijri_offset", "INTEGER DEFAULT 0"); err != nil {
+		log.Printf("Note: %v", err)
+	}
+
+	// Mushaf Madinah page and juz span of each reading, derived from the ayah range
+	for _, column := range []string{"start_page", "end_page", "start_juz", "end_juz"} {
+		if err := addColumnIfNotExists(db, "quran_readings", column, "INTEGER DEFAULT 0"); err != nil {
+			log.Printf("Note: %v", err)
+		}
+	}
+	if err := backfillQuranPages(db); err != nil {
+		log.Printf("Note: quran page backfill: %v", err)
+	}
+
+	// Last-read position per user for "lanjutkan membaca"
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS quran_bookmarks (
+		user_id INTEGER PRIMARY KEY,
+		surah_id INTEGER NOT NULL,
+		ayah INTEGER NOT NULL,
+		source VARCHAR(20) NOT NULL DEFAULT 'reading',
+		updated_at TIMESTAMP NOT NULL,
+		FOREIGN KEY (user_id) REFERENCES users(id)
+	)`); err != nil {
+		log.Printf("Note: quran_bookmarks migration: %v", err)
+	}
+
+	// Tahfidz: memorized ranges with their murajaah schedule, and review results
+	tahfidzMigrations := []string{
+		`CREATE TABLE IF NOT EXISTS hafalan (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			start_surah_id INTEGER NOT NULL,
+			start_ayah INTEGER NOT NULL,
+			end_surah_id INTEGER NOT NULL,
+			end_ayah INTEGER NOT NULL,
+			level INTEGER DEFAULT 0,
+			next_review DATE NOT NULL,
+			last_reviewed DATE,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (user_id) REFERENCES users(id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS hafalan_reviews (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			hafalan_id INTEGER NOT NULL,
+			user_id INTEGER NOT NULL,
+			date DATE NOT NULL,
+			result VARCHAR(20) NOT NULL,
+			level INTEGER NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (hafalan_id) REFERENCES hafalan(id),
+			FOREIGN KEY (user_id) REFERENCES users(id)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_hafalan_user_review ON hafalan(user_id, next_review)`,
+		`CREATE INDEX IF NOT EXISTS idx_hafalan_reviews_user ON hafalan_reviews(user_id, created_at)`,
+	}
+	tahfidzMigrations = append(tahfidzMigrations,
+		`CREATE TABLE IF NOT EXISTS setoran (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			student_id INTEGER NOT NULL,
+			teacher_id INTEGER,
+			school_id INTEGER,
+			start_surah_id INTEGER NOT NULL,
+			start_ayah INTEGER NOT NULL,
+			end_surah_id INTEGER NOT NULL,
+			end_ayah INTEGER NOT NULL,
+			status VARCHAR(20) DEFAULT 'pending',
+			grade VARCHAR(20),
+			tajwid_notes TEXT,
+			setoran_date DATE,
+			points INTEGER DEFAULT 0,
+			requested_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			graded_at TIMESTAMP,
+			FOREIGN KEY (student_id) REFERENCES users(id),
+			FOREIGN KEY (teacher_id) REFERENCES users(id)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_setoran_student ON setoran(student_id, requested_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_setoran_school_status ON setoran(school_id, status)`,
+	)
+	for _, m := range tahfidzMigrations {
+		if _, err := db.Exec(m); err != nil {
+			log.Printf("Note: tahfidz migration: %v", err)
+		}
+	}
+
+	// Full-text index over the Quran, doa and hadits. Arabic is stored without
+	// harakat; unicode61 folds the diacritics of the Latin transliteration.
+	searchMigrations := []string{
+		`CREATE VIRTUAL TABLE IF NOT EXISTS search_index USING fts5(
+			source UNINDEXED, kind UNINDEXED, ref UNINDEXED, title, body, latin, arab,
+			tokenize = 'unicode61 remove_diacritics 2'
+		)`,
+		`CREATE TABLE IF NOT EXISTS search_sources (
+			source VARCHAR(50) PRIMARY KEY,
+			documents INTEGER DEFAULT 0,
+			indexed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+	}
+	for _, m := range searchMigrations {
+		if _, err := db.Exec(m); err != nil {
+			log.Printf("Note: search migration: %v", err)
+		}
+	}
+
+	// School admins can override the daily ayah, doa or hadits
+	dailyContentMigration := `CREATE TABLE IF NOT EXISTS daily_content_pins (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		school_id INTEGER NOT NULL,
+		date DATE NOT NULL,
+		kind VARCHAR(10) NOT NULL,
+		ref VARCHAR(50) NOT NULL,
+		created_by INTEGER,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(school_id, date, kind),
+		FOREIGN KEY (school_id) REFERENCES schools(id)
+	)`
+	if _, err := db.Exec(dailyContentMigration); err != nil {
+		log.Printf("Note: daily content migration: %v", err)
+	}
+
+	favoriteMigrations := []string{
+		`CREATE TABLE IF NOT EXISTS favorite_collections (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			name VARCHAR(100) NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(user_id, name),
+			FOREIGN KEY (user_id) REFERENCES users(id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS favorites (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			content_type VARCHAR(10) NOT NULL,
+			content_id VARCHAR(50) NOT NULL,
+			collection_id INTEGER,
+			position INTEGER DEFAULT 0,
+			title VARCHAR(255) NOT NULL,
+			arab TEXT,
+			text TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(user_id, content_type, content_id),
+			FOREIGN KEY (user_id) REFERENCES users(id),
+			FOREIGN KEY (collection_id) REFERENCES favorite_collections(id)
+		)`,
+	}
+	for _, m := range favoriteMigrations {
+		if _, err := db.Exec(m); err != nil {
+			log.Printf("Note: favorite migration: %v", err)
+		}
+	}
+
+	// Latest status change per prayer, classified against the day's schedule
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS prayer_timings (
+		user_id INTEGER NOT NULL,
+		date DATE NOT NULL,
+		prayer VARCHAR(10) NOT NULL,
+		status VARCHAR(20) NOT NULL,
+		marked_at DATETIME NOT NULL,
+		timing VARCHAR(20) NOT NULL DEFAULT '',
+		PRIMARY KEY (user_id, date, prayer),
+		FOREIGN KEY (user_id) REFERENCES users(id)
+	)`)
+	if err != nil {
+		log.Printf("Note: prayer_timings migration: %v", err)
+	}
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS sunnah_prayers (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		date DATE NOT NULL,
+		tarawih_rakaat INTEGER NOT NULL DEFAULT 0,
+		tarawih_place VARCHAR(10) NOT NULL DEFAULT '',
+		tarawih_mode VARCHAR(10) NOT NULL DEFAULT '',
+		witir_rakaat INTEGER NOT NULL DEFAULT 0,
+		dhuha_rakaat INTEGER NOT NULL DEFAULT 0,
+		tahajud_rakaat INTEGER NOT NULL DEFAULT 0,
+		rawatib VARCHAR(100) NOT NULL DEFAULT '',
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(user_id, date),
+		FOREIGN KEY (user_id) REFERENCES users(id)
+	)`)
+	if err != nil {
+		log.Printf("Note: sunnah_prayers migration: %v", err)
+	}
+
+	// How far back students may fill in entries, and whether late ones need a teacher
+	if err := addColumnIfNotExists(db, "schools", "backdate_days", "INTEGER DEFAULT 1"); err != nil {
+		log.Printf("Note: %v", err)
+	}
+	if err := addColumnIfNotExists(db, "schools", "late_approval", "INTEGER DEFAULT 0"); err != nil {
+		log.Printf("Note: %v", err)
+	}
+
+	entryPolicyMigrations := []string{
+		`CREATE TABLE IF NOT EXISTS entry_locks (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			school_id INTEGER NOT NULL DEFAULT 0,
+			class VARCHAR(50) NOT NULL DEFAULT '',
+			date DATE NOT NULL,
+			source VARCHAR(10) NOT NULL,
+			locked_by INTEGER,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(school_id, class, date)
+		)`,
+		`CREATE TABLE IF NOT EXISTS late_entries (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			school_id INTEGER NOT NULL DEFAULT 0,
+			type VARCHAR(10) NOT NULL,
+			date DATE NOT NULL,
+			data TEXT NOT NULL,
+			status VARCHAR(10) NOT NULL DEFAULT 'pending',
+			note TEXT NOT NULL DEFAULT '',
+			reviewed_by INTEGER,
+			reviewed_at DATETIME,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (user_id) REFERENCES users(id)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_late_entries_school_status ON late_entries(school_id, status)`,
+	}
+	for _, m := range entryPolicyMigrations {
+		if _, err := db.Exec(m); err != nil {
+			log.Printf("Note: entry policy migration: %v", err)
+		}
+	}
+
+	if err := normalizeStatuses(db); err != nil {
+		log.Printf("Note: status normalization: %v", err)
+	}
+
+	// Udzur periods are private to the student; end_date holds the latest
+	// possible day while ongoing
+	udzurMigrations := []string{
+		`CREATE TABLE IF NOT EXISTS udzur_periods (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			kind VARCHAR(10) NOT NULL,
+			start_date DATE NOT NULL,
+			end_date DATE NOT NULL,
+			ongoing INTEGER NOT NULL DEFAULT 0,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (user_id) REFERENCES users(id)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_udzur_periods_user_dates ON udzur_periods(user_id, start_date, end_date)`,
+	}
+	for _, m := range udzurMigrations {
+		if _, err := db.Exec(m); err != nil {
+			log.Printf("Note: udzur migration: %v", err)
+		}
+	}
+
+	// Make-up fasts paying back the missed days of a Ramadhan
+	qadhaMigrations := []string{
+		`CREATE TABLE IF NOT EXISTS qadha_fasts (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			date DATE NOT NULL,
+			ramadhan_year INTEGER NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (user_id) REFERENCES users(id),
+			UNIQUE(user_id, date)
+		)`,
+	}
+	for _, m := range qadhaMigrations {
+		if _, err := db.Exec(m); err != nil {
+			log.Printf("Note: qadha migration: %v", err)
+		}
+	}
+
+	// Sunnah fasts outside Ramadhan, and the badges for them. Badges are only
+	// added when missing since the table has no unique key.
+	sunnahFastMigrations := []string{
+		`CREATE TABLE IF NOT EXISTS sunnah_fasts (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			date DATE NOT NULL,
+			kinds VARCHAR(100) NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (user_id) REFERENCES users(id),
+			UNIQUE(user_id, date)
+		)`,
+		`INSERT INTO badges (name, description, icon, criteria_type, criteria_value)
+			SELECT 'Puasa Sunnah Pertama', 'Menunaikan puasa sunnah pertama kali', 'moon', 'sunnah_fasting_count', 1
+			WHERE NOT EXISTS (SELECT 1 FROM badges WHERE criteria_type = 'sunnah_fasting_count' AND criteria_value = 1)`,
+		`INSERT INTO badges (name, description, icon, criteria_type, criteria_value)
+			SELECT 'Ahli Puasa Sunnah', 'Menunaikan 30 hari puasa sunnah', 'sun', 'sunnah_fasting_count', 30
+			WHERE NOT EXISTS (SELECT 1 FROM badges WHERE criteria_type = 'sunnah_fasting_count' AND criteria_value = 30)`,
+		`INSERT INTO badges (name, description, icon, criteria_type, criteria_value)
+			SELECT 'Pejuang Senin-Kamis', 'Puasa Senin-Kamis sebanyak 8 kali', 'fire', 'senin_kamis_count', 8
+			WHERE NOT EXISTS (SELECT 1 FROM badges WHERE criteria_type = 'senin_kamis_count')`,
+	}
+	for _, m := range sunnahFastMigrations {
+		if _, err := db.Exec(m); err != nil {
+			log.Printf("Note: sunnah fasting migration: %v", err)
+		}
+	}
+
+	// Sahur and iftar check-ins, one of each per day
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS fasting_checkins (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		date DATE NOT NULL,
+		meal VARCHAR(10) NOT NULL,
+		checked_at DATETIME NOT NULL,
+		schedule_time VARCHAR(5) NOT NULL DEFAULT '',
+		on_time BOOLEAN NOT NULL DEFAULT 0,
+		points INTEGER NOT NULL DEFAULT 0,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users(id),
+		UNIQUE(user_id, date, meal)
+	)`)
+	if err != nil {
+		log.Printf("Note: fasting_checkins migration: %v", err)
+	}
+
 	return nil
 }
 
@@ -306,3 +668,93 @@ func seedAdminUser(db *sql.DB) error {
 	log.Println("Default superadmin user created: admin / admin123")
 	return nil
 }
+
+// backfillQuranPages replaces the page count typed on older readings with
+// the one computed from their ayah range. Rows whose range does not exist in
+// the mushaf keep their typed value.
+func backfillQuranPages(db *sql.DB) error {
+	rows, err := db.Query(`SELECT id, start_surah_id, start_ayah, end_surah_id, end_ayah
+		FROM quran_readings WHERE COALESCE(start_page, 0) = 0`)
+	if err != nil {
+		return err
+	}
+
+	type pending struct {
+		id       int
+		coverage mushaf.Coverage
+	}
+	var updates []pending
+	for rows.Next() {
+		var id int
+		var r mushaf.Range
+		var startSurah, startAyah, endSurah, endAyah sql.NullInt64
+		if err := rows.Scan(&id, &startSurah, &startAyah, &endSurah, &endAyah); err != nil {
+			rows.Close()
+			return err
+		}
+		r.StartSurah, r.StartAyah = int(startSurah.Int64), int(startAyah.Int64)
+		r.EndSurah, r.EndAyah = int(endSurah.Int64), int(endAyah.Int64)
+		if c, err := r.Cover(); err == nil {
+			updates = append(updates, pending{id, c})
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, u := range updates {
+		c := u.coverage
+		if _, err := db.Exec(`UPDATE quran_readings SET pages = ?, start_page = ?, end_page = ?, start_juz = ?, end_juz = ? WHERE id = ?`,
+			c.Pages, c.StartPage, c.EndPage, c.StartJuz, c.EndJuz, u.id); err != nil {
+			return err
+		}
+	}
+	if len(updates) > 0 {
+		log.Printf("Recomputed pages for %d Quran readings", len(updates))
+	}
+	return nil
+}
+
+// normalizeStatuses rewrites prayer and fasting statuses saved before they
+// were validated. Values differing only in case or spacing are kept; any other
+// prayer status becomes belum and any other fasting status tidak, so nothing
+// unverified counts as done.
+func normalizeStatuses(db *sql.DB) error {
+	quote := func(values ...string) string {
+		return "'" + strings.Join(values, "', '") + "'"
+	}
+	var prayerValues []string
+	for _, v := range models.PrayerStatusValues {
+		prayerValues = append(prayerValues, string(v))
+	}
+	prayerIn := quote(prayerValues...)
+	fastingIn := quote(string(models.FastingDone), string(models.FastingSkipped))
+
+	columns := []struct {
+		table, column, values, fallback string
+	}{
+		{"prayers", "subuh", prayerIn, string(models.PrayerPending)},
+		{"prayers", "dzuhur", prayerIn, string(models.PrayerPending)},
+		{"prayers", "ashar", prayerIn, string(models.PrayerPending)},
+		{"prayers", "maghrib", prayerIn, string(models.PrayerPending)},
+		{"prayers", "isya", prayerIn, string(models.PrayerPending)},
+		{"fastings", "status", fastingIn, string(models.FastingSkipped)},
+	}
+
+	fixed := 0
+	for _, c := range columns {
+		res, err := db.Exec(fmt.Sprintf(`UPDATE %[1]s SET %[2]s = CASE
+			WHEN lower(trim(%[2]s)) IN (%[3]s) THEN lower(trim(%[2]s)) ELSE ? END
+			WHERE %[2]s IS NULL OR %[2]s NOT IN (%[3]s)`, c.table, c.column, c.values), c.fallback)
+		if err != nil {
+			return err
+		}
+		n, _ := res.RowsAffected()
+		fixed += int(n)
+	}
+	if fixed > 0 {
+		log.Printf("Normalized %d prayer and fasting statuses", fixed)
+	}
+	return nil
+}
@@ -18,7 +18,7 @@ func (h *Handler) DownloadReport(c echo.Context) error {
 	}
 
 	if reportType == "daily" {
-		f, err := h.ExportService.GenerateDailyReportExcel(date, className)
+		f, err := h.ExportService.GenerateDailyReportExcel(0, date, className)
 		if err != nil {
 			return c.Redirect(http.StatusSeeOther, "/admin/reports?error="+err.Error())
 		}
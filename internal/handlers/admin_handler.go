@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/ramadhan/amaliah-monitoring/internal/models"
@@ -108,3 +109,31 @@ func (h *Handler) DeleteClass(c echo.Context) error {
 
 	return c.Redirect(http.StatusSeeOther, "/admin/classes?success=Kelas berhasil dihapus")
 }
+
+// ShowCache shows how equran.id requests are being served from the schedule cache
+func (h *Handler) ShowCache(c echo.Context) error {
+	entries, _ := h.ScheduleCache.Repo.List(200)
+
+	return c.Render(http.StatusOK, "admin/cache.html", map[string]interface{}{
+		"Title":   "Cache Jadwal",
+		"User":    c.Get("user"),
+		"Stats":   h.ScheduleCache.Stats(),
+		"Entries": entries,
+		"Now":     time.Now(),
+		"Success": c.QueryParam("success"),
+		"Error":   c.QueryParam("error"),
+	})
+}
+
+// RefreshCache expires cached entries so the next request fetches fresh data.
+// An empty prefix refreshes everything.
+func (h *Handler) RefreshCache(c echo.Context) error {
+	prefix := c.FormValue("prefix")
+
+	count, err := h.ScheduleCache.Expire(prefix)
+	if err != nil {
+		return c.Redirect(http.StatusSeeOther, "/admin/cache?error=Gagal menyegarkan cache")
+	}
+
+	return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/admin/cache?success=%d data akan diambil ulang", count))
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+	"github.com/ramadhan/amaliah-monitoring/internal/services"
+	"github.com/ramadhan/amaliah-monitoring/internal/utils"
+)
+
+// LocationCalendarFeed serves a public .ics feed for a provinsi/kabkota
+func (h *Handler) LocationCalendarFeed(c echo.Context) error {
+	provinsi := c.QueryParam("provinsi")
+	kabkota := c.QueryParam("kabkota")
+	if provinsi == "" || kabkota == "" {
+		return c.String(http.StatusBadRequest, "provinsi dan kabkota diperlukan")
+	}
+
+	return h.serveCalendar(c, provinsi, kabkota, "public")
+}
+
+// UserCalendarFeed serves the .ics feed for the location saved in a user's profile.
+// Calendar apps cannot send cookies, so the private token in the URL identifies the user.
+func (h *Handler) UserCalendarFeed(c echo.Context) error {
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+
+	user, err := h.UserRepo.GetByCalendarToken(token)
+	if err != nil {
+		return c.String(http.StatusNotFound, "Kalender tidak ditemukan")
+	}
+	if user.Provinsi == "" || user.Kabkota == "" {
+		return c.String(http.StatusNotFound, "Lokasi belum diatur di profil")
+	}
+
+	return h.serveCalendar(c, user.Provinsi, user.Kabkota, "private")
+}
+
+// ResetCalendarToken invalidates the user's old subscription URL
+func (h *Handler) ResetCalendarToken(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+
+	token, err := utils.GenerateSecureToken(16)
+	if err == nil {
+		err = h.UserRepo.SetCalendarToken(user.ID, token)
+	}
+	if err != nil {
+		return c.Redirect(http.StatusSeeOther, "/user/jadwal?error=Gagal membuat ulang tautan kalender")
+	}
+
+	return c.Redirect(http.StatusSeeOther, "/user/jadwal?success=Tautan kalender berhasil dibuat ulang")
+}
+
+func (h *Handler) serveCalendar(c echo.Context, provinsi, kabkota, cacheScope string) error {
+	months, _ := strconv.Atoi(c.QueryParam("months"))
+	if months == 0 {
+		months = 2
+	}
+
+	alarmParam := c.QueryParam("alarm")
+	if alarmParam == "" {
+		alarmParam = "10"
+	}
+
+	feed, err := h.CalendarService.BuildFeed(services.CalendarOptions{
+		Provinsi: provinsi,
+		Kabkota:  kabkota,
+		Months:   months,
+		Alarms:   services.ParseAlarmOffsets(alarmParam),
+		From:     time.Now(),
+	})
+	if err != nil {
+		return c.String(http.StatusBadGateway, "Gagal memuat jadwal shalat")
+	}
+
+	res := c.Response()
+	res.Header().Set("ETag", feed.ETag)
+	res.Header().Set("Cache-Control", cacheScope+", max-age=3600")
+
+	if match := c.Request().Header.Get("If-None-Match"); match != "" && match == feed.ETag {
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	res.Header().Set("Content-Disposition", `inline; filename="jadwal-shalat.ics"`)
+	return c.Blob(http.StatusOK, "text/calendar; charset=utf-8", feed.Body)
+}
+
+// calendarURLs returns the subscription links shown on the jadwal page
+func (h *Handler) calendarURLs(c echo.Context, user *models.User, provinsi, kabkota string) map[string]string {
+	base := c.Scheme() + "://" + c.Request().Host
+	urls := map[string]string{}
+
+	if provinsi != "" && kabkota != "" {
+		q := url.Values{}
+		q.Set("provinsi", provinsi)
+		q.Set("kabkota", kabkota)
+		urls["Location"] = base + "/jadwal/calendar.ics?" + q.Encode()
+	}
+
+	if user != nil && user.Provinsi != "" && user.Kabkota != "" {
+		token, _ := h.UserRepo.GetCalendarToken(user.ID)
+		if token == "" {
+			if t, err := utils.GenerateSecureToken(16); err == nil && h.UserRepo.SetCalendarToken(user.ID, t) == nil {
+				token = t
+			}
+		}
+		if token != "" {
+			urls["Personal"] = base + "/calendar/" + token + ".ics"
+		}
+	}
+
+	return urls
+}
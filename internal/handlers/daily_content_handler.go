@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+	"github.com/ramadhan/amaliah-monitoring/internal/services"
+)
+
+// dailyContent returns the ayah, doa and hadits of the day for the user's school
+func (h *Handler) dailyContent(user *models.User, now time.Time) *services.DailyContent {
+	day, ok := h.ramadhanFor(user, now).Day(now)
+	if !ok {
+		day = 0
+	}
+	return h.DailyContentService.Get(now, user.SchoolID, day)
+}
+
+// DailyContentAPI returns today's content as JSON
+func (h *Handler) DailyContentAPI(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+	return c.JSON(http.StatusOK, h.dailyContent(user, time.Now()))
+}
+
+// PinDailyContent lets a school admin choose the ayah, doa or hadits shown on a date
+func (h *Handler) PinDailyContent(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+	if user.Role != "admin" || user.SchoolID == 0 {
+		return c.Redirect(http.StatusSeeOther, "/user/dashboard")
+	}
+
+	pin := &models.DailyContentPin{
+		SchoolID:  user.SchoolID,
+		Date:      c.FormValue("date"),
+		Kind:      c.FormValue("kind"),
+		Ref:       c.FormValue("ref"),
+		CreatedBy: user.ID,
+	}
+	if pin.Kind == "doa" {
+		pin.Ref = c.FormValue("doa_ref")
+	}
+	if err := h.DailyContentService.Pin(pin); err != nil {
+		return c.Redirect(http.StatusSeeOther, "/school/admin?error="+url.QueryEscape("Gagal menyematkan konten: "+err.Error()))
+	}
+	return c.Redirect(http.StatusSeeOther, "/school/admin?success=Konten harian disematkan")
+}
+
+func (h *Handler) UnpinDailyContent(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+	if user.Role != "admin" || user.SchoolID == 0 {
+		return c.Redirect(http.StatusSeeOther, "/user/dashboard")
+	}
+
+	id, _ := strconv.Atoi(c.Param("id"))
+	if err := h.DailyContentService.Unpin(user.SchoolID, id); err != nil {
+		return c.Redirect(http.StatusSeeOther, "/school/admin?error=Gagal menghapus sematan")
+	}
+	return c.Redirect(http.StatusSeeOther, "/school/admin?success=Sematan dihapus")
+}
@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+	"github.com/ramadhan/amaliah-monitoring/internal/services"
+)
+
+// entryWindow is the date picker of an entry page
+type entryWindow struct {
+	Date         string
+	MinDate      string
+	Today        string
+	LateApproval bool
+	Notice       string // why the selected date cannot be changed directly
+}
+
+// entryWindow returns the date an entry page shows: the ?date= query when the
+// school's policy still accepts it, today otherwise
+func (h *Handler) entryWindow(c echo.Context, user *models.User, now time.Time) entryWindow {
+	policy := h.EntryPolicyService.Policy(user)
+	w := entryWindow{
+		Date:         now.Format("2006-01-02"),
+		MinDate:      services.EarliestEntryDate(policy, now),
+		Today:        now.Format("2006-01-02"),
+		LateApproval: policy.LateApproval,
+	}
+	if d := c.QueryParam("date"); d != "" {
+		if _, err := services.CheckEntryDate(policy, d, now); err == nil {
+			w.Date = d
+		}
+	}
+
+	late, err := h.EntryPolicyService.Check(user, w.Date, now)
+	if err != nil {
+		w.Notice = err.Error()
+	} else if late {
+		w.Notice = "Perubahan pada tanggal ini akan dikirim ke guru untuk disetujui"
+	}
+	return w
+}
+
+// entryRedirect sends the student back to page on date, with an optional
+// error or success message
+func entryRedirect(c echo.Context, page, date, key, msg string) error {
+	q := url.Values{}
+	if msg != "" {
+		q.Set(key, msg)
+	}
+	if date != "" && date != time.Now().Format("2006-01-02") {
+		q.Set("date", date)
+	}
+	if len(q) > 0 {
+		page += "?" + q.Encode()
+	}
+	return c.Redirect(http.StatusSeeOther, page)
+}
+
+type lateEntryView struct {
+	*models.LateEntry
+	TypeLabel string
+	Summary   string
+}
+
+// ShowEntryReview lists late entries waiting for approval and the dates the
+// teacher has locked
+func (h *Handler) ShowEntryReview(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+	if !services.IsTeacher(user.Role) {
+		return c.Redirect(http.StatusSeeOther, "/user/dashboard")
+	}
+	if !services.HasSchoolScope(user) {
+		return c.Redirect(http.StatusSeeOther, "/user/dashboard?error=Anda belum terdaftar di sekolah")
+	}
+
+	class := c.QueryParam("class")
+	pending, _ := h.EntryPolicyService.Repo.GetPendingLateEntries(teacherSchool(user), class)
+	views := make([]lateEntryView, 0, len(pending))
+	for _, e := range pending {
+		views = append(views, lateEntryView{
+			LateEntry: e,
+			TypeLabel: services.LateEntryTypeLabel(e.Type),
+			Summary:   h.EntryPolicyService.Describe(e),
+		})
+	}
+
+	today := time.Now()
+	locks, _ := h.EntryPolicyService.Repo.GetLocks(teacherSchool(user), today.AddDate(0, 0, -30).Format("2006-01-02"))
+	classes, _ := h.UserRepo.GetClassesBySchool(teacherSchool(user))
+
+	return c.Render(http.StatusOK, "school/entries.html", map[string]interface{}{
+		"Title":       "Persetujuan & Penguncian",
+		"User":        user,
+		"Pending":     views,
+		"Locks":       locks,
+		"LockSources": services.EntryLockSources,
+		"Policy":      h.EntryPolicyService.Policy(user),
+		"Classes":     classes,
+		"Class":       class,
+		"Today":       today.Format("2006-01-02"),
+		"Error":       c.QueryParam("error"),
+		"Success":     c.QueryParam("success"),
+	})
+}
+
+func (h *Handler) ApproveLateEntry(c echo.Context) error {
+	return h.reviewLateEntry(c, true)
+}
+
+func (h *Handler) RejectLateEntry(c echo.Context) error {
+	return h.reviewLateEntry(c, false)
+}
+
+func (h *Handler) reviewLateEntry(c echo.Context, approve bool) error {
+	user := c.Get("user").(*models.User)
+	if !services.IsTeacher(user.Role) {
+		return c.Redirect(http.StatusSeeOther, "/user/dashboard")
+	}
+	back := "/school/entries?class=" + url.QueryEscape(c.FormValue("class"))
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.Redirect(http.StatusSeeOther, back+"&error=ID tidak valid")
+	}
+
+	var e *models.LateEntry
+	if approve {
+		e, err = h.EntryPolicyService.Approve(user, id)
+	} else {
+		e, err = h.EntryPolicyService.Reject(user, id, c.FormValue("note"))
+	}
+	if err != nil {
+		msg := err.Error()
+		if err == sql.ErrNoRows {
+			msg = "Pengajuan tidak ditemukan"
+		}
+		return c.Redirect(http.StatusSeeOther, back+"&error="+url.QueryEscape(msg))
+	}
+
+	msg := fmt.Sprintf("%s %s tanggal %s ditolak", services.LateEntryTypeLabel(e.Type), e.StudentName, e.Date)
+	if approve {
+		msg = fmt.Sprintf("%s %s tanggal %s disetujui", services.LateEntryTypeLabel(e.Type), e.StudentName, e.Date)
+	}
+	return c.Redirect(http.StatusSeeOther, back+"&success="+url.QueryEscape(msg))
+}
+
+// SignOffEntries locks a date for a class after the teacher has checked it
+func (h *Handler) SignOffEntries(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+	if !services.IsTeacher(user.Role) {
+		return c.Redirect(http.StatusSeeOther, "/user/dashboard")
+	}
+	if !services.HasSchoolScope(user) {
+		return c.Redirect(http.StatusSeeOther, "/user/dashboard?error=Anda belum terdaftar di sekolah")
+	}
+	class := c.FormValue("class")
+	back := "/school/entries?class=" + url.QueryEscape(class)
+
+	date := c.FormValue("date")
+	if date > time.Now().Format("2006-01-02") {
+		return c.Redirect(http.StatusSeeOther, back+"&error="+url.QueryEscape(services.ErrEntryFutureDate.Error()))
+	}
+	if err := h.EntryPolicyService.Lock(user, class, date, "signoff"); err != nil {
+		return c.Redirect(http.StatusSeeOther, back+"&error="+url.QueryEscape(err.Error()))
+	}
+	return c.Redirect(http.StatusSeeOther, back+"&success="+url.QueryEscape("Data tanggal "+date+" disahkan dan dikunci"))
+}
+
+// UnlockEntries removes a lock so students can edit the date again
+func (h *Handler) UnlockEntries(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+	if !services.IsTeacher(user.Role) {
+		return c.Redirect(http.StatusSeeOther, "/user/dashboard")
+	}
+	if !services.HasSchoolScope(user) {
+		return c.Redirect(http.StatusSeeOther, "/user/dashboard?error=Anda belum terdaftar di sekolah")
+	}
+	id, _ := strconv.Atoi(c.Param("id"))
+	if err := h.EntryPolicyService.Unlock(user, id); err != nil {
+		return c.Redirect(http.StatusSeeOther, "/school/entries?error=Kunci tidak ditemukan")
+	}
+	return c.Redirect(http.StatusSeeOther, "/school/entries?success=Kunci tanggal dibuka")
+}
+
+// ExportEntries downloads the daily report of the teacher's school and locks
+// the exported date
+func (h *Handler) ExportEntries(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+	if !services.IsTeacher(user.Role) {
+		return c.Redirect(http.StatusSeeOther, "/user/dashboard")
+	}
+	if !services.HasSchoolScope(user) {
+		return c.Redirect(http.StatusSeeOther, "/user/dashboard?error=Anda belum terdaftar di sekolah")
+	}
+	class := c.QueryParam("class")
+	date := c.QueryParam("date")
+	if date == "" {
+		date = time.Now().Format("2006-01-02")
+	}
+	back := "/school/entries?class=" + url.QueryEscape(class)
+	if date > time.Now().Format("2006-01-02") {
+		return c.Redirect(http.StatusSeeOther, back+"&error="+url.QueryEscape(services.ErrEntryFutureDate.Error()))
+	}
+
+	f, err := h.ExportService.GenerateDailyReportExcel(teacherSchool(user), date, class)
+	if err != nil {
+		return c.Redirect(http.StatusSeeOther, back+"&error=Gagal membuat laporan")
+	}
+	if err := h.EntryPolicyService.Lock(user, class, date, "export"); err != nil {
+		return c.Redirect(http.StatusSeeOther, back+"&error="+url.QueryEscape(err.Error()))
+	}
+
+	name := "Semua_Kelas"
+	if class != "" {
+		name = url.PathEscape(class)
+	}
+	c.Response().Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	c.Response().Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=Laporan_Harian_%s_%s.xlsx", name, date))
+	return f.Write(c.Response().Writer)
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+	"github.com/ramadhan/amaliah-monitoring/internal/services"
+)
+
+// fastingCheckinView is today's sahur and iftar for the fasting page and
+// the dashboard
+type fastingCheckinView struct {
+	Times *services.FastingTimes // nil without a schedule
+	Sahur *models.FastingCheckin
+	Iftar *models.FastingCheckin
+	// Location is the zone the times are shown in
+	Location *time.Location
+	Reminder string
+	// Unavailable says why check-ins cannot be made today
+	Unavailable string
+}
+
+func (h *Handler) fastingCheckins(user *models.User, now time.Time, offset int) fastingCheckinView {
+	v := fastingCheckinView{Location: services.UserLocation(user)}
+	now = now.In(v.Location)
+	today := now.Format("2006-01-02")
+
+	done, _ := h.FastingCheckinService.Repo.GetByUserAndDate(user.ID, today)
+	v.Sahur, v.Iftar = done["sahur"], done["iftar"]
+
+	ft, err := h.FastingCheckinService.Times(user, now, offset)
+	if err != nil {
+		v.Unavailable = err.Error()
+		return v
+	}
+	v.Times = &ft
+	if !h.FastingCheckinService.FastingDay(user, now, offset) {
+		v.Unavailable = services.ErrCheckinNoFast.Error()
+		return v
+	}
+
+	// No reminders on a day the student is not fasting
+	if h.UdzurService.Excused(user.ID, today, today)[today] {
+		return v
+	}
+	if f, err := h.FastingRepo.GetByUserAndDate(user.ID, today); err == nil && !f.Status.Done() {
+		return v
+	}
+	v.Reminder = services.CheckinReminder(ft, now, done)
+	return v
+}
+
+// CheckInFasting records a sahur or iftar at the current time
+func (h *Handler) CheckInFasting(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+
+	meal := c.FormValue("meal")
+	checkin, err := h.FastingCheckinService.CheckIn(user, meal, h.hijriOffset(user), time.Now())
+	if err != nil {
+		return c.Redirect(http.StatusSeeOther, "/user/fasting?error="+url.QueryEscape(err.Error()))
+	}
+
+	h.LiveDashboardService.Record(user, "fasting", "check-in "+services.FastingMealLabel(meal))
+
+	msg := services.FastingMealLabel(meal) + " tercatat pukul " + checkin.ClockIn(services.UserLocation(user))
+	switch {
+	case checkin.Points > 0:
+		msg += ", sebelum imsak (+" + strconv.Itoa(checkin.Points) + " poin)"
+	case meal == "sahur" && !checkin.OnTime:
+		msg += ", sudah lewat imsak"
+	case meal == "iftar" && checkin.OnTime:
+		msg += ", tepat waktu"
+	}
+	return c.Redirect(http.StatusSeeOther, "/user/fasting?success="+url.QueryEscape(msg))
+}
+
+// UndoFastingCheckin removes today's check-in of a meal
+func (h *Handler) UndoFastingCheckin(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+
+	meal := c.Param("meal")
+	if err := h.FastingCheckinService.Undo(user, meal, time.Now()); err != nil {
+		return c.Redirect(http.StatusSeeOther, "/user/fasting?error="+url.QueryEscape(err.Error()))
+	}
+	return c.Redirect(http.StatusSeeOther, "/user/fasting?success="+url.QueryEscape(services.FastingMealLabel(meal)+" dibatalkan"))
+}
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+	"github.com/ramadhan/amaliah-monitoring/internal/services"
+)
+
+type favoriteView struct {
+	*models.Favorite
+	URL       string
+	TypeLabel string
+}
+
+func favoriteViews(items []*models.Favorite) []favoriteView {
+	views := make([]favoriteView, len(items))
+	for i, f := range items {
+		views[i] = favoriteView{Favorite: f, URL: services.ContentURL(f.ContentType, f.ContentID, f.Title)}
+		for _, t := range services.FavoriteTypes {
+			if t.Value == f.ContentType {
+				views[i].TypeLabel = t.Label
+			}
+		}
+	}
+	return views
+}
+
+// favoritesBack returns to the collection the user was looking at
+func favoritesBack(c echo.Context, param, msg string) error {
+	target := "/user/favorites?collection=" + url.QueryEscape(c.FormValue("collection"))
+	if msg != "" {
+		target += "&" + param + "=" + url.QueryEscape(msg)
+	}
+	return c.Redirect(http.StatusSeeOther, target)
+}
+
+// ShowFavorites lists the user's favorites, optionally one collection only
+func (h *Handler) ShowFavorites(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+
+	collection := c.QueryParam("collection")
+	all, _ := h.FavoriteService.Repo.GetByUser(user.ID)
+	collections, _ := h.FavoriteService.Repo.GetCollections(user.ID)
+
+	items := all
+	if collection != "" {
+		id, _ := strconv.Atoi(collection)
+		items = nil
+		for _, f := range all {
+			if f.CollectionID == id {
+				items = append(items, f)
+			}
+		}
+	}
+
+	return c.Render(http.StatusOK, "user/favorites.html", map[string]interface{}{
+		"Title":       "Favorit Saya",
+		"User":        user,
+		"Favorites":   favoriteViews(items),
+		"Total":       len(all),
+		"Collections": collections,
+		"Collection":  collection,
+		"Error":       c.QueryParam("error"),
+		"Success":     c.QueryParam("success"),
+	})
+}
+
+// FavoritesAPI returns every favorite and collection as JSON
+func (h *Handler) FavoritesAPI(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+
+	favorites, err := h.FavoriteService.Repo.GetByUser(user.ID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Gagal memuat favorit"})
+	}
+	collections, _ := h.FavoriteService.Repo.GetCollections(user.ID)
+	if favorites == nil {
+		favorites = []*models.Favorite{}
+	}
+	if collections == nil {
+		collections = []*models.FavoriteCollection{}
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"favorites":   favorites,
+		"collections": collections,
+	})
+}
+
+// ToggleFavorite saves or removes a doa, hadits or ayah from the reader pages
+func (h *Handler) ToggleFavorite(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+
+	favorited, err := h.FavoriteService.Toggle(user.ID, c.FormValue("type"), c.FormValue("id"))
+	if err != nil {
+		if err == services.ErrInvalidFavorite {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Gagal menyimpan favorit"})
+	}
+	return c.JSON(http.StatusOK, map[string]bool{"favorited": favorited})
+}
+
+func (h *Handler) DeleteFavorite(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+
+	id, _ := strconv.Atoi(c.Param("id"))
+	if err := h.FavoriteService.Repo.Delete(user.ID, id); err != nil {
+		return favoritesBack(c, "error", "Gagal menghapus favorit")
+	}
+	return favoritesBack(c, "success", "Favorit dihapus")
+}
+
+func (h *Handler) MoveFavorite(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+
+	id, _ := strconv.Atoi(c.Param("id"))
+	step := 1
+	if c.FormValue("dir") == "up" {
+		step = -1
+	}
+	if err := h.FavoriteService.Move(user.ID, id, step); err != nil {
+		return favoritesBack(c, "error", "Gagal mengubah urutan")
+	}
+	return favoritesBack(c, "", "")
+}
+
+func (h *Handler) SetFavoriteCollection(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+
+	id, _ := strconv.Atoi(c.Param("id"))
+	collectionID, _ := strconv.Atoi(c.FormValue("collection_id"))
+	if err := h.FavoriteService.SetCollection(user.ID, id, collectionID); err != nil {
+		return favoritesBack(c, "error", "Gagal memindahkan favorit")
+	}
+	return favoritesBack(c, "success", "Favorit dipindahkan")
+}
+
+func (h *Handler) CreateFavoriteCollection(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+
+	col, err := h.FavoriteService.CreateCollection(user.ID, c.FormValue("name"))
+	if err != nil {
+		msg := "Nama koleksi sudah dipakai"
+		if err == services.ErrInvalidCollectionName {
+			msg = "Nama koleksi harus 1-100 karakter"
+		}
+		return c.Redirect(http.StatusSeeOther, "/user/favorites?error="+url.QueryEscape(msg))
+	}
+	return c.Redirect(http.StatusSeeOther, "/user/favorites?collection="+strconv.Itoa(col.ID)+"&success=Koleksi dibuat")
+}
+
+func (h *Handler) DeleteFavoriteCollection(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+
+	id, _ := strconv.Atoi(c.Param("id"))
+	if err := h.FavoriteService.DeleteCollection(user.ID, id); err != nil {
+		return c.Redirect(http.StatusSeeOther, "/user/favorites?error=Koleksi tidak ditemukan")
+	}
+	return c.Redirect(http.StatusSeeOther, "/user/favorites?success=Koleksi dihapus, isinya dipindahkan ke Favorit")
+}
+
+// favoriteIDs marks which items on a reader page are already favorites
+func (h *Handler) favoriteIDs(user *models.User, contentType string) map[string]bool {
+	ids, _ := h.FavoriteService.Repo.GetIDs(user.ID, contentType)
+	return ids
+}
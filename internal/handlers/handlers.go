@@ -6,14 +6,18 @@ import (
 	"io"
 	"math"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
+	"github.com/ramadhan/amaliah-monitoring/internal/hijri"
 	"github.com/ramadhan/amaliah-monitoring/internal/models"
+	"github.com/ramadhan/amaliah-monitoring/internal/mushaf"
 	"github.com/ramadhan/amaliah-monitoring/internal/repository"
 	"github.com/ramadhan/amaliah-monitoring/internal/services"
 	"github.com/ramadhan/amaliah-monitoring/internal/utils"
@@ -27,7 +31,7 @@ type Handler struct {
 	FastingRepo      *repository.FastingRepository
 	QuranRepo        *repository.QuranRepository
 	AmaliahRepo      *repository.AmaliahRepository
-	MuslimAPI        *services.MuslimAPIService
+	Content          services.ContentProvider
 	ImsakiyahService *services.ImsakiyahService
 	ShalatService    *services.ShalatService
 	AdminService     *services.AdminService
@@ -37,6 +41,23 @@ type Handler struct {
 	StatisticsService *services.StatisticsService
 	CertificateService *services.CertificateService
 	ClassRepo        *repository.ClassRepository
+	SyncService      *services.SyncService
+	CalendarService  *services.CalendarService
+	LiveDashboardService *services.LiveDashboardService
+	ScheduleCache    *services.ScheduleCache
+	KhatamService    *services.KhatamService
+	TahfidzService   *services.TahfidzService
+	SetoranService   *services.SetoranService
+	SearchService    *services.SearchService
+	DailyContentService *services.DailyContentService
+	FavoriteService  *services.FavoriteService
+	PrayerTimingService *services.PrayerTimingService
+	SunnahService    *services.SunnahService
+	EntryPolicyService *services.EntryPolicyService
+	UdzurService     *services.UdzurService
+	QadhaService     *services.QadhaService
+	SunnahFastingService *services.SunnahFastingService
+	FastingCheckinService *services.FastingCheckinService
 }
 
 func NewHandler(db *sql.DB) *Handler {
@@ -47,6 +68,48 @@ func NewHandler(db *sql.DB) *Handler {
 	amaliahRepo := repository.NewAmaliahRepository(db)
 	badgeRepo := repository.NewBadgeRepository(db)
 	classRepo := repository.NewClassRepository(db)
+	syncRepo := repository.NewSyncRepository(db)
+
+	// equran.id responses are shared by the shalat and imsakiyah services
+	scheduleCache := services.NewScheduleCache(repository.NewCacheRepository(db))
+	prayerCalculator := services.NewPrayerCalculator(services.LookupKabkota)
+	shalatService := services.NewShalatService()
+	shalatService.Cache = scheduleCache
+	shalatService.Calculator = prayerCalculator
+	shalatService.LocalFirst = services.LocalPrayerTimesFirst()
+	imsakiyahService := services.NewImsakiyahService()
+	imsakiyahService.Cache = scheduleCache
+	imsakiyahService.Calculator = prayerCalculator
+	imsakiyahService.LocalFirst = shalatService.LocalFirst
+	imsakiyahService.RamadhanStart = services.RamadhanStartFromEnv()
+	khatamService := services.NewKhatamService(quranRepo)
+	content := services.NewContentProvider()
+	searchService := services.NewSearchService(repository.NewSearchRepository(db), content)
+	setoranRepo := repository.NewSetoranRepository(db)
+	exportService := services.NewExportService(userRepo, prayerRepo, fastingRepo, quranRepo, amaliahRepo)
+	exportService.SetoranRepo = setoranRepo
+	prayerTimingService := services.NewPrayerTimingService(repository.NewPrayerTimingRepository(db), shalatService)
+	exportService.PrayerTimingRepo = prayerTimingService.Repo
+	sunnahRepo := repository.NewSunnahPrayerRepository(db)
+	exportService.SunnahRepo = sunnahRepo
+	udzurRepo := repository.NewUdzurRepository(db)
+	exportService.UdzurRepo = udzurRepo
+	sunnahFastingService := services.NewSunnahFastingService(repository.NewSunnahFastRepository(db), udzurRepo)
+	exportService.SunnahFastRepo = sunnahFastingService.Repo
+	fastingCheckinService := services.NewFastingCheckinService(repository.NewFastingCheckinRepository(db), fastingRepo, udzurRepo, userRepo, imsakiyahService, shalatService)
+	fastingCheckinService.SunnahFastRepo = sunnahFastingService.Repo
+	exportService.FastingCheckinRepo = fastingCheckinService.Repo
+	statisticsService := services.NewStatisticsService(prayerRepo, amaliahRepo, fastingRepo, userRepo)
+	statisticsService.SunnahRepo = sunnahRepo
+	syncService := services.NewSyncService(syncRepo, userRepo, prayerRepo, fastingRepo, quranRepo, amaliahRepo)
+	syncService.PrayerTiming = prayerTimingService
+	badgeService := services.NewBadgeService(badgeRepo, prayerRepo, amaliahRepo, quranRepo, khatamService)
+	badgeService.SunnahFastRepo = sunnahFastingService.Repo
+	entryPolicyService := services.NewEntryPolicyService(repository.NewEntryPolicyRepository(db))
+	entryPolicyService.Sync = syncService
+	syncService.Policy = entryPolicyService
+	udzurService := services.NewUdzurService(udzurRepo)
+	udzurService.Policy = entryPolicyService
 
 	return &Handler{
 		DB:               db,
@@ -55,16 +118,33 @@ func NewHandler(db *sql.DB) *Handler {
 		FastingRepo:      fastingRepo,
 		QuranRepo:        quranRepo,
 		AmaliahRepo:      amaliahRepo,
-		MuslimAPI:        services.NewMuslimAPIService(),
-		ImsakiyahService: services.NewImsakiyahService(),
-		ShalatService:    services.NewShalatService(),
+		Content:          &services.IndexingContentProvider{ContentProvider: content, Search: searchService},
+		ImsakiyahService: imsakiyahService,
+		ShalatService:    shalatService,
 		AdminService:     services.NewAdminService(userRepo),
-		ExportService:    services.NewExportService(userRepo, prayerRepo, fastingRepo, quranRepo, amaliahRepo),
+		ExportService:    exportService,
 		BadgeRepo:        badgeRepo,
-		BadgeService:     services.NewBadgeService(badgeRepo, prayerRepo, amaliahRepo, quranRepo),
-		StatisticsService: services.NewStatisticsService(prayerRepo, amaliahRepo, fastingRepo, userRepo),
+		BadgeService:     badgeService,
+		StatisticsService: statisticsService,
 		CertificateService: services.NewCertificateService(),
 		ClassRepo:        classRepo,
+		SyncService:      syncService,
+		CalendarService:  services.NewCalendarService(shalatService),
+		ScheduleCache:    scheduleCache,
+		LiveDashboardService: services.NewLiveDashboardService(services.NewEventBus(), userRepo, prayerRepo, fastingRepo, quranRepo, amaliahRepo),
+		KhatamService:    khatamService,
+		TahfidzService:   services.NewTahfidzService(repository.NewHafalanRepository(db)),
+		SetoranService:   services.NewSetoranService(setoranRepo, userRepo),
+		SearchService:    searchService,
+		DailyContentService: services.NewDailyContentService(repository.NewDailyContentRepository(db), content),
+		FavoriteService:  services.NewFavoriteService(repository.NewFavoriteRepository(db), content),
+		PrayerTimingService: prayerTimingService,
+		SunnahService:    services.NewSunnahService(sunnahRepo),
+		EntryPolicyService: entryPolicyService,
+		UdzurService:     udzurService,
+		QadhaService:     services.NewQadhaService(repository.NewQadhaRepository(db), fastingRepo, udzurRepo, userRepo),
+		SunnahFastingService: sunnahFastingService,
+		FastingCheckinService: fastingCheckinService,
 	}
 }
 
@@ -99,7 +179,7 @@ func (h *Handler) ShowJadwal(c echo.Context) error {
 	if provinsi != "" && kabkota != "" {
 		now := time.Now()
 		shalatData, _ = h.ShalatService.GetShalat(provinsi, kabkota, int(now.Month()), now.Year())
-		imsakiyahData, _ = h.ImsakiyahService.GetImsakiyah(provinsi, kabkota)
+		imsakiyahData, _ = h.ImsakiyahService.GetImsakiyah(provinsi, kabkota, 0)
 
 		if shalatData != nil {
 			dayOfMonth := now.Day()
@@ -111,10 +191,10 @@ func (h *Handler) ShowJadwal(c echo.Context) error {
 			}
 		}
 
-		if imsakiyahData != nil {
-			dayOfMonth := now.Day()
+		// Imsakiyah rows are numbered by day of Ramadhan
+		if ramadhanDay, ok := hijri.RamadhanDay(now, 0); ok && imsakiyahData != nil {
 			for _, s := range imsakiyahData.Imsakiyah {
-				if s.Tanggal == dayOfMonth {
+				if s.Tanggal == ramadhanDay {
 					todayImsakiyah = &s
 					break
 				}
@@ -133,6 +213,7 @@ func (h *Handler) ShowJadwal(c echo.Context) error {
 		"ImsakiyahData":  imsakiyahData,
 		"TodayShalat":    todayShalat,
 		"TodayImsakiyah": todayImsakiyah,
+		"CalendarURLs":   h.calendarURLs(c, nil, provinsi, kabkota),
 	}
 
 	user := c.Get("user")
@@ -178,7 +259,7 @@ func (h *Handler) ShowUserJadwal(c echo.Context) error {
 	if provinsi != "" && kabkota != "" {
 		now := time.Now()
 		shalatData, _ = h.ShalatService.GetShalat(provinsi, kabkota, int(now.Month()), now.Year())
-		imsakiyahData, _ = h.ImsakiyahService.GetImsakiyah(provinsi, kabkota)
+		imsakiyahData, _ = h.ImsakiyahService.GetImsakiyah(provinsi, kabkota, h.hijriOffset(user))
 
 		if shalatData != nil {
 			dayOfMonth := now.Day()
@@ -190,10 +271,10 @@ func (h *Handler) ShowUserJadwal(c echo.Context) error {
 			}
 		}
 
-		if imsakiyahData != nil {
-			dayOfMonth := now.Day()
+		// Imsakiyah rows are numbered by day of Ramadhan
+		if ramadhanDay, ok := hijri.RamadhanDay(now, h.hijriOffset(user)); ok && imsakiyahData != nil {
 			for _, s := range imsakiyahData.Imsakiyah {
-				if s.Tanggal == dayOfMonth {
+				if s.Tanggal == ramadhanDay {
 					todayImsakiyah = &s
 					break
 				}
@@ -213,6 +294,9 @@ func (h *Handler) ShowUserJadwal(c echo.Context) error {
 		"ImsakiyahData":  imsakiyahData,
 		"TodayShalat":    todayShalat,
 		"TodayImsakiyah": todayImsakiyah,
+		"CalendarURLs":   h.calendarURLs(c, user, provinsi, kabkota),
+		"Error":          c.QueryParam("error"),
+		"Success":        c.QueryParam("success"),
 	}
 
 	return c.Render(http.StatusOK, "user/jadwal.html", data)
@@ -392,21 +476,7 @@ func (h *Handler) UserDashboard(c echo.Context) error {
 
 	prayerCompleted := 0
 	if prayer != nil {
-		if prayer.Subuh == "jamaah" || prayer.Subuh == "sendiri" {
-			prayerCompleted++
-		}
-		if prayer.Dzuhur == "jamaah" || prayer.Dzuhur == "sendiri" {
-			prayerCompleted++
-		}
-		if prayer.Ashar == "jamaah" || prayer.Ashar == "sendiri" {
-			prayerCompleted++
-		}
-		if prayer.Maghrib == "jamaah" || prayer.Maghrib == "sendiri" {
-			prayerCompleted++
-		}
-		if prayer.Isya == "jamaah" || prayer.Isya == "sendiri" {
-			prayerCompleted++
-		}
+		prayerCompleted = prayer.Completed()
 	}
 
 	fasting, _ := h.FastingRepo.GetTodayFasting(user.ID)
@@ -438,43 +508,52 @@ func (h *Handler) UserDashboard(c echo.Context) error {
 		BestAmaliah:   bestAmaliah,
 	}
 
+	now := time.Now()
+	offset := h.hijriOffset(user)
+	ramadhan := hijri.RamadhanFor(now, offset)
+	ramadhanDay, inRamadhan := ramadhan.Day(now)
+
 	var todaySchedule *models.ImsakiyahSchedule
 	var imsakiyahData *models.ImsakiyahData
 	if user.Provinsi != "" && user.Kabkota != "" {
-		dayOfMonth := time.Now().Day()
-		todaySchedule, _ = h.ImsakiyahService.GetTodaySchedule(user.Provinsi, user.Kabkota, dayOfMonth)
-		imsakiyahData, _ = h.ImsakiyahService.GetImsakiyah(user.Provinsi, user.Kabkota)
+		todaySchedule, _ = h.FastingCheckinService.Schedule(user, now, offset)
+		imsakiyahData, _ = h.ImsakiyahService.GetImsakiyah(user.Provinsi, user.Kabkota, offset)
+	}
+
+	qadha, _ := h.QadhaService.Summary(user.ID, offset, now)
+
+	// Sahur and iftar reminders, only given on fasting days
+	var fastingReminder string
+	if todaySchedule != nil {
+		fastingReminder = h.fastingCheckins(user, now, offset).Reminder
 	}
 
 	// Check and get badges
 	newBadges, _ := h.BadgeService.CheckAndAwardBadges(user.ID)
 	userBadges, _ := h.BadgeRepo.GetUserBadges(user.ID)
 
-	// NEW: Prepare Chart Data (Quran Monthly Progress)
+	// NEW: Prepare Chart Data (Quran progress per day of Ramadhan)
 	type DailyQuranStat struct {
-		Day    int
+		Day    int // day of Ramadhan
 		Target int
 		Actual int
 	}
 	var quranChartData []DailyQuranStat
-	
-	now := time.Now()
-	// Get start and end of current month
-	startOfMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
-	nextMonth := now.AddDate(0, 1, 0)
-	lastDayOfMonth := time.Date(nextMonth.Year(), nextMonth.Month(), 0, 0, 0, 0, 0, nextMonth.Location())
-	
-	startOfMonthStr := startOfMonth.Format("2006-01-02") 
-	endOfMonthStr := lastDayOfMonth.Format("2006-01-02")
 
-	// Get readings for the month
-	monthReadings, _ := h.QuranRepo.GetByDateRange(user.ID, startOfMonthStr, endOfMonthStr)
+	// Get readings for Ramadhan
+	monthReadings, _ := h.QuranRepo.GetByDateRange(user.ID, ramadhan.Start.Format("2006-01-02"), ramadhan.End().Format("2006-01-02"))
 	
-	// Map readings to date
+	// Map readings to Ramadhan day
 	readingsMap := make(map[int]int) // day -> pages
 	for _, r := range monthReadings {
-		t, _ := time.Parse("2006-01-02", r.Date)
-		readingsMap[t.Day()] += r.Pages
+		date := r.Date
+		if len(date) > 10 {
+			date = date[:10] // drivers may return DATE columns as timestamps
+		}
+		t, _ := time.ParseInLocation("2006-01-02", date, time.Local)
+		if d, ok := ramadhan.Day(t); ok {
+			readingsMap[d] += r.Pages
+		}
 	}
 
 	// Calculate Daily Target
@@ -484,9 +563,8 @@ func (h *Handler) UserDashboard(c echo.Context) error {
 	}
 	dailyTarget := int(math.Ceil(604.0 / float64(targetKhatam)))
 
-	// Fill chart data for all days in month
-	daysInMonth := lastDayOfMonth.Day()
-	for d := 1; d <= daysInMonth; d++ {
+	// Fill chart data for all days of Ramadhan
+	for d := 1; d <= ramadhan.Days; d++ {
 		quranChartData = append(quranChartData, DailyQuranStat{
 			Day:    d,
 			Target: dailyTarget,
@@ -507,6 +585,10 @@ func (h *Handler) UserDashboard(c echo.Context) error {
 		location = "Indonesia"
 	}
 	dashboardDate := fmt.Sprintf("%s, %s", location, formattedDate)
+	hijriDate := hijri.FromTime(now, offset).String()
+	if inRamadhan {
+		hijriDate = fmt.Sprintf("Ramadhan hari ke-%d · %s", ramadhanDay, hijriDate)
+	}
 
 	// Get School Name, Code, and Status
 	schoolName := ""
@@ -540,6 +622,11 @@ func (h *Handler) UserDashboard(c echo.Context) error {
 		"NewBadges":       newBadges,
 		"QuranChartData":  quranChartData,
 		"DashboardDate":   dashboardDate,
+		"HijriDate":       hijriDate,
+		"RamadhanDay":     ramadhanDay,
+		"DailyContent":    h.dailyContent(user, now),
+		"Qadha":           qadha,
+		"FastingReminder": fastingReminder,
 		"SchoolName":      schoolName,
 		"SchoolCode":      schoolCode,
 
@@ -550,20 +637,21 @@ func (h *Handler) UserDashboard(c echo.Context) error {
 func (h *Handler) ShowPrayers(c echo.Context) error {
 	user := c.Get("user").(*models.User)
 
-	// Get today's prayer or create default
+	// Get the selected day's prayer (today by default) or create default
 	today := time.Now()
 	todayStr := today.Format("2006-01-02")
-	prayer, err := h.PrayerRepo.GetByUserAndDate(user.ID, todayStr)
+	entry := h.entryWindow(c, user, today)
+	prayer, err := h.PrayerRepo.GetByUserAndDate(user.ID, entry.Date)
 	if err != nil {
 		// Create default prayer entry
 		prayer = &models.Prayer{
 			UserID:  user.ID,
-			Date:    todayStr,
-			Subuh:   "belum",
-			Dzuhur:  "belum",
-			Ashar:   "belum",
-			Maghrib: "belum",
-			Isya:    "belum",
+			Date:    entry.Date,
+			Subuh:   models.PrayerPending,
+			Dzuhur:  models.PrayerPending,
+			Ashar:   models.PrayerPending,
+			Maghrib: models.PrayerPending,
+			Isya:    models.PrayerPending,
 		}
 	}
 
@@ -574,11 +662,14 @@ func (h *Handler) ShowPrayers(c echo.Context) error {
 	// Get month stats
 	startOfMonth := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, today.Location()).Format("2006-01-02")
 	monthStats, _ := h.PrayerRepo.GetPrayerStats(user.ID, startOfMonth, todayStr)
+	monthTiming, _ := h.PrayerTimingService.Repo.GetStats(user.ID, startOfMonth, todayStr)
+	todayTimings, _ := h.PrayerTimingService.Repo.GetByUserAndDate(user.ID, entry.Date)
 
-	// Format today's date for display
+	// Format the selected date for display
+	day, _ := time.ParseInLocation("2006-01-02", entry.Date, today.Location())
 	months := []string{"Januari", "Februari", "Maret", "April", "Mei", "Juni", "Juli", "Agustus", "September", "Oktober", "November", "Desember"}
 	days := []string{"Minggu", "Senin", "Selasa", "Rabu", "Kamis", "Jumat", "Sabtu"}
-	todayFormatted := days[int(today.Weekday())] + ", " + strconv.Itoa(today.Day()) + " " + months[today.Month()-1] + " " + strconv.Itoa(today.Year())
+	todayFormatted := days[int(day.Weekday())] + ", " + strconv.Itoa(day.Day()) + " " + months[day.Month()-1] + " " + strconv.Itoa(day.Year())
 
 	return c.Render(http.StatusOK, "user/prayers.html", map[string]interface{}{
 		"Title":        "Shalat",
@@ -586,8 +677,13 @@ func (h *Handler) ShowPrayers(c echo.Context) error {
 		"Prayer":       prayer,
 		"WeekPrayers":  weekPrayers,
 		"MonthStats":   monthStats,
+		"MonthTiming":  monthTiming,
+		"Timings":      todayTimings,
+		"Windows":      h.PrayerTimingService.Windows(user, entry.Date),
+		"Excused":      h.UdzurService.Excused(user.ID, entry.Date, entry.Date)[entry.Date],
 		"TodayDate":    todayFormatted,
-		"TodayDateISO": todayStr,
+		"TodayDateISO": entry.Date,
+		"Entry":        entry,
 		"Error":        c.QueryParam("error"),
 		"Success":      c.QueryParam("success"),
 	})
@@ -601,18 +697,47 @@ func (h *Handler) SavePrayers(c echo.Context) error {
 		date = time.Now().Format("2006-01-02")
 	}
 
-	subuh := c.FormValue("subuh")
-	dzuhur := c.FormValue("dzuhur")
-	ashar := c.FormValue("ashar")
-	maghrib := c.FormValue("maghrib")
-	isya := c.FormValue("isya")
+	after := &models.Prayer{}
+	for _, f := range []struct {
+		name   string
+		target *models.PrayerStatus
+	}{
+		{"subuh", &after.Subuh}, {"dzuhur", &after.Dzuhur}, {"ashar", &after.Ashar}, {"maghrib", &after.Maghrib}, {"isya", &after.Isya},
+	} {
+		status, err := models.ParsePrayerStatus(f.name, c.FormValue(f.name))
+		if err != nil {
+			return entryRedirect(c, "/user/prayers", date, "error", err.Error())
+		}
+		*f.target = status
+	}
+	subuh, dzuhur, ashar := string(after.Subuh), string(after.Dzuhur), string(after.Ashar)
+	maghrib, isya := string(after.Maghrib), string(after.Isya)
 
-	err := h.PrayerRepo.CreateOrUpdate(user.ID, date, subuh, dzuhur, ashar, maghrib, isya)
+	queued, err := h.EntryPolicyService.Admit(user, "prayer", date, models.SyncPrayerData{
+		Subuh: &subuh, Dzuhur: &dzuhur, Ashar: &ashar, Maghrib: &maghrib, Isya: &isya,
+	}, time.Now())
+	if err != nil {
+		return entryRedirect(c, "/user/prayers", date, "error", err.Error())
+	}
+	if queued {
+		return entryRedirect(c, "/user/prayers", date, "success", services.LateEntryQueuedMessage)
+	}
+
+	before, _ := h.PrayerRepo.GetByUserAndDate(user.ID, date)
+	timings, err := h.PrayerTimingService.Evaluate(user, date, before, after, time.Now())
+	if err != nil {
+		return entryRedirect(c, "/user/prayers", date, "error", err.Error())
+	}
+
+	err = h.PrayerRepo.CreateOrUpdate(user.ID, date, after.Subuh, after.Dzuhur, after.Ashar, after.Maghrib, after.Isya)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to save prayer"})
 	}
+	h.PrayerTimingService.Record(timings)
 
-	return c.Redirect(http.StatusSeeOther, "/user/prayers")
+	h.LiveDashboardService.Record(user, "prayer", "mengisi shalat")
+
+	return entryRedirect(c, "/user/prayers", date, "success", "Shalat tersimpan")
 }
 
 func (h *Handler) ShowFasting(c echo.Context) error {
@@ -621,23 +746,25 @@ func (h *Handler) ShowFasting(c echo.Context) error {
 	today := time.Now()
 	todayStr := today.Format("2006-01-02")
 
-	// Get or create today's fasting
-	fasting, err := h.FastingRepo.GetTodayFasting(user.ID)
+	// Get or create the selected day's fasting (today by default)
+	entry := h.entryWindow(c, user, today)
+	fasting, err := h.FastingRepo.GetByUserAndDate(user.ID, entry.Date)
 	if err != nil {
 		fasting = &models.Fasting{
 			UserID: user.ID,
-			Date:   todayStr,
-			Status: "puasa",
+			Date:   entry.Date,
+			Status: models.FastingDone,
 			Reason: "",
 		}
 	}
 
-	// Get current month fasting data
-	startOfMonth := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, today.Location())
-	startOfMonthStr := startOfMonth.Format("2006-01-02")
-	endOfMonthStr := utils.GetEndOfMonth()
+	// The calendar covers Ramadhan, which straddles two Gregorian months
+	offset := h.hijriOffset(user)
+	ramadhan := hijri.RamadhanFor(today, offset)
+	startStr := ramadhan.Start.Format("2006-01-02")
+	endStr := ramadhan.End().Format("2006-01-02")
 
-	monthFastings, _ := h.FastingRepo.GetByUserAndDateRange(user.ID, startOfMonthStr, endOfMonthStr)
+	monthFastings, _ := h.FastingRepo.GetByUserAndDateRange(user.ID, startStr, endStr)
 
 	// Create fasting map for quick lookup
 	fastingMap := make(map[string]*models.Fasting)
@@ -645,61 +772,65 @@ func (h *Handler) ShowFasting(c echo.Context) error {
 		fastingMap[f.Date] = f
 	}
 
-	// Build calendar days
 	// Build calendar days
 	type CalendarDay struct {
-		Day     int
+		Day     int // day of Ramadhan
 		Date    string
+		Masehi  string
 		HasData bool
-		Status  string
+		Status  models.FastingStatus
 		Reason  string
 		IsToday bool
 		IsPast  bool
+		Excused bool // udzur; neither fasting nor a missed day
 	}
 
 	var calendarDays []CalendarDay
-	emptyDays := int(startOfMonth.Weekday())
-
-	// Get last day of month
-	nextMonth := today.AddDate(0, 1, 0)
-	lastDay := time.Date(nextMonth.Year(), nextMonth.Month(), 0, 0, 0, 0, 0, nextMonth.Location()).Day()
+	emptyDays := int(ramadhan.Start.Weekday())
 
     // Calculate stats manually for "Assumed Puasa"
     notFastingCount := 0
+    excusedCount := 0
+    excused := h.UdzurService.Excused(user.ID, startStr, endStr)
     daysPassed := 0
-    if today.Month() == startOfMonth.Month() {
-        daysPassed = today.Day()
-    } else if today.After(startOfMonth) {
-         // If today is after this month (viewing past month?), handle logic. 
-         // But here startOfMonth is based on today. So we are viewing current month.
-         daysPassed = today.Day()
-    }
+	if n, ok := ramadhan.Day(today); ok {
+		daysPassed = n
+	} else if todayStr > endStr {
+		daysPassed = ramadhan.Days
+	}
 
-	for day := 1; day <= lastDay; day++ {
-		dateStr := time.Date(today.Year(), today.Month(), day, 0, 0, 0, 0, today.Location()).Format("2006-01-02")
+	for day := 1; day <= ramadhan.Days; day++ {
+		date := ramadhan.Date(day)
+		dateStr := date.Format("2006-01-02")
         isPast := dateStr < todayStr
-        isToday := dateStr == todayStr // Logic check: today is dateStr?
+        isToday := dateStr == todayStr
 
 		dayData := CalendarDay{
 			Day:     day,
 			Date:    dateStr,
+			Masehi:  fmt.Sprintf("%d/%d", date.Day(), int(date.Month())),
 			HasData: false,
 			IsToday: isToday,
 			IsPast:  isPast,
 		}
 
-		if f, exists := fastingMap[dateStr]; exists {
+		if excused[dateStr] && (isPast || isToday) {
+			dayData.Excused = true
+			if day <= daysPassed {
+				excusedCount++
+			}
+		} else if f, exists := fastingMap[dateStr]; exists {
 			dayData.HasData = true
 			dayData.Status = f.Status
 			dayData.Reason = f.Reason
             
-            if f.Status == "tidak" && day <= daysPassed {
+            if !f.Status.Done() && day <= daysPassed {
                 notFastingCount++
             }
 		} else if isPast {
              // AUTO-FILL 'Puasa' for past days
              dayData.HasData = true
-             dayData.Status = "puasa"
+             dayData.Status = models.FastingDone
              dayData.Reason = ""
         }
 
@@ -707,7 +838,8 @@ func (h *Handler) ShowFasting(c echo.Context) error {
 	}
 
 	// Override DB stats with "Assumed" stats
-    // Fasting = DaysPassed - NotFasting
+    // Fasting = DaysPassed - NotFasting, excused days count as neither
+    daysPassed -= excusedCount
     fastingCount := daysPassed - notFastingCount
     if fastingCount < 0 { fastingCount = 0 }
     
@@ -727,12 +859,19 @@ func (h *Handler) ShowFasting(c echo.Context) error {
         "fasting": fastingCount,
         "not_fasting": notFastingCount,
         "total_days": daysPassed, 
+        "udzur": excusedCount,
     }
 
-	// Format today's date for display
+	checkinStats, err := h.FastingCheckinService.Repo.GetStats(user.ID, startStr, endStr)
+	if err != nil {
+		checkinStats = &models.FastingCheckinStats{}
+	}
+
+	// Format the selected date for display
+	day, _ := time.ParseInLocation("2006-01-02", entry.Date, today.Location())
 	months := []string{"Januari", "Februari", "Maret", "April", "Mei", "Juni", "Juli", "Agustus", "September", "Oktober", "November", "Desember"}
 	days := []string{"Minggu", "Senin", "Selasa", "Rabu", "Kamis", "Jumat", "Sabtu"}
-	todayFormatted := days[int(today.Weekday())] + ", " + strconv.Itoa(today.Day()) + " " + months[today.Month()-1] + " " + strconv.Itoa(today.Year())
+	todayFormatted := days[int(day.Weekday())] + ", " + strconv.Itoa(day.Day()) + " " + months[day.Month()-1] + " " + strconv.Itoa(day.Year())
 
 	return c.Render(http.StatusOK, "user/fasting.html", map[string]interface{}{
 		"Title":        "Puasa",
@@ -741,8 +880,16 @@ func (h *Handler) ShowFasting(c echo.Context) error {
 		"CalendarDays": calendarDays,
 		"EmptyDays":    emptyDays,
 		"Stats":        stats,
+		"Excused":      h.UdzurService.Excused(user.ID, entry.Date, entry.Date)[entry.Date],
 		"TodayDate":    todayFormatted,
-		"TodayDateISO": todayStr,
+		"TodayDateISO": entry.Date,
+		"Entry":        entry,
+		"Ramadhan":     ramadhanLabel(ramadhan),
+		"HijriToday":   hijri.FromTime(today, offset).String(),
+		"InRamadhan":   ramadhan.Start.Format("2006-01-02") <= todayStr && todayStr <= endStr,
+		"SunnahToday":  services.SunnahFastingLabels(strings.Join(services.SunnahFastingKindsOn(today, offset), ",")),
+		"Checkin":      h.fastingCheckins(user, today, offset),
+		"CheckinStats": checkinStats,
 		"Error":        c.QueryParam("error"),
 		"Success":      c.QueryParam("success"),
 	})
@@ -756,15 +903,28 @@ func (h *Handler) SaveFasting(c echo.Context) error {
 		date = time.Now().Format("2006-01-02")
 	}
 
-	status := c.FormValue("status")
+	status, err := models.ParseFastingStatus(c.FormValue("status"))
+	if err != nil {
+		return entryRedirect(c, "/user/fasting", date, "error", err.Error())
+	}
 	reason := c.FormValue("reason")
 
-	err := h.FastingRepo.CreateOrUpdate(user.ID, date, status, reason)
+	queued, err := h.EntryPolicyService.Admit(user, "fasting", date, models.SyncFastingData{Status: string(status), Reason: reason}, time.Now())
+	if err != nil {
+		return entryRedirect(c, "/user/fasting", date, "error", err.Error())
+	}
+	if queued {
+		return entryRedirect(c, "/user/fasting", date, "success", services.LateEntryQueuedMessage)
+	}
+
+	err = h.FastingRepo.CreateOrUpdate(user.ID, date, status, reason)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to save fasting"})
 	}
 
-	return c.Redirect(http.StatusSeeOther, "/user/fasting")
+	h.LiveDashboardService.Record(user, "fasting", "mengisi puasa")
+
+	return entryRedirect(c, "/user/fasting", date, "success", "Puasa tersimpan")
 }
 
 func (h *Handler) ShowQuran(c echo.Context) error {
@@ -786,7 +946,12 @@ func (h *Handler) ShowQuran(c echo.Context) error {
 		targetKhatamDays = 30
 	}
 	targetDailyPages := float64(604) / float64(targetKhatamDays)
-	progressPercent := float64(totalPages) / 604.0 * 100
+
+	// Progress counts distinct ayat read in the current khatam cycle, not pages
+	khatam, err := h.KhatamService.Progress(user.ID)
+	if err != nil {
+		khatam = &services.KhatamProgress{Cycle: 1}
+	}
 
 	// Get today's readings
 	todayReadings, _ := h.QuranRepo.GetByUserAndDate(user.ID, todayStr)
@@ -795,8 +960,15 @@ func (h *Handler) ShowQuran(c echo.Context) error {
 		todayPages += r.Pages
 	}
 
-	// Get all surah from API for dropdown
-	surahList, _ := h.MuslimAPI.GetAllSurah()
+	// Surah list for the dropdown
+	surahList, _ := h.Content.GetAllSurah()
+
+	// The next reading starts right after the bookmark
+	bookmark, _ := h.QuranRepo.GetBookmark(user.ID)
+	var nextSurah, nextAyah int
+	if bookmark != nil {
+		nextSurah, nextAyah = mushaf.Next(bookmark.SurahID, bookmark.Ayah)
+	}
 
 	// Format today's date
 	months := []string{"Januari", "Februari", "Maret", "April", "Mei", "Juni", "Juli", "Agustus", "September", "Oktober", "November", "Desember"}
@@ -809,17 +981,34 @@ func (h *Handler) ShowQuran(c echo.Context) error {
 		"Readings":         readings,
 		"TotalReadings":    totalReadings,
 		"TotalPages":       totalPages,
-		"ProgressPercent":  int(progressPercent),
+		"ProgressPercent":  khatam.Percent,
+		"Khatam":           khatam,
 		"TargetDailyPages": int(math.Ceil(targetDailyPages)),
 		"TodayPages":       todayPages,
 		"TodayReadings":    todayReadings,
 		"TodayDate":        todayFormatted,
 		"SurahList":        surahList,
+		"Bookmark":         bookmark,
+		"BookmarkName":     h.surahName(bookmark),
+		"NextSurah":        nextSurah,
+		"NextAyah":         nextAyah,
+		"Entry":            h.entryWindow(c, user, today),
 		"Error":            c.QueryParam("error"),
 		"Success":          c.QueryParam("success"),
 	})
 }
 
+// surahName returns the Indonesian name of the bookmarked surah, or "" without a bookmark
+func (h *Handler) surahName(b *models.QuranBookmark) string {
+	if b == nil {
+		return ""
+	}
+	if surah, err := h.Content.GetSurahByID(b.SurahID); err == nil {
+		return surah.NameID
+	}
+	return fmt.Sprintf("Surah %d", b.SurahID)
+}
+
 func (h *Handler) SaveQuran(c echo.Context) error {
 	user := c.Get("user").(*models.User)
 
@@ -829,8 +1018,11 @@ func (h *Handler) SaveQuran(c echo.Context) error {
 	endSurahID, _ := strconv.Atoi(c.FormValue("end_surah_id"))
 	endSurahName := c.FormValue("end_surah_name")
 	endAyah, _ := strconv.Atoi(c.FormValue("end_ayah"))
-	pages, _ := strconv.Atoi(c.FormValue("pages"))
 	notes := c.FormValue("notes")
+	date := c.FormValue("date")
+	if date == "" {
+		date = time.Now().Format("2006-01-02")
+	}
 
 	// Validation
 	if startSurahID < 1 || startSurahID > 114 {
@@ -854,22 +1046,52 @@ func (h *Handler) SaveQuran(c echo.Context) error {
 
 	reading := &models.QuranReading{
 		UserID:         user.ID,
-		Date:           time.Now().Format("2006-01-02"),
+		Date:           date,
 		StartSurahID:   startSurahID,
 		StartSurahName: startSurahName,
 		StartAyah:      startAyah,
 		EndSurahID:     endSurahID,
 		EndSurahName:   endSurahName,
 		EndAyah:        endAyah,
-		Pages:          pages,
 		Notes:          notes,
 	}
+	// Pages and juz come from the ayah range
+	if err := services.FillQuranCoverage(reading); err != nil {
+		return c.Redirect(http.StatusSeeOther, "/user/quran?error=Bacaan tidak valid: "+err.Error())
+	}
+
+	queued, err := h.EntryPolicyService.Admit(user, "quran", date, models.SyncQuranData{
+		StartSurahID: startSurahID, StartSurahName: startSurahName, StartAyah: startAyah,
+		EndSurahID: endSurahID, EndSurahName: endSurahName, EndAyah: endAyah, Notes: notes,
+	}, time.Now())
+	if err != nil {
+		return c.Redirect(http.StatusSeeOther, "/user/quran?error="+url.QueryEscape(err.Error()))
+	}
+	if queued {
+		return c.Redirect(http.StatusSeeOther, "/user/quran?success="+url.QueryEscape(services.LateEntryQueuedMessage))
+	}
+
+	khatamBefore := 0
+	if before, err := h.KhatamService.Progress(user.ID); err == nil {
+		khatamBefore = len(before.Completed)
+	}
 
-	err := h.QuranRepo.Create(reading)
+	err = h.QuranRepo.Create(reading)
 	if err != nil {
 		return c.Redirect(http.StatusSeeOther, "/user/quran?error=Gagal menyimpan bacaan")
 	}
 
+	h.LiveDashboardService.Record(user, "quran", fmt.Sprintf("membaca %s %d - %s %d", startSurahName, startAyah, endSurahName, endAyah))
+
+	h.QuranRepo.SaveBookmark(&models.QuranBookmark{
+		UserID: user.ID, SurahID: endSurahID, Ayah: endAyah, Source: "reading", UpdatedAt: time.Now(),
+	})
+
+	if after, err := h.KhatamService.Progress(user.ID); err == nil && len(after.Completed) > khatamBefore {
+		msg := fmt.Sprintf("Alhamdulillah, khatam ke-%d selesai! Siklus baru dimulai", len(after.Completed))
+		return c.Redirect(http.StatusSeeOther, "/user/quran?success="+url.QueryEscape(msg))
+	}
+
 	return c.Redirect(http.StatusSeeOther, "/user/quran?success=Bacaan berhasil disimpan")
 }
 
@@ -895,6 +1117,14 @@ func (h *Handler) DeleteQuran(c echo.Context) error {
 		return c.Redirect(http.StatusSeeOther, "/user/quran?error=Data tidak ditemukan")
 	}
 
+	late, err := h.EntryPolicyService.Check(user, readingToDelete.Date, time.Now())
+	if err == nil && late {
+		err = services.ErrEntryNeedsApproval
+	}
+	if err != nil {
+		return c.Redirect(http.StatusSeeOther, "/user/quran?error="+url.QueryEscape(err.Error()))
+	}
+
 	err = h.QuranRepo.Delete(readingID)
 	if err != nil {
 		return c.Redirect(http.StatusSeeOther, "/user/quran?error=Gagal menghapus bacaan")
@@ -909,9 +1139,9 @@ func (h *Handler) ShowAmaliah(c echo.Context) error {
 	// Get all amaliah types
 	types, _ := h.AmaliahRepo.GetAllTypes()
 
-	// Get today's amaliah
-	today := time.Now().Format("2006-01-02")
-	todayAmaliah, _ := h.AmaliahRepo.GetDailyAmaliah(user.ID, today)
+	// Get the selected day's amaliah (today by default)
+	entry := h.entryWindow(c, user, time.Now())
+	todayAmaliah, _ := h.AmaliahRepo.GetDailyAmaliah(user.ID, entry.Date)
 
 	// Create completed map
 	completedMap := make(map[int]bool)
@@ -937,6 +1167,7 @@ func (h *Handler) ShowAmaliah(c echo.Context) error {
 		"CompletedCount": completedCount,
 		"TodayPoints":    todayPoints,
 		"Leaderboard":    leaderboard,
+		"Entry":          entry,
 		"Error":          c.QueryParam("error"),
 		"Success":        c.QueryParam("success"),
 	})
@@ -948,11 +1179,28 @@ func (h *Handler) SaveAmaliah(c echo.Context) error {
 	amaliahTypeID, _ := strconv.Atoi(c.FormValue("amaliah_type_id"))
 	notes := c.FormValue("notes")
 	action := c.FormValue("action") // "add" or "remove"
+	date := c.FormValue("date")
+	if date == "" {
+		date = time.Now().Format("2006-01-02")
+	}
+
+	syncAction := "add"
+	if action == "remove" {
+		syncAction = "remove"
+	}
+	queued, err := h.EntryPolicyService.Admit(user, "amaliah", date, models.SyncAmaliahData{
+		AmaliahTypeID: amaliahTypeID, Action: syncAction, Notes: notes,
+	}, time.Now())
+	if err != nil {
+		return entryRedirect(c, "/user/amaliah", date, "error", err.Error())
+	}
+	if queued {
+		return entryRedirect(c, "/user/amaliah", date, "success", services.LateEntryQueuedMessage)
+	}
 
 	if action == "remove" {
 		// Remove amaliah
-		today := time.Now().Format("2006-01-02")
-		item, err := h.AmaliahRepo.GetDailyAmaliahByType(user.ID, amaliahTypeID, today)
+		item, err := h.AmaliahRepo.GetDailyAmaliahByType(user.ID, amaliahTypeID, date)
 		if err == nil {
 			h.AmaliahRepo.DeleteDailyAmaliah(item.ID)
 
@@ -964,18 +1212,17 @@ func (h *Handler) SaveAmaliah(c echo.Context) error {
 		}
 	} else {
 		// Add amaliah
-		// Check if already exists for today
-		today := time.Now().Format("2006-01-02")
-		_, err := h.AmaliahRepo.GetDailyAmaliahByType(user.ID, amaliahTypeID, today)
+		// Check if already exists for the day
+		_, err := h.AmaliahRepo.GetDailyAmaliahByType(user.ID, amaliahTypeID, date)
 		if err == nil {
 			// Already exists, do not add again
-			return c.Redirect(http.StatusSeeOther, "/user/amaliah")
+			return entryRedirect(c, "/user/amaliah", date, "", "")
 		}
 
 		da := &models.DailyAmaliah{
 			UserID:        user.ID,
 			AmaliahTypeID: amaliahTypeID,
-			Date:          today,
+			Date:          date,
 			Notes:         notes,
 		}
 
@@ -988,10 +1235,11 @@ func (h *Handler) SaveAmaliah(c echo.Context) error {
 		amaliahType, _ := h.AmaliahRepo.GetTypeByID(amaliahTypeID)
 		if amaliahType != nil {
 			h.UserRepo.UpdatePoints(user.ID, amaliahType.Points)
+			h.LiveDashboardService.Record(user, "amaliah", amaliahType.Name)
 		}
 	}
 
-	return c.Redirect(http.StatusSeeOther, "/user/amaliah")
+	return entryRedirect(c, "/user/amaliah", date, "", "")
 }
 
 // Admin Handlers
@@ -1097,19 +1345,102 @@ func (h *Handler) AdminDashboard(c echo.Context) error {
 func (h *Handler) ManageUsers(c echo.Context) error {
 	user := c.Get("user").(*models.User)
 
-	users, _ := h.UserRepo.GetAll()
+	query := userQueryFromRequest(c)
+	page, err := h.UserRepo.Query(query)
+	if err != nil {
+		page = &models.UserPage{Page: 1, PerPage: query.PerPage}
+	}
+	repository.NormalizeUserQuery(&query)
+
 	classes, _ := h.ClassRepo.GetAll()
 
+	var schools []models.School
+	rows, err := h.DB.Query("SELECT id, name FROM schools ORDER BY name ASC")
+	if err == nil {
+		defer rows.Close()
+		for rows.Next() {
+			var sc models.School
+			if err := rows.Scan(&sc.ID, &sc.Name); err == nil {
+				schools = append(schools, sc)
+			}
+		}
+	}
+
+	var prevURL, nextURL string
+	if page.Page > 1 {
+		prevURL = userListURL(query, page.Page-1)
+	}
+	if page.Page < page.TotalPages {
+		nextURL = userListURL(query, page.Page+1)
+	}
+
 	return c.Render(http.StatusOK, "admin/users.html", map[string]interface{}{
-		"Title":   "Kelola Siswa",
-		"User":    user,
-		"Users":   users,
-		"Classes": classes,
-		"Success": c.QueryParam("success"),
-		"Error":   c.QueryParam("error"),
+		"Title":    "Kelola Siswa",
+		"User":     user,
+		"Users":    page.Users,
+		"Page":     page,
+		"Query":    query,
+		"Search":   query.Search,
+		"Filtered": query.Search != "" || query.Role != "" || query.Class != "" || query.SchoolID > 0 || query.ActiveFrom != "" || query.ActiveUntil != "",
+		"PrevURL":  prevURL,
+		"NextURL":  nextURL,
+		"Classes":  classes,
+		"Schools":  schools,
+		"Success":  c.QueryParam("success"),
+		"Error":    c.QueryParam("error"),
 	})
 }
 
+// ListUsersAPI returns the same paginated user list as ManageUsers as JSON
+func (h *Handler) ListUsersAPI(c echo.Context) error {
+	page, err := h.UserRepo.Query(userQueryFromRequest(c))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Gagal mengambil data user"})
+	}
+	return c.JSON(http.StatusOK, page)
+}
+
+func userQueryFromRequest(c echo.Context) models.UserQuery {
+	schoolID, _ := strconv.Atoi(c.QueryParam("school_id"))
+	page, _ := strconv.Atoi(c.QueryParam("page"))
+	perPage, _ := strconv.Atoi(c.QueryParam("per_page"))
+
+	return models.UserQuery{
+		Search:      c.QueryParam("q"),
+		Role:        c.QueryParam("role"),
+		Class:       c.QueryParam("class"),
+		SchoolID:    schoolID,
+		ActiveFrom:  c.QueryParam("active_from"),
+		ActiveUntil: c.QueryParam("active_until"),
+		Sort:        c.QueryParam("sort"),
+		Order:       c.QueryParam("order"),
+		Page:        page,
+		PerPage:     perPage,
+	}
+}
+
+func userListURL(q models.UserQuery, page int) string {
+	v := url.Values{}
+	set := func(key, value string) {
+		if value != "" {
+			v.Set(key, value)
+		}
+	}
+	set("q", q.Search)
+	set("role", q.Role)
+	set("class", q.Class)
+	if q.SchoolID > 0 {
+		v.Set("school_id", strconv.Itoa(q.SchoolID))
+	}
+	set("active_from", q.ActiveFrom)
+	set("active_until", q.ActiveUntil)
+	set("sort", q.Sort)
+	set("order", q.Order)
+	v.Set("per_page", strconv.Itoa(q.PerPage))
+	v.Set("page", strconv.Itoa(page))
+	return "/admin/users?" + v.Encode()
+}
+
 func (h *Handler) CreateUser(c echo.Context) error {
 	var req models.RegisterRequest
 	if err := c.Bind(&req); err != nil {
@@ -1333,6 +1664,7 @@ func (h *Handler) ShowUserDetail(c echo.Context) error {
 	startOfMonth := time.Date(time.Now().Year(), time.Now().Month(), 1, 0, 0, 0, 0, time.Now().Location()).Format("2006-01-02")
 
 	prayerStats, _ := h.PrayerRepo.GetPrayerStats(userID, startOfMonth, today)
+	prayerTiming, _ := h.PrayerTimingService.Repo.GetStats(userID, startOfMonth, today)
 	fastingStats, _ := h.FastingRepo.GetFastingStats(userID, startOfMonth, today)
 	totalReadings, _ := h.QuranRepo.GetTotalReadings(userID)
 	totalPoints, _ := h.AmaliahRepo.GetTotalPoints(userID, startOfMonth, today)
@@ -1348,6 +1680,7 @@ func (h *Handler) ShowUserDetail(c echo.Context) error {
 		"User":           user,
 		"TargetUser":     targetUser,
 		"PrayerStats":    prayerStats,
+		"PrayerTiming":   prayerTiming,
 		"FastingStats":   fastingStats,
 		"TotalReadings":  totalReadings,
 		"TotalPoints":    totalPoints,
@@ -1358,18 +1691,9 @@ func (h *Handler) ShowUserDetail(c echo.Context) error {
 	})
 }
 
+// SearchUsers keeps the old /admin/users/search links working; ManageUsers handles ?q=
 func (h *Handler) SearchUsers(c echo.Context) error {
-	user := c.Get("user").(*models.User)
-
-	query := c.QueryParam("q")
-	users, _ := h.UserRepo.SearchUsers(query)
-
-	return c.Render(http.StatusOK, "admin/users.html", map[string]interface{}{
-		"Title":  "Kelola Siswa",
-		"User":   user,
-		"Users":  users,
-		"Search": query,
-	})
+	return h.ManageUsers(c)
 }
 
 // Admin Reports
@@ -1427,9 +1751,9 @@ func (h *Handler) ShowDoa(c echo.Context) error {
 	var err error
 
 	if search != "" {
-		doaList, err = h.MuslimAPI.SearchDoa(search)
+		doaList, err = h.Content.SearchDoa(search)
 	} else {
-		doaList, err = h.MuslimAPI.GetDoaBySource(source)
+		doaList, err = h.Content.GetDoaBySource(source)
 	}
 
 	if err != nil {
@@ -1440,6 +1764,7 @@ func (h *Handler) ShowDoa(c echo.Context) error {
 		"Title":   "Doa Harian",
 		"User":    user,
 		"DoaList": doaList,
+		"Favorites": h.favoriteIDs(user, "doa"),
 		"Source":  source,
 		"Search":  search,
 		"Error":   c.QueryParam("error"),
@@ -1459,14 +1784,14 @@ func (h *Handler) ShowHadits(c echo.Context) error {
 
 	if nomorStr != "" {
 		nomor, _ := strconv.Atoi(nomorStr)
-		selectedHadits, err = h.MuslimAPI.GetHaditsByNumber(nomor)
+		selectedHadits, err = h.Content.GetHaditsByNumber(nomor)
 		if err == nil && selectedHadits != nil {
 			haditsList = []services.Hadits{*selectedHadits}
 		}
 	} else if search != "" {
-		haditsList, err = h.MuslimAPI.SearchHadits(search)
+		haditsList, err = h.Content.SearchHadits(search)
 	} else {
-		haditsList, err = h.MuslimAPI.GetAllHadits()
+		haditsList, err = h.Content.GetAllHadits()
 	}
 
 	if err != nil {
@@ -1477,6 +1802,7 @@ func (h *Handler) ShowHadits(c echo.Context) error {
 		"Title":         "Hadits Arbain",
 		"User":          user,
 		"HaditsList":    haditsList,
+		"Favorites":     h.favoriteIDs(user, "hadits"),
 		"Search":        search,
 		"SelectedNomor": nomorStr,
 		"Error":         c.QueryParam("error"),
@@ -1494,20 +1820,26 @@ func (h *Handler) ShowQuranIndonesia(c echo.Context) error {
 	var selectedSurah *services.Surah
 	var err error
 
-	surahList, err = h.MuslimAPI.GetAllSurah()
+	surahList, err = h.Content.GetAllSurah()
 	if err != nil {
 		surahList = []services.Surah{}
 	}
 
 	if surahIDStr != "" {
 		surahID, _ := strconv.Atoi(surahIDStr)
-		selectedSurah, _ = h.MuslimAPI.GetSurahByID(surahID)
-		ayahList, err = h.MuslimAPI.GetAyahBySurah(surahID)
+		selectedSurah, _ = h.Content.GetSurahByID(surahID)
+		ayahList, err = h.Content.GetAyahBySurah(surahID)
 		if err != nil {
 			ayahList = []services.Ayah{}
 		}
 	}
 
+	bookmark, _ := h.QuranRepo.GetBookmark(user.ID)
+	bookmarkAyah := ""
+	if bookmark != nil && selectedSurah != nil && strconv.Itoa(bookmark.SurahID) == selectedSurah.Number {
+		bookmarkAyah = strconv.Itoa(bookmark.Ayah)
+	}
+
 	return c.Render(http.StatusOK, "user/quran_indonesia.html", map[string]interface{}{
 		"Title":         "Al-Quran Indonesia",
 		"User":          user,
@@ -1515,10 +1847,31 @@ func (h *Handler) ShowQuranIndonesia(c echo.Context) error {
 		"AyahList":      ayahList,
 		"SelectedSurah": selectedSurah,
 		"SurahID":       surahIDStr,
+		"Bookmark":      bookmark,
+		"BookmarkName":  h.surahName(bookmark),
+		"BookmarkAyah":  bookmarkAyah,
+		"Favorites":     h.favoriteIDs(user, "ayah"),
 		"Error":         c.QueryParam("error"),
 	})
 }
 
+// SetQuranBookmark marks an ayah as last read from the Quran reader
+func (h *Handler) SetQuranBookmark(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+
+	surahID, _ := strconv.Atoi(c.FormValue("surah"))
+	ayah, _ := strconv.Atoi(c.FormValue("ayah"))
+	if err := mushaf.Validate(surahID, ayah); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	bookmark := &models.QuranBookmark{UserID: user.ID, SurahID: surahID, Ayah: ayah, Source: "reader", UpdatedAt: time.Now()}
+	if err := h.QuranRepo.SaveBookmark(bookmark); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Gagal menyimpan penanda"})
+	}
+	return c.JSON(http.StatusOK, bookmark)
+}
+
 // Profile Handlers
 func (h *Handler) ShowProfile(c echo.Context) error {
 	user := c.Get("user").(*models.User)
@@ -1709,7 +2062,7 @@ func (h *Handler) GetImsakiyahAPI(c echo.Context) error {
 		}
 	}
 
-	data, err := h.ImsakiyahService.GetImsakiyah(provinsi, kabkota)
+	data, err := h.ImsakiyahService.GetImsakiyah(provinsi, kabkota, h.hijriOffset(user))
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
@@ -1730,24 +2083,21 @@ func (h *Handler) AutoDetectLocation(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid longitude"})
 	}
 
-	// 1. Reverse Geocode
-	detectedProv, detectedCity, err := h.ShalatService.ReverseGeocode(lat, long)
+	match, err := h.ShalatService.ResolveLocation(lat, long)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Gagal mendeteksi lokasi: " + err.Error()})
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Gagal mendeteksi lokasi: " + err.Error()})
 	}
 
-	// 2. Match with API Data
-	matchedProv, matchedCity, err := h.ShalatService.MatchLocation(detectedProv, detectedCity)
-	
 	// Prepare response
 	response := map[string]string{
-		"detected_prov": detectedProv,
-		"detected_city": detectedCity,
-		"provinsi":     matchedProv,
-		"kabkota":      matchedCity,
+		"detected_prov": match.DetectedProv,
+		"detected_city": match.DetectedCity,
+		"provinsi":      match.Provinsi,
+		"kabkota":       match.Kabkota,
+		"distance_km":   strconv.FormatFloat(match.DistanceKm, 'f', 1, 64),
 	}
 
-	if err != nil {
+	if !match.Exact {
 		response["status"] = "partial_match"
 		response["message"] = "Lokasi terdeteksi namun tidak cocok sempurna dengan database. Silakan sesuaikan manual."
 	} else {
@@ -1838,12 +2188,26 @@ func (h *Handler) DownloadCertificate(c echo.Context) error {
 	totalPoints := user.Points
 	totalPages, _ := h.QuranRepo.GetTotalPagesRead(user.ID)
 	
-	progressPercent := float64(totalPages) / 604.0 * 100
+	khatamPercent := 0
+	if khatam, err := h.KhatamService.Progress(user.ID); err == nil {
+		khatamPercent = khatam.Percent
+		if len(khatam.Completed) > 0 {
+			khatamPercent = 100
+		}
+	}
+
+	// Before this Hijri year's Ramadhan starts, the certificate is for last year's
+	now := time.Now()
+	ramadhan := h.ramadhanFor(user, now)
+	if now.Before(ramadhan.Start) {
+		ramadhan = hijri.RamadhanOf(ramadhan.Year-1, h.hijriOffset(user))
+	}
 	
 	stats := map[string]interface{}{
 		"total_points": totalPoints,
 		"total_pages":  totalPages,
-		"khatam_percent": int(progressPercent),
+		"khatam_percent": khatamPercent,
+		"period":         ramadhanLabel(ramadhan),
 	}
 
 	pdfBytes, err := h.CertificateService.Generate(user, stats)
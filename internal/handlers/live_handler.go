@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+)
+
+const (
+	// liveDebounce groups bursts of saves (e.g. right after Subuh) into one push
+	liveDebounce  = 2 * time.Second
+	liveKeepAlive = 25 * time.Second
+)
+
+// AdminDashboardStream pushes live counters to the superadmin dashboard,
+// optionally narrowed with ?school_id=
+func (h *Handler) AdminDashboardStream(c echo.Context) error {
+	schoolID, _ := strconv.Atoi(c.QueryParam("school_id"))
+	return h.streamDashboard(c, schoolID)
+}
+
+// SchoolDashboardStream pushes live counters for the school admin's own school
+func (h *Handler) SchoolDashboardStream(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+	if user.Role != "admin" || user.SchoolID == 0 {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "Akses ditolak"})
+	}
+	return h.streamDashboard(c, user.SchoolID)
+}
+
+func (h *Handler) streamDashboard(c echo.Context, schoolID int) error {
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.Header().Set(echo.HeaderConnection, "keep-alive")
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+
+	events, unsubscribe := h.LiveDashboardService.Bus.Subscribe(schoolID)
+	defer unsubscribe()
+
+	if err := h.sendDashboardSnapshot(c, schoolID); err != nil {
+		return nil
+	}
+
+	keepAlive := time.NewTicker(liveKeepAlive)
+	defer keepAlive.Stop()
+
+	var debounce <-chan time.Time
+	ctx := c.Request().Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev := <-events:
+			data, _ := json.Marshal(ev)
+			if _, err := fmt.Fprintf(res, "event: activity\ndata: %s\n\n", data); err != nil {
+				return nil
+			}
+			res.Flush()
+			if debounce == nil {
+				debounce = time.After(liveDebounce)
+			}
+		case <-debounce:
+			debounce = nil
+			if err := h.sendDashboardSnapshot(c, schoolID); err != nil {
+				return nil
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(res, ": ping\n\n"); err != nil {
+				return nil
+			}
+			res.Flush()
+		}
+	}
+}
+
+func (h *Handler) sendDashboardSnapshot(c echo.Context, schoolID int) error {
+	snap, err := h.LiveDashboardService.Snapshot(schoolID)
+	if err != nil {
+		// Keep the connection; the next activity retries the snapshot
+		return nil
+	}
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+	res := c.Response()
+	if _, err := fmt.Fprintf(res, "event: stats\ndata: %s\n\n", data); err != nil {
+		return err
+	}
+	res.Flush()
+	return nil
+}
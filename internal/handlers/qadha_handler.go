@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+	"github.com/ramadhan/amaliah-monitoring/internal/services"
+)
+
+type qadhaDebtView struct {
+	*services.QadhaDebt
+	Label    string
+	Deadline string
+	Late     bool
+}
+
+// ShowQadha shows the make-up fasts a student still owes per Ramadhan and
+// lets them log the ones they have done
+func (h *Handler) ShowQadha(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+
+	now := time.Now()
+	today := now.Format("2006-01-02")
+	summary, err := h.QadhaService.Summary(user.ID, h.hijriOffset(user), now)
+	if err != nil {
+		summary = &services.QadhaSummary{}
+	}
+	debts := make([]qadhaDebtView, len(summary.Debts))
+	for i, d := range summary.Debts {
+		deadline := d.Deadline.Format("2006-01-02")
+		debts[i] = qadhaDebtView{
+			QadhaDebt: d,
+			Label:     ramadhanLabel(d.Ramadhan),
+			Deadline:  deadline,
+			Late:      d.Outstanding() > 0 && deadline <= today,
+		}
+	}
+
+	rate := services.FidyahRateFromEnv()
+	return c.Render(http.StatusOK, "user/qadha.html", map[string]interface{}{
+		"Title":        "Qadha Puasa",
+		"User":         user,
+		"Summary":      summary,
+		"Debts":        debts,
+		"Fidyah":       services.FidyahLabel(summary.FidyahDays, rate),
+		"TodayDateISO": today,
+		"Error":        c.QueryParam("error"),
+		"Success":      c.QueryParam("success"),
+	})
+}
+
+func (h *Handler) LogQadha(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+
+	q, err := h.QadhaService.Log(user, c.FormValue("date"), h.hijriOffset(user), time.Now())
+	if err != nil {
+		return c.Redirect(http.StatusSeeOther, "/user/qadha?error="+url.QueryEscape(err.Error()))
+	}
+	msg := fmt.Sprintf("Puasa qadha untuk Ramadhan %d H tercatat", q.RamadhanYear)
+	return c.Redirect(http.StatusSeeOther, "/user/qadha?success="+url.QueryEscape(msg))
+}
+
+func (h *Handler) DeleteQadha(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+
+	id, _ := strconv.Atoi(c.Param("id"))
+	if err := h.QadhaService.Repo.Delete(id, user.ID); err != nil {
+		msg := "Gagal menghapus puasa qadha"
+		if err == sql.ErrNoRows {
+			msg = "Puasa qadha tidak ditemukan"
+		}
+		return c.Redirect(http.StatusSeeOther, "/user/qadha?error="+url.QueryEscape(msg))
+	}
+	return c.Redirect(http.StatusSeeOther, "/user/qadha?success=Puasa qadha dihapus")
+}
+
+// ShowQadhaReport lists the students of a class who still owe make-up fasts.
+// Only totals are shown, never the reasons.
+func (h *Handler) ShowQadhaReport(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+	if !services.IsTeacher(user.Role) {
+		return c.Redirect(http.StatusSeeOther, "/user/dashboard")
+	}
+	if !services.HasSchoolScope(user) {
+		return c.Redirect(http.StatusSeeOther, "/user/dashboard?error=Anda belum terdaftar di sekolah")
+	}
+
+	class := c.QueryParam("class")
+	rows, err := h.QadhaService.ClassReport(teacherSchool(user), class, h.hijriOffset(user), time.Now())
+	if err != nil {
+		return c.Redirect(http.StatusSeeOther, "/user/dashboard?error=Gagal memuat laporan qadha")
+	}
+	classes, _ := h.UserRepo.GetClassesBySchool(teacherSchool(user))
+
+	outstanding := 0
+	for _, r := range rows {
+		outstanding += r.Outstanding
+	}
+	return c.Render(http.StatusOK, "school/qadha.html", map[string]interface{}{
+		"Title":       "Laporan Qadha Puasa",
+		"User":        user,
+		"Rows":        rows,
+		"Outstanding": outstanding,
+		"Classes":     classes,
+		"Class":       class,
+		"Error":       c.QueryParam("error"),
+	})
+}
+
+// ExportQadhaReport downloads the class report as a spreadsheet
+func (h *Handler) ExportQadhaReport(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+	if !services.IsTeacher(user.Role) {
+		return c.Redirect(http.StatusSeeOther, "/user/dashboard")
+	}
+	if !services.HasSchoolScope(user) {
+		return c.Redirect(http.StatusSeeOther, "/user/dashboard?error=Anda belum terdaftar di sekolah")
+	}
+
+	class := c.QueryParam("class")
+	back := "/school/qadha?class=" + url.QueryEscape(class)
+	rows, err := h.QadhaService.ClassReport(teacherSchool(user), class, h.hijriOffset(user), time.Now())
+	if err != nil {
+		return c.Redirect(http.StatusSeeOther, back+"&error=Gagal membuat laporan")
+	}
+	f, err := h.ExportService.GenerateQadhaReportExcel(rows, services.FidyahRateFromEnv())
+	if err != nil {
+		return c.Redirect(http.StatusSeeOther, back+"&error=Gagal membuat laporan")
+	}
+
+	name := "Semua_Kelas"
+	if class != "" {
+		name = url.PathEscape(class)
+	}
+	c.Response().Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	c.Response().Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=Laporan_Qadha_%s_%s.xlsx", name, time.Now().Format("2006-01-02")))
+	return f.Write(c.Response().Writer)
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ramadhan/amaliah-monitoring/internal/hijri"
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+)
+
+// hijriOffset returns the isbat correction configured for the user's school
+func (h *Handler) hijriOffset(user *models.User) int {
+	if user == nil || user.SchoolID == 0 {
+		return 0
+	}
+	var offset int
+	h.DB.QueryRow("SELECT COALESCE(hijri_offset, 0) FROM schools WHERE id = ?", user.SchoolID).Scan(&offset)
+	return hijri.ClampOffset(offset)
+}
+
+// ramadhanFor returns the user's Ramadhan period around now
+func (h *Handler) ramadhanFor(user *models.User, now time.Time) hijri.Period {
+	return hijri.RamadhanFor(now, h.hijriOffset(user))
+}
+
+// ramadhanLabel renders e.g. "Ramadhan 1447 H / 2026 M"
+func ramadhanLabel(p hijri.Period) string {
+	return fmt.Sprintf("Ramadhan %d H / %d M", p.Year, p.Start.Year())
+}
@@ -7,7 +7,9 @@ import (
 	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/ramadhan/amaliah-monitoring/internal/hijri"
 	"github.com/ramadhan/amaliah-monitoring/internal/models"
+	"github.com/ramadhan/amaliah-monitoring/internal/services"
 	"github.com/ramadhan/amaliah-monitoring/internal/utils"
 )
 
@@ -206,9 +208,9 @@ func (h *Handler) SchoolAdminDashboard(c echo.Context) error {
 
 	var school models.School
 	err := h.DB.QueryRow(
-		"SELECT id, name, code, address, admin_id FROM schools WHERE id = ?",
+		"SELECT id, name, code, COALESCE(address, ''), COALESCE(admin_id, 0), COALESCE(hijri_offset, 0) FROM schools WHERE id = ?",
 		user.SchoolID,
-	).Scan(&school.ID, &school.Name, &school.Code, &school.Address, &school.AdminID)
+	).Scan(&school.ID, &school.Name, &school.Code, &school.Address, &school.AdminID, &school.HijriOffset)
 	if err != nil {
 		return c.Redirect(http.StatusSeeOther, "/user/dashboard?error=Sekolah tidak ditemukan")
 	}
@@ -236,13 +238,27 @@ func (h *Handler) SchoolAdminDashboard(c echo.Context) error {
 		members = append(members, m)
 	}
 
+	ramadhan := hijri.RamadhanFor(time.Now(), school.HijriOffset)
+	today := time.Now().Format("2006-01-02")
+	dailyPins, _ := h.DailyContentService.Repo.GetUpcomingPins(user.SchoolID, today)
+	doaList, _ := h.Content.GetAllDoa()
+
 	return c.Render(http.StatusOK, "school/admin_dashboard.html", map[string]interface{}{
-		"Title":   "Kelola Sekolah",
-		"School":  school,
-		"Members": members,
-		"User":    user,
-		"Success": c.QueryParam("success"),
-		"Error":   c.QueryParam("error"),
+		"Title":           "Kelola Sekolah",
+		"School":          school,
+		"Members":         members,
+		"User":            user,
+		"HijriOffsets":    []int{-2, -1, 0, 1, 2},
+		"Policy":          h.EntryPolicyService.Policy(user),
+		"MaxBackdateDays": services.MaxBackdateDays,
+		"RamadhanStart":   ramadhan.Start.Format("02/01/2006"),
+		"RamadhanLabel":   ramadhanLabel(ramadhan),
+		"DailyPins":       dailyPins,
+		"DailyKinds":      services.DailyContentKinds,
+		"DoaList":         doaList,
+		"Today":           today,
+		"Success":         c.QueryParam("success"),
+		"Error":           c.QueryParam("error"),
 	})
 }
 
@@ -257,8 +273,14 @@ func (h *Handler) SchoolUpdate(c echo.Context) error {
 	if newName == "" {
 		return c.Redirect(http.StatusSeeOther, "/school/admin?error=Nama sekolah tidak boleh kosong")
 	}
-	h.DB.Exec("UPDATE schools SET name = ? WHERE id = ?", newName, user.SchoolID)
-	return c.Redirect(http.StatusSeeOther, "/school/admin?success=Nama sekolah berhasil diperbarui")
+	offset, _ := strconv.Atoi(c.FormValue("hijri_offset"))
+	h.DB.Exec("UPDATE schools SET name = ?, hijri_offset = ? WHERE id = ?", newName, hijri.ClampOffset(offset), user.SchoolID)
+	backdate, _ := strconv.Atoi(c.FormValue("backdate_days"))
+	h.EntryPolicyService.Repo.SavePolicy(user.SchoolID, services.ClampEntryPolicy(models.EntryPolicy{
+		BackdateDays: backdate,
+		LateApproval: c.FormValue("late_approval") == "1",
+	}))
+	return c.Redirect(http.StatusSeeOther, "/school/admin?success=Pengaturan sekolah berhasil diperbarui")
 }
 
 // ─── School Remove Member ─────────────────────────────────────────────────────
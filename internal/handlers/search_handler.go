@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+	"github.com/ramadhan/amaliah-monitoring/internal/services"
+)
+
+const searchLimit = 50
+
+// ShowSearch searches the Quran, doa and hadits from one page
+func (h *Handler) ShowSearch(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+
+	query := c.QueryParam("q")
+	kind := c.QueryParam("type")
+
+	var results []services.SearchResult
+	errMsg := ""
+	if query != "" {
+		var err error
+		results, err = h.SearchService.Search(query, kind, searchLimit)
+		if err != nil {
+			errMsg = "Pencarian gagal, coba kata kunci lain"
+		}
+	}
+
+	return c.Render(http.StatusOK, "user/search.html", map[string]interface{}{
+		"Title":   "Pencarian",
+		"User":    user,
+		"Query":   query,
+		"Type":    kind,
+		"Kinds":   services.SearchKinds,
+		"Results": results,
+		"Error":   errMsg,
+	})
+}
+
+// SearchAPI returns the same results as ShowSearch as JSON
+func (h *Handler) SearchAPI(c echo.Context) error {
+	results, err := h.SearchService.Search(c.QueryParam("q"), c.QueryParam("type"), searchLimit)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Pencarian gagal"})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"query":   c.QueryParam("q"),
+		"results": results,
+	})
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+	"github.com/ramadhan/amaliah-monitoring/internal/mushaf"
+	"github.com/ramadhan/amaliah-monitoring/internal/services"
+)
+
+type setoranView struct {
+	*models.Setoran
+	Label      string
+	GradeLabel string
+}
+
+func setoranViews(names map[int]string, items []*models.Setoran) []setoranView {
+	views := make([]setoranView, len(items))
+	for i, item := range items {
+		views[i] = setoranView{
+			Setoran:    item,
+			Label:      services.RangeLabel(names, services.SetoranRange(item)),
+			GradeLabel: services.SetoranGradeLabel(item.Grade),
+		}
+	}
+	return views
+}
+
+// RequestSetoran files a setoran so a teacher can listen to and grade it
+func (h *Handler) RequestSetoran(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+
+	r := mushaf.Range{}
+	r.StartSurah, _ = strconv.Atoi(c.FormValue("start_surah_id"))
+	r.StartAyah, _ = strconv.Atoi(c.FormValue("start_ayah"))
+	r.EndSurah, _ = strconv.Atoi(c.FormValue("end_surah_id"))
+	r.EndAyah, _ = strconv.Atoi(c.FormValue("end_ayah"))
+
+	if _, err := h.SetoranService.Request(user, r); err != nil {
+		return c.Redirect(http.StatusSeeOther, "/user/tahfidz?error="+url.QueryEscape("Setoran tidak valid: "+err.Error()))
+	}
+	return c.Redirect(http.StatusSeeOther, "/user/tahfidz?success=Setoran diajukan, silakan temui guru untuk menyetorkan hafalan")
+}
+
+// CancelSetoran withdraws a request that has not been graded yet
+func (h *Handler) CancelSetoran(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.Redirect(http.StatusSeeOther, "/user/tahfidz?error=ID tidak valid")
+	}
+	if err := h.SetoranService.Repo.DeletePending(user.ID, id); err != nil {
+		return c.Redirect(http.StatusSeeOther, "/user/tahfidz?error=Gagal membatalkan setoran")
+	}
+	return c.Redirect(http.StatusSeeOther, "/user/tahfidz?success=Setoran dibatalkan")
+}
+
+// teacherSchool returns the school whose setoran the teacher works with; 0
+// lets the superadmin see every school
+func teacherSchool(user *models.User) int {
+	if user.Role == "superadmin" {
+		return 0
+	}
+	return user.SchoolID
+}
+
+// ShowSetoranTeacher lists the setoran waiting to be graded and the ones this
+// teacher graded recently
+func (h *Handler) ShowSetoranTeacher(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+	if !services.IsTeacher(user.Role) {
+		return c.Redirect(http.StatusSeeOther, "/user/dashboard")
+	}
+	if !services.HasSchoolScope(user) {
+		return c.Redirect(http.StatusSeeOther, "/user/dashboard?error=Anda belum terdaftar di sekolah")
+	}
+
+	class := c.QueryParam("class")
+	pending, _ := h.SetoranService.Repo.GetPending(teacherSchool(user), class)
+	graded, _ := h.SetoranService.Repo.GetGradedByTeacher(user.ID, 30)
+	classes, _ := h.UserRepo.GetClassesBySchool(teacherSchool(user))
+
+	surahList, _ := h.Content.GetAllSurah()
+	names := services.SurahNames(surahList)
+
+	today := time.Now()
+	return c.Render(http.StatusOK, "school/setoran.html", map[string]interface{}{
+		"Title":      "Setoran Hafalan",
+		"User":       user,
+		"Pending":    setoranViews(names, pending),
+		"Graded":     setoranViews(names, graded),
+		"Grades":     services.SetoranGrades,
+		"Classes":    classes,
+		"Class":      class,
+		"Today":      today.Format("2006-01-02"),
+		"MonthStart": today.Format("2006-01") + "-01",
+		"Error":      c.QueryParam("error"),
+		"Success":    c.QueryParam("success"),
+	})
+}
+
+func (h *Handler) GradeSetoran(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+	if !services.IsTeacher(user.Role) {
+		return c.Redirect(http.StatusSeeOther, "/user/dashboard")
+	}
+	back := "/school/setoran?class=" + url.QueryEscape(c.FormValue("class"))
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.Redirect(http.StatusSeeOther, back+"&error=ID tidak valid")
+	}
+	date, err := time.Parse("2006-01-02", c.FormValue("date"))
+	if err != nil {
+		date = time.Now()
+	}
+
+	item, err := h.SetoranService.Grade(user, id, c.FormValue("grade"), c.FormValue("tajwid_notes"), date)
+	if err != nil {
+		msg := "Gagal menyimpan nilai"
+		switch err {
+		case sql.ErrNoRows:
+			msg = "Setoran tidak ditemukan"
+		case services.ErrInvalidSetoranGrade, services.ErrSetoranForbidden:
+			msg = err.Error()
+		}
+		return c.Redirect(http.StatusSeeOther, back+"&error="+url.QueryEscape(msg))
+	}
+
+	msg := fmt.Sprintf("Setoran %s dinilai %s", item.StudentName, services.SetoranGradeLabel(item.Grade))
+	if item.Points > 0 {
+		msg += fmt.Sprintf(" (+%d poin)", item.Points)
+	}
+	return c.Redirect(http.StatusSeeOther, back+"&success="+url.QueryEscape(msg))
+}
+
+// ExportSetoran downloads the graded setoran of a class as a spreadsheet
+func (h *Handler) ExportSetoran(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+	if !services.IsTeacher(user.Role) {
+		return c.Redirect(http.StatusSeeOther, "/user/dashboard")
+	}
+	if !services.HasSchoolScope(user) {
+		return c.Redirect(http.StatusSeeOther, "/user/dashboard?error=Anda belum terdaftar di sekolah")
+	}
+
+	class := c.QueryParam("class")
+	startDate := c.QueryParam("start_date")
+	endDate := c.QueryParam("end_date")
+	if endDate == "" {
+		endDate = time.Now().Format("2006-01-02")
+	}
+	if startDate == "" {
+		startDate = endDate[:8] + "01"
+	}
+
+	surahList, _ := h.Content.GetAllSurah()
+	f, err := h.ExportService.GenerateSetoranReportExcel(teacherSchool(user), class, startDate, endDate, services.SurahNames(surahList))
+	if err != nil {
+		return c.Redirect(http.StatusSeeOther, "/school/setoran?error=Gagal membuat laporan")
+	}
+
+	name := "Semua_Kelas"
+	if class != "" {
+		name = url.PathEscape(class)
+	}
+	c.Response().Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	c.Response().Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=Laporan_Setoran_%s_%s_%s.xlsx", name, startDate, endDate))
+	return f.Write(c.Response().Writer)
+}
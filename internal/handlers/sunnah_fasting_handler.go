@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ramadhan/amaliah-monitoring/internal/hijri"
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+	"github.com/ramadhan/amaliah-monitoring/internal/services"
+)
+
+type sunnahFastingDayView struct {
+	services.SunnahFastingDay
+	Fasted  bool
+	Excused bool
+	IsToday bool
+	IsPast  bool
+}
+
+// ShowSunnahFasting shows a month of the sunnah fasting calendar with the
+// days the student fasted, and their streaks
+func (h *Handler) ShowSunnahFasting(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+
+	now := time.Now()
+	todayStr := now.Format("2006-01-02")
+	offset := h.hijriOffset(user)
+
+	month, err := time.ParseInLocation("2006-01", c.QueryParam("month"), time.Local)
+	if err != nil {
+		month = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+	}
+	startStr := month.Format("2006-01-02")
+	endStr := month.AddDate(0, 1, -1).Format("2006-01-02")
+
+	fasts, _ := h.SunnahFastingService.Repo.GetByUserAndDateRange(user.ID, startStr, endStr)
+	fasted := make(map[string]bool, len(fasts))
+	for _, f := range fasts {
+		fasted[f.Date] = true
+	}
+	excused := h.UdzurService.Excused(user.ID, startStr, endStr)
+
+	var days []sunnahFastingDayView
+	for _, d := range services.SunnahFastingCalendar(month.Year(), month.Month(), offset) {
+		days = append(days, sunnahFastingDayView{
+			SunnahFastingDay: d,
+			Fasted:           fasted[d.Date],
+			Excused:          excused[d.Date],
+			IsToday:          d.Date == todayStr,
+			IsPast:           d.Date < todayStr,
+		})
+	}
+
+	todayFast, _ := h.SunnahFastingService.Repo.GetByUserAndDate(user.ID, todayStr)
+	streaks, _ := h.SunnahFastingService.Streaks(user.ID, offset, now)
+	syawalDone, syawalTarget := h.SunnahFastingService.SyawalProgress(user.ID, offset, now)
+	total, _ := h.SunnahFastingService.Repo.Count(user.ID, "")
+
+	return c.Render(http.StatusOK, "user/sunnah_fasting.html", map[string]interface{}{
+		"Title":        "Puasa Sunnah",
+		"User":         user,
+		"Days":         days,
+		"EmptyDays":    int(month.Weekday()),
+		"MonthLabel":   fmt.Sprintf("%s %d", bulanNames[month.Month()-1], month.Year()),
+		"HijriLabel":   hijriMonthsLabel(month, offset),
+		"PrevMonth":    month.AddDate(0, -1, 0).Format("2006-01"),
+		"NextMonth":    month.AddDate(0, 1, 0).Format("2006-01"),
+		"Kinds":        services.SunnahFastingKinds,
+		"TodayKinds":   services.SunnahFastingLabels(strings.Join(services.SunnahFastingKindsOn(now, offset), ",")),
+		"TodayFasted":  todayFast != nil,
+		"Streaks":      streaks,
+		"SyawalDone":   syawalDone,
+		"SyawalTarget": syawalTarget,
+		"Total":        total,
+		"TodayDateISO": todayStr,
+		"Error":        c.QueryParam("error"),
+		"Success":      c.QueryParam("success"),
+	})
+}
+
+var bulanNames = []string{"Januari", "Februari", "Maret", "April", "Mei", "Juni", "Juli", "Agustus", "September", "Oktober", "November", "Desember"}
+
+// hijriMonthsLabel names the Hijri months a Gregorian month spans, e.g.
+// "Rabiul Akhir – Jumadil Awal 1448 H"
+func hijriMonthsLabel(month time.Time, offset int) string {
+	first := hijri.FromTime(month, offset)
+	last := hijri.FromTime(month.AddDate(0, 1, -1), offset)
+	from, to := hijri.MonthNames[first.Month-1], hijri.MonthNames[last.Month-1]
+	switch {
+	case first.Month == last.Month:
+		return fmt.Sprintf("%s %d H", from, first.Year)
+	case first.Year == last.Year:
+		return fmt.Sprintf("%s – %s %d H", from, to, last.Year)
+	}
+	return fmt.Sprintf("%s %d – %s %d H", from, first.Year, to, last.Year)
+}
+
+func (h *Handler) SaveSunnahFasting(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+
+	date := c.FormValue("date")
+	if date == "" {
+		date = time.Now().Format("2006-01-02")
+	}
+	back := "/user/puasa-sunnah?month=" + url.QueryEscape(monthOf(date))
+	// Like sunnah prayers, there is no approval queue so a late day is refused
+	late, err := h.EntryPolicyService.Check(user, date, time.Now())
+	if err == nil && late {
+		err = services.ErrEntryNeedsApproval
+	}
+	if err != nil {
+		return c.Redirect(http.StatusSeeOther, back+"&error="+url.QueryEscape(err.Error()))
+	}
+
+	fast, err := h.SunnahFastingService.Log(user, date, h.hijriOffset(user), time.Now())
+	if err != nil {
+		return c.Redirect(http.StatusSeeOther, back+"&error="+url.QueryEscape(err.Error()))
+	}
+
+	h.LiveDashboardService.Record(user, "fasting", "mencatat puasa sunnah")
+
+	msg := "Puasa " + services.SunnahFastingLabels(fast.Kinds) + " tercatat"
+	return c.Redirect(http.StatusSeeOther, back+"&success="+url.QueryEscape(msg))
+}
+
+func (h *Handler) DeleteSunnahFasting(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+
+	date := c.Param("date")
+	back := "/user/puasa-sunnah?month=" + url.QueryEscape(monthOf(date))
+	if _, err := h.EntryPolicyService.Check(user, date, time.Now()); err != nil {
+		return c.Redirect(http.StatusSeeOther, back+"&error="+url.QueryEscape(err.Error()))
+	}
+	if err := h.SunnahFastingService.Repo.Delete(user.ID, date); err != nil {
+		msg := "Gagal menghapus puasa sunnah"
+		if err == sql.ErrNoRows {
+			msg = "Puasa sunnah tidak ditemukan"
+		}
+		return c.Redirect(http.StatusSeeOther, back+"&error="+url.QueryEscape(msg))
+	}
+	return c.Redirect(http.StatusSeeOther, back+"&success=Puasa sunnah dihapus")
+}
+
+// monthOf returns the YYYY-MM of a date for the calendar to return to
+func monthOf(date string) string {
+	if len(date) < 7 {
+		return ""
+	}
+	return date[:7]
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ramadhan/amaliah-monitoring/internal/hijri"
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+	"github.com/ramadhan/amaliah-monitoring/internal/services"
+)
+
+func (h *Handler) ShowSunnah(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+
+	today := time.Now()
+	todayStr := today.Format("2006-01-02")
+
+	sunnah, err := h.SunnahService.Repo.GetByUserAndDate(user.ID, todayStr)
+	if err != nil {
+		sunnah = &models.SunnahPrayer{UserID: user.ID, Date: todayStr}
+	}
+	rawatib := make(map[string]bool)
+	for _, k := range strings.Split(sunnah.Rawatib, ",") {
+		rawatib[k] = true
+	}
+
+	monthAgo := today.AddDate(0, 0, -29).Format("2006-01-02")
+	stats, _ := h.SunnahService.Repo.GetStats(user.ID, monthAgo, todayStr)
+	streaks, _ := h.SunnahService.Streaks(user.ID, today)
+	weekAgo := today.AddDate(0, 0, -6).Format("2006-01-02")
+	week, _ := h.SunnahService.Repo.GetByUserAndDateRange(user.ID, weekAgo, todayStr)
+
+	_, isRamadhan := hijri.RamadhanDay(today, h.hijriOffset(user))
+
+	return c.Render(http.StatusOK, "user/sunnah.html", map[string]interface{}{
+		"Title":          "Shalat Sunnah",
+		"User":           user,
+		"Sunnah":         sunnah,
+		"Rawatib":        rawatib,
+		"RawatibPrayers": services.RawatibPrayers,
+		"Kinds":          services.SunnahKinds,
+		"Stats":          stats,
+		"Streaks":        streaks,
+		"Week":           week,
+		"IsRamadhan":     isRamadhan || sunnah.TarawihRakaat > 0,
+		"TodayDateISO":   todayStr,
+		"Error":          c.QueryParam("error"),
+		"Success":        c.QueryParam("success"),
+	})
+}
+
+func (h *Handler) SaveSunnah(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+
+	date := c.FormValue("date")
+	if date == "" {
+		date = time.Now().Format("2006-01-02")
+	}
+	// Sunnah prayers have no approval queue, so a late day is simply refused
+	late, err := h.EntryPolicyService.Check(user, date, time.Now())
+	if err == nil && late {
+		err = services.ErrEntryNeedsApproval
+	}
+	if err != nil {
+		return c.Redirect(http.StatusSeeOther, "/user/sunnah?error="+url.QueryEscape(err.Error()))
+	}
+
+	rakaat := func(name string) int {
+		n, _ := strconv.Atoi(c.FormValue(name))
+		return n
+	}
+	form, _ := c.FormParams()
+	rawatib, err := services.NormalizeRawatib(form["rawatib"])
+	if err != nil {
+		return c.Redirect(http.StatusSeeOther, "/user/sunnah?error="+url.QueryEscape(err.Error()))
+	}
+
+	sunnah := &models.SunnahPrayer{
+		UserID:        user.ID,
+		Date:          date,
+		TarawihRakaat: rakaat("tarawih_rakaat"),
+		TarawihPlace:  c.FormValue("tarawih_place"),
+		TarawihMode:   c.FormValue("tarawih_mode"),
+		WitirRakaat:   rakaat("witir_rakaat"),
+		DhuhaRakaat:   rakaat("dhuha_rakaat"),
+		TahajudRakaat: rakaat("tahajud_rakaat"),
+		Rawatib:       rawatib,
+	}
+	if err := h.SunnahService.Save(sunnah); err != nil {
+		return c.Redirect(http.StatusSeeOther, "/user/sunnah?error="+url.QueryEscape(err.Error()))
+	}
+
+	h.LiveDashboardService.Record(user, "prayer", "mengisi shalat sunnah")
+
+	return c.Redirect(http.StatusSeeOther, "/user/sunnah?success=Shalat sunnah tersimpan")
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+	"github.com/ramadhan/amaliah-monitoring/internal/services"
+)
+
+// SyncAPI applies a batch of entries queued by the service worker while offline
+func (h *Handler) SyncAPI(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+
+	var req models.SyncRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request"})
+	}
+	if len(req.Items) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Tidak ada data untuk disinkronkan"})
+	}
+	if len(req.Items) > services.MaxSyncBatch {
+		return c.JSON(http.StatusRequestEntityTooLarge, map[string]string{"error": "Terlalu banyak data dalam satu batch"})
+	}
+
+	results := h.SyncService.Apply(user.ID, req.Items)
+
+	// Let live dashboards know about entries that actually changed data
+	for i, result := range results {
+		if result.Status == "applied" {
+			h.LiveDashboardService.Record(user, req.Items[i].Type, "sinkronisasi offline")
+		}
+	}
+
+	return c.JSON(http.StatusOK, models.SyncResponse{
+		ServerTime: time.Now().UTC().Format(time.RFC3339),
+		Results:    results,
+	})
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+	"github.com/ramadhan/amaliah-monitoring/internal/mushaf"
+	"github.com/ramadhan/amaliah-monitoring/internal/services"
+)
+
+// ShowTahfidz shows today's murajaah list, the memorization map per juz and
+// the form to add a newly memorized range
+func (h *Handler) ShowTahfidz(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+	today := time.Now()
+
+	due, _ := h.TahfidzService.Due(user.ID, today)
+	hafalan, _ := h.TahfidzService.Repo.GetByUser(user.ID)
+	reviews, _ := h.TahfidzService.Repo.GetReviews(user.ID, 10)
+
+	surahList, _ := h.Content.GetAllSurah()
+	surahNames := services.SurahNames(surahList)
+	setoran, _ := h.SetoranService.Repo.GetByStudent(user.ID, 20)
+	label := func(items []*models.Hafalan) []hafalanView {
+		views := make([]hafalanView, len(items))
+		for i, item := range items {
+			views[i] = hafalanView{Hafalan: item, Label: services.RangeLabel(surahNames, hafalanRange(item))}
+		}
+		return views
+	}
+
+	return c.Render(http.StatusOK, "user/tahfidz.html", map[string]interface{}{
+		"Title":     "Tahfidz",
+		"User":      user,
+		"Due":       label(due),
+		"Hafalan":   label(hafalan),
+		"Reviews":   reviews,
+		"Map":       services.BuildHafalanMap(hafalan),
+		"MaxLevel":  services.MaxHafalanLevel,
+		"Results":   services.HafalanResults,
+		"SurahList": surahList,
+		"Setoran":   setoranViews(surahNames, setoran),
+		"Today":     today.Format("2006-01-02"),
+		"Error":     c.QueryParam("error"),
+		"Success":   c.QueryParam("success"),
+	})
+}
+
+type hafalanView struct {
+	*models.Hafalan
+	Label string
+}
+
+func hafalanRange(h *models.Hafalan) mushaf.Range {
+	return mushaf.Range{StartSurah: h.StartSurahID, StartAyah: h.StartAyah, EndSurah: h.EndSurahID, EndAyah: h.EndAyah}
+}
+
+func (h *Handler) SaveHafalan(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+
+	r := mushaf.Range{}
+	r.StartSurah, _ = strconv.Atoi(c.FormValue("start_surah_id"))
+	r.StartAyah, _ = strconv.Atoi(c.FormValue("start_ayah"))
+	r.EndSurah, _ = strconv.Atoi(c.FormValue("end_surah_id"))
+	r.EndAyah, _ = strconv.Atoi(c.FormValue("end_ayah"))
+
+	if _, err := h.TahfidzService.Add(user.ID, r, time.Now()); err != nil {
+		return c.Redirect(http.StatusSeeOther, "/user/tahfidz?error=Hafalan tidak valid: "+err.Error())
+	}
+	return c.Redirect(http.StatusSeeOther, "/user/tahfidz?success=Hafalan berhasil ditambahkan")
+}
+
+func (h *Handler) ReviewHafalan(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.Redirect(http.StatusSeeOther, "/user/tahfidz?error=ID tidak valid")
+	}
+	if _, err := h.TahfidzService.Review(user.ID, id, c.FormValue("result"), time.Now()); err != nil {
+		if err == services.ErrInvalidHafalanResult {
+			return c.Redirect(http.StatusSeeOther, "/user/tahfidz?error=Hasil murajaah tidak valid")
+		}
+		return c.Redirect(http.StatusSeeOther, "/user/tahfidz?error=Hafalan tidak ditemukan")
+	}
+	return c.Redirect(http.StatusSeeOther, "/user/tahfidz?success=Murajaah tercatat")
+}
+
+func (h *Handler) DeleteHafalan(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.Redirect(http.StatusSeeOther, "/user/tahfidz?error=ID tidak valid")
+	}
+	if err := h.TahfidzService.Repo.Delete(user.ID, id); err != nil {
+		return c.Redirect(http.StatusSeeOther, "/user/tahfidz?error=Gagal menghapus hafalan")
+	}
+	return c.Redirect(http.StatusSeeOther, "/user/tahfidz?success=Hafalan dihapus")
+}
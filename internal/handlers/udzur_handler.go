@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+	"github.com/ramadhan/amaliah-monitoring/internal/services"
+)
+
+type udzurView struct {
+	*models.UdzurPeriod
+	KindLabel string
+	Days      int
+	// Running is an ongoing period still inside its kind's longest duration
+	Running bool
+}
+
+func newUdzurView(p *models.UdzurPeriod, today string) udzurView {
+	v := udzurView{UdzurPeriod: p, KindLabel: services.UdzurKindLabel(p.Kind)}
+	end := p.EndDate
+	if p.Ongoing && today <= p.EndDate {
+		v.Running, end = true, today
+	}
+	v.Days = len(services.ExcusedDates([]*models.UdzurPeriod{p}, p.StartDate, end))
+	return v
+}
+
+// ShowUdzur lets a student record the days they are excused from prayer and
+// fasting. Nobody but the student sees this page.
+func (h *Handler) ShowUdzur(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+
+	today := time.Now()
+	todayStr := today.Format("2006-01-02")
+	periods, _ := h.UdzurService.Repo.GetByUser(user.ID, 24)
+	views := make([]udzurView, 0, len(periods))
+	var current *udzurView
+	for _, p := range periods {
+		v := newUdzurView(p, todayStr)
+		if p.StartDate <= todayStr && todayStr <= p.EndDate {
+			current = &v
+		}
+		views = append(views, v)
+	}
+	qadha, _ := h.QadhaService.Summary(user.ID, h.hijriOffset(user), today)
+
+	return c.Render(http.StatusOK, "user/udzur.html", map[string]interface{}{
+		"Title":        "Udzur",
+		"User":         user,
+		"Current":      current,
+		"Periods":      views,
+		"Kinds":        services.UdzurKinds,
+		"Qadha":        qadha,
+		"TodayDateISO": todayStr,
+		"Error":        c.QueryParam("error"),
+		"Success":      c.QueryParam("success"),
+	})
+}
+
+func (h *Handler) AddUdzur(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+
+	_, err := h.UdzurService.Add(user, c.FormValue("kind"), c.FormValue("start_date"), c.FormValue("end_date"), time.Now())
+	if err != nil {
+		return c.Redirect(http.StatusSeeOther, "/user/udzur?error="+url.QueryEscape(err.Error()))
+	}
+	return c.Redirect(http.StatusSeeOther, "/user/udzur?success=Udzur tercatat")
+}
+
+func (h *Handler) EndUdzur(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+
+	id, _ := strconv.Atoi(c.Param("id"))
+	end := c.FormValue("end_date")
+	if end == "" {
+		end = time.Now().Format("2006-01-02")
+	}
+	if err := h.UdzurService.End(user, id, end, time.Now()); err != nil {
+		msg := err.Error()
+		if err == sql.ErrNoRows {
+			msg = "Udzur tidak ditemukan"
+		}
+		return c.Redirect(http.StatusSeeOther, "/user/udzur?error="+url.QueryEscape(msg))
+	}
+	return c.Redirect(http.StatusSeeOther, "/user/udzur?success=Udzur diakhiri")
+}
+
+func (h *Handler) DeleteUdzur(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+
+	id, _ := strconv.Atoi(c.Param("id"))
+	if err := h.UdzurService.Repo.Delete(id, user.ID); err != nil {
+		return c.Redirect(http.StatusSeeOther, "/user/udzur?error=Udzur tidak ditemukan")
+	}
+	return c.Redirect(http.StatusSeeOther, "/user/udzur?success=Udzur dihapus")
+}
@@ -0,0 +1,145 @@
+// Package hijri converts between Gregorian and Hijri dates using the
+// tabular (arithmetic) Islamic calendar. Sighting-based decisions such as
+// the Indonesian isbat can differ by a day or two; callers pass an offset
+// in days to follow them.
+package hijri
+
+import (
+	"fmt"
+	"time"
+)
+
+const Ramadhan = 9
+
+var MonthNames = []string{
+	"Muharram", "Safar", "Rabiul Awal", "Rabiul Akhir", "Jumadil Awal", "Jumadil Akhir",
+	"Rajab", "Sya'ban", "Ramadhan", "Syawal", "Dzulqa'dah", "Dzulhijjah",
+}
+
+// MaxOffset bounds the per-school correction applied to the tabular calendar
+const MaxOffset = 2
+
+type Date struct {
+	Year  int
+	Month int
+	Day   int
+}
+
+func (d Date) String() string {
+	return fmt.Sprintf("%d %s %d H", d.Day, MonthNames[d.Month-1], d.Year)
+}
+
+// FromTime converts the calendar date of t. A positive offset moves the
+// Hijri date forward, e.g. +1 when the month was sighted a day earlier
+// than the tabular calendar predicts.
+func FromTime(t time.Time, offset int) Date {
+	return fromJDN(julianDay(t) + offset)
+}
+
+// Time returns the Gregorian date (midnight, local time) of d
+func (d Date) Time(offset int) time.Time {
+	return fromJulianDay(toJDN(d) - offset)
+}
+
+// MonthLength is 29 or 30 days
+func MonthLength(year, month int) int {
+	next := Date{Year: year, Month: month + 1, Day: 1}
+	if month == 12 {
+		next = Date{Year: year + 1, Month: 1, Day: 1}
+	}
+	return toJDN(next) - toJDN(Date{Year: year, Month: month, Day: 1})
+}
+
+// Period is one Hijri month laid out on Gregorian dates
+type Period struct {
+	Year  int
+	Month int
+	Start time.Time // day 1
+	Days  int
+}
+
+// RamadhanOf returns Ramadhan of the given Hijri year
+func RamadhanOf(year, offset int) Period {
+	first := Date{Year: year, Month: Ramadhan, Day: 1}
+	return Period{
+		Year:  year,
+		Month: Ramadhan,
+		Start: first.Time(offset),
+		Days:  MonthLength(year, Ramadhan),
+	}
+}
+
+// RamadhanFor returns the Ramadhan of t's Hijri year: the coming one before
+// it starts, the past one after it ends
+func RamadhanFor(t time.Time, offset int) Period {
+	return RamadhanOf(FromTime(t, offset).Year, offset)
+}
+
+// End is the last day of the period
+func (p Period) End() time.Time {
+	return p.Start.AddDate(0, 0, p.Days-1)
+}
+
+// Date returns the Gregorian date of day n (1-based)
+func (p Period) Date(n int) time.Time {
+	return p.Start.AddDate(0, 0, n-1)
+}
+
+// Day returns which day of the period t falls on
+func (p Period) Day(t time.Time) (int, bool) {
+	n := julianDay(t) - julianDay(p.Start) + 1
+	if n < 1 || n > p.Days {
+		return 0, false
+	}
+	return n, true
+}
+
+// RamadhanDay returns N for "Ramadhan hari ke-N", or false outside Ramadhan
+func RamadhanDay(t time.Time, offset int) (int, bool) {
+	d := FromTime(t, offset)
+	if d.Month != Ramadhan {
+		return 0, false
+	}
+	return d.Day, true
+}
+
+// ClampOffset keeps a stored offset within ±MaxOffset
+func ClampOffset(offset int) int {
+	if offset > MaxOffset {
+		return MaxOffset
+	}
+	if offset < -MaxOffset {
+		return -MaxOffset
+	}
+	return offset
+}
+
+// julianDay is the Julian Day Number of t's calendar date
+func julianDay(t time.Time) int {
+	y, m, d := t.Date()
+	noon := time.Date(y, m, d, 12, 0, 0, 0, time.UTC)
+	return int(noon.Unix()/86400) + 2440588
+}
+
+func fromJulianDay(jdn int) time.Time {
+	u := time.Unix(int64(jdn-2440588)*86400, 0).UTC()
+	return time.Date(u.Year(), u.Month(), u.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// Tabular calendar with the 2, 5, 7, 10, 13, 16, 18, 21, 24, 26, 29 leap
+// years of each 30-year cycle, epoch 16 July 622 (Julian)
+func toJDN(d Date) int {
+	return (11*d.Year+3)/30 + 354*d.Year + 30*d.Month - (d.Month-1)/2 + d.Day + 1948440 - 385
+}
+
+func fromJDN(jdn int) Date {
+	l := jdn - 1948440 + 10632
+	n := (l - 1) / 10631
+	l = l - 10631*n + 354
+	j := ((10985-l)/5316)*((50*l)/17719) + (l/5670)*((43*l)/15238)
+	l = l - ((30-j)/15)*((17719*j)/50) - (j/16)*((15238*j)/43) + 29
+	m := (24 * l) / 709
+	d := l - (709*m)/24
+	y := 30*n + j - 30
+	return Date{Year: y, Month: m, Day: d}
+}
@@ -0,0 +1,47 @@
+package hijri
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func date(s string) time.Time {
+	t, _ := time.ParseInLocation("2006-01-02", s, time.Local)
+	return t
+}
+
+func TestFromTime(t *testing.T) {
+	assert.Equal(t, Date{1446, Ramadhan, 1}, FromTime(date("2025-03-01"), 0))
+	assert.Equal(t, Date{1446, 10, 1}, FromTime(date("2025-03-31"), 0))
+	assert.Equal(t, "1 Ramadhan 1447 H", FromTime(date("2026-02-18"), 0).String())
+
+	// Indonesia started 1447 a day after the tabular calendar
+	assert.Equal(t, Date{1447, Ramadhan, 1}, FromTime(date("2026-02-19"), -1))
+
+	d := Date{1447, Ramadhan, 15}
+	assert.Equal(t, d, FromTime(d.Time(-1), -1))
+}
+
+func TestRamadhanPeriod(t *testing.T) {
+	p := RamadhanOf(1447, -1)
+	assert.Equal(t, "2026-02-19", p.Start.Format("2006-01-02"))
+	assert.Equal(t, 30, p.Days)
+	assert.Equal(t, "2026-03-20", p.End().Format("2006-01-02"))
+
+	// Day N runs across the February/March boundary
+	n, ok := p.Day(date("2026-03-01"))
+	assert.True(t, ok)
+	assert.Equal(t, 11, n)
+	_, ok = p.Day(date("2026-03-21"))
+	assert.False(t, ok)
+
+	n, ok = RamadhanDay(date("2026-03-01"), -1)
+	assert.True(t, ok)
+	assert.Equal(t, 11, n)
+
+	// Syawal still shows the Ramadhan that just ended
+	assert.Equal(t, 1447, RamadhanFor(date("2026-04-01"), 0).Year)
+	assert.Equal(t, MaxOffset, ClampOffset(7))
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// ActivityEvent is published whenever a student saves a tracking entry
+type ActivityEvent struct {
+	Kind     string    `json:"kind"` // prayer, fasting, quran, amaliah
+	UserID   int       `json:"user_id"`
+	SchoolID int       `json:"school_id"`
+	FullName string    `json:"full_name"`
+	Class    string    `json:"class"`
+	Summary  string    `json:"summary"`
+	At       time.Time `json:"at"`
+}
+
+// LiveDashboard is the payload pushed to connected admin dashboards
+type LiveDashboard struct {
+	Date           string                   `json:"date"`
+	TotalStudents  int                      `json:"total_students"`
+	ActiveUsers    int                      `json:"active_users"`
+	PrayerUsers    int                      `json:"prayer_users"`
+	FastingUsers   int                      `json:"fasting_users"`
+	Fasting        int                      `json:"fasting"`
+	QuranUsers     int                      `json:"quran_users"`
+	QuranReadings  int                      `json:"quran_readings"`
+	AmaliahUsers   int                      `json:"amaliah_users"`
+	AmaliahPoints  int                      `json:"amaliah_points"`
+	RecentActivity []ActivityEvent          `json:"recent_activity"`
+	Leaderboard    []map[string]interface{} `json:"leaderboard"`
+	GeneratedAt    time.Time                `json:"generated_at"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// CacheEntry is one cached upstream API response
+type CacheEntry struct {
+	Key       string    `json:"key"`
+	Payload   []byte    `json:"-"`
+	Size      int       `json:"size"`
+	FetchedAt time.Time `json:"fetched_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// CacheStat counts how requests for one key namespace were served
+type CacheStat struct {
+	Namespace  string  `json:"namespace"`
+	MemoryHits int64   `json:"memory_hits"`
+	DBHits     int64   `json:"db_hits"`
+	Misses     int64   `json:"misses"`
+	Stale      int64   `json:"stale"`
+	Errors     int64   `json:"errors"`
+	HitRate    float64 `json:"hit_rate"`
+}
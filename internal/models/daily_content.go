@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// DailyContentPin replaces the automatic pick of one kind (ayah, doa or
+// hadits) for a school on a date. Ref is "surah:ayah" for an ayah, the doa
+// ID or the hadits number.
+type DailyContentPin struct {
+	ID        int       `json:"id"`
+	SchoolID  int       `json:"school_id"`
+	Date      string    `json:"date"`
+	Kind      string    `json:"kind"`
+	Ref       string    `json:"ref"`
+	CreatedBy int       `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// EntryPolicy is a school's rule for filling in past days
+type EntryPolicy struct {
+	BackdateDays int  `json:"backdate_days"` // days before today that may still be created or edited
+	LateApproval bool `json:"late_approval"` // entries for past days wait for a teacher
+}
+
+// EntryLock freezes a date after the teacher exported or signed it off. An
+// empty Class locks the whole school.
+type EntryLock struct {
+	ID        int       `json:"id"`
+	SchoolID  int       `json:"school_id"`
+	Class     string    `json:"class"`
+	Date      string    `json:"date"`
+	Source    string    `json:"source"` // export, signoff
+	LockedBy  int       `json:"locked_by"`
+	CreatedAt time.Time `json:"created_at"`
+	// Joined for lists
+	LockedByName string `json:"locked_by_name,omitempty"`
+}
+
+// LateEntry is a past-day entry waiting for teacher approval. Data holds the
+// same payload the offline sync API accepts for Type.
+type LateEntry struct {
+	ID         int        `json:"id"`
+	UserID     int        `json:"user_id"`
+	SchoolID   int        `json:"school_id"`
+	Type       string     `json:"type"` // prayer, fasting, quran, amaliah
+	Date       string     `json:"date"`
+	Data       string     `json:"data"`
+	Status     string     `json:"status"` // pending, approved, rejected
+	Note       string     `json:"note"`
+	ReviewedBy int        `json:"reviewed_by"`
+	ReviewedAt *time.Time `json:"reviewed_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	// Joined for lists
+	StudentName  string `json:"student_name,omitempty"`
+	StudentClass string `json:"student_class,omitempty"`
+}
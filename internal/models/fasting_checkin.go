@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// FastingCheckin records the moment a student ate sahur or broke their fast
+type FastingCheckin struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id"`
+	Date      string    `json:"date"`
+	Meal      string    `json:"meal"` // sahur or iftar
+	CheckedAt time.Time `json:"checked_at"`
+	// ScheduleTime is the imsak or maghrib time the check-in was held
+	// against, empty when no schedule was available
+	ScheduleTime string    `json:"schedule_time"`
+	OnTime       bool      `json:"on_time"`
+	Points       int       `json:"points"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// FastingCheckinStats counts check-ins over a period
+type FastingCheckinStats struct {
+	Sahur       int `json:"sahur"`
+	SahurOnTime int `json:"sahur_on_time"`
+	Iftar       int `json:"iftar"`
+	IftarOnTime int `json:"iftar_on_time"`
+}
+
+// ClockIn is the time of the check-in in loc, e.g. "04:05"
+func (c *FastingCheckin) ClockIn(loc *time.Location) string {
+	return c.CheckedAt.In(loc).Format("15:04")
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// Favorite is a doa, hadits or ayah saved by a user. ContentID is the doa
+// ID, the hadits number or "surah:ayah". The text is copied when the item is
+// saved so the favorites page works offline and without the content API.
+type Favorite struct {
+	ID           int       `json:"id"`
+	UserID       int       `json:"user_id"`
+	ContentType  string    `json:"content_type"` // doa, hadits, ayah
+	ContentID    string    `json:"content_id"`
+	CollectionID int       `json:"collection_id"` // 0 is the default list
+	Position     int       `json:"position"`
+	Title        string    `json:"title"`
+	Arab         string    `json:"arab"`
+	Text         string    `json:"text"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// FavoriteCollection is a named list of favorites, e.g. "Doa Sahur & Berbuka"
+type FavoriteCollection struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id"`
+	Name      string    `json:"name"`
+	Count     int       `json:"count"`
+	CreatedAt time.Time `json:"created_at"`
+}
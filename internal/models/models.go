@@ -20,10 +20,33 @@ type User struct {
 	Provinsi     string    `json:"provinsi"`
 	Kabkota      string    `json:"kabkota"`
 	SchoolID     int       `json:"school_id"`
+	LastActive   string    `json:"last_active,omitempty"` // only filled by UserRepository.Query
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 }
 
+// UserQuery describes one page of the admin user list
+type UserQuery struct {
+	Search      string `json:"q"`
+	Role        string `json:"role"`
+	Class       string `json:"class"`
+	SchoolID    int    `json:"school_id"`
+	ActiveFrom  string `json:"active_from"`  // last activity on or after, YYYY-MM-DD
+	ActiveUntil string `json:"active_until"` // last activity on or before, YYYY-MM-DD
+	Sort        string `json:"sort"`         // name, points, class, created, last_active
+	Order       string `json:"order"`        // asc or desc
+	Page        int    `json:"page"`
+	PerPage     int    `json:"per_page"`
+}
+
+type UserPage struct {
+	Users      []*User `json:"users"`
+	Total      int     `json:"total"`
+	Page       int     `json:"page"`
+	PerPage    int     `json:"per_page"`
+	TotalPages int     `json:"total_pages"`
+}
+
 type Class struct {
 	ID          int       `json:"id"`
 	Name        string    `json:"name"`
@@ -52,25 +75,25 @@ type ChangePasswordRequest struct {
 }
 
 type Prayer struct {
-	ID        int       `json:"id"`
-	UserID    int       `json:"user_id"`
-	Date      string    `json:"date"`
-	Subuh     string    `json:"subuh"`
-	Dzuhur    string    `json:"dzuhur"`
-	Ashar     string    `json:"ashar"`
-	Maghrib   string    `json:"maghrib"`
-	Isya      string    `json:"isya"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID        int          `json:"id"`
+	UserID    int          `json:"user_id"`
+	Date      string       `json:"date"`
+	Subuh     PrayerStatus `json:"subuh"`
+	Dzuhur    PrayerStatus `json:"dzuhur"`
+	Ashar     PrayerStatus `json:"ashar"`
+	Maghrib   PrayerStatus `json:"maghrib"`
+	Isya      PrayerStatus `json:"isya"`
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
 }
 
 type Fasting struct {
-	ID        int       `json:"id"`
-	UserID    int       `json:"user_id"`
-	Date      string    `json:"date"`
-	Status    string    `json:"status"`
-	Reason    string    `json:"reason"`
-	CreatedAt time.Time `json:"created_at"`
+	ID        int           `json:"id"`
+	UserID    int           `json:"user_id"`
+	Date      string        `json:"date"`
+	Status    FastingStatus `json:"status"`
+	Reason    string        `json:"reason"`
+	CreatedAt time.Time     `json:"created_at"`
 }
 
 type QuranReading struct {
@@ -84,10 +107,24 @@ type QuranReading struct {
 	EndSurahName   string    `json:"end_surah_name"`
 	EndAyah        int       `json:"end_ayah"`
 	Pages          int       `json:"pages"`
+	StartPage      int       `json:"start_page"`
+	EndPage        int       `json:"end_page"`
+	StartJuz       int       `json:"start_juz"`
+	EndJuz         int       `json:"end_juz"`
 	Notes          string    `json:"notes"`
 	CreatedAt      time.Time `json:"created_at"`
 }
 
+// QuranBookmark is the last ayah a user read, taken from the end of their
+// latest reading or marked in the Quran reader
+type QuranBookmark struct {
+	UserID    int       `json:"user_id"`
+	SurahID   int       `json:"surah_id"`
+	Ayah      int       `json:"ayah"`
+	Source    string    `json:"source"` // reading, reader
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
 type AmaliahType struct {
 	ID          int       `json:"id"`
 	Name        string    `json:"name"`
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// PrayerTiming records when a prayer's status last changed and where that
+// moment falls in the prayer's time window
+type PrayerTiming struct {
+	UserID   int          `json:"user_id"`
+	Date     string       `json:"date"`
+	Prayer   string       `json:"prayer"`
+	Status   PrayerStatus `json:"status"`
+	MarkedAt time.Time    `json:"marked_at"`
+	// Timing is on_time, late or outside_window; empty when the prayer was
+	// not marked done or no schedule was available
+	Timing string `json:"timing"`
+}
+
+// PrayerTimingStats counts classified prayers over a period
+type PrayerTimingStats struct {
+	OnTime  int `json:"on_time"`
+	Late    int `json:"late"`
+	Outside int `json:"outside_window"`
+	Total   int `json:"total"`
+	// Rate is the share of classified prayers marked on time, in percent
+	Rate int `json:"rate"`
+}
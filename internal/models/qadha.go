@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// QadhaFast is a make-up fast paying back one missed day of the Ramadhan of
+// RamadhanYear (Hijri)
+type QadhaFast struct {
+	ID           int       `json:"id"`
+	UserID       int       `json:"user_id"`
+	Date         string    `json:"date"`
+	RamadhanYear int       `json:"ramadhan_year"`
+	CreatedAt    time.Time `json:"created_at"`
+}
@@ -3,11 +3,12 @@ package models
 import "time"
 
 type School struct {
-	ID        int       `json:"id"`
-	Name      string    `json:"name"`
-	Code      string    `json:"code"`
-	Address   string    `json:"address"`
-	AdminID   int       `json:"admin_id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID          int       `json:"id"`
+	Name        string    `json:"name"`
+	Code        string    `json:"code"`
+	Address     string    `json:"address"`
+	AdminID     int       `json:"admin_id"`
+	HijriOffset int       `json:"hijri_offset"` // days added to the tabular Hijri calendar (isbat)
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
 }
@@ -0,0 +1,23 @@
+package models
+
+// SearchDocument is one searchable entry: an ayah, a surah, a doa or a hadits.
+// Source groups the documents that are indexed together ("doa", "hadits",
+// "surah", "ayah:2") so a source can be replaced in one go.
+type SearchDocument struct {
+	Source string
+	Kind   string // ayah, surah, doa, hadits
+	Ref    string
+	Title  string
+	Body   string
+	Latin  string
+	// Arab is stored normalized, without harakat
+	Arab string
+}
+
+// SearchHit is a ranked match with its snippet marked by \x02 and \x03
+type SearchHit struct {
+	Kind    string
+	Ref     string
+	Title   string
+	Snippet string
+}
@@ -0,0 +1,112 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PrayerStatus is how a fardhu prayer of a day was performed
+type PrayerStatus string
+
+const (
+	PrayerPending PrayerStatus = "belum"
+	PrayerJamaah  PrayerStatus = "jamaah"
+	PrayerAlone   PrayerStatus = "sendiri"
+	PrayerMissed  PrayerStatus = "tidak"
+)
+
+// PrayerStatusValues lists every valid prayer status in form order
+var PrayerStatusValues = []PrayerStatus{PrayerPending, PrayerJamaah, PrayerAlone, PrayerMissed}
+
+// FastingStatus is whether a student fasted on a day
+type FastingStatus string
+
+const (
+	FastingDone    FastingStatus = "puasa"
+	FastingSkipped FastingStatus = "tidak"
+)
+
+// FastingStatusValues lists every valid fasting status
+var FastingStatusValues = []FastingStatus{FastingDone, FastingSkipped}
+
+// InvalidStatusError rejects a status outside the known values of Field
+type InvalidStatusError struct {
+	Field string // subuh … isya, or puasa
+	Value string
+}
+
+func (e *InvalidStatusError) Error() string {
+	if e.Value == "" {
+		return fmt.Sprintf("status %s wajib diisi", e.Field)
+	}
+	return fmt.Sprintf("status %s tidak valid: %s", e.Field, e.Value)
+}
+
+// ParsePrayerStatus reads the status of the prayer field, ignoring case and
+// surrounding spaces
+func ParsePrayerStatus(field, s string) (PrayerStatus, error) {
+	v := PrayerStatus(strings.ToLower(strings.TrimSpace(s)))
+	if !v.Valid() {
+		return "", &InvalidStatusError{Field: field, Value: s}
+	}
+	return v, nil
+}
+
+func (s PrayerStatus) Valid() bool {
+	for _, v := range PrayerStatusValues {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Done reports whether the prayer was performed, alone or in congregation
+func (s PrayerStatus) Done() bool {
+	return s == PrayerJamaah || s == PrayerAlone
+}
+
+// ParseFastingStatus reads a fasting status, ignoring case and surrounding
+// spaces
+func ParseFastingStatus(s string) (FastingStatus, error) {
+	v := FastingStatus(strings.ToLower(strings.TrimSpace(s)))
+	if !v.Valid() {
+		return "", &InvalidStatusError{Field: "puasa", Value: s}
+	}
+	return v, nil
+}
+
+func (s FastingStatus) Valid() bool {
+	for _, v := range FastingStatusValues {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Done reports whether the student fasted
+func (s FastingStatus) Done() bool {
+	return s == FastingDone
+}
+
+// Statuses returns the five prayers of the day in order, subuh first
+func (p *Prayer) Statuses() [5]PrayerStatus {
+	return [5]PrayerStatus{p.Subuh, p.Dzuhur, p.Ashar, p.Maghrib, p.Isya}
+}
+
+// Completed counts the prayers of the day that were performed
+func (p *Prayer) Completed() int {
+	n := 0
+	for _, s := range p.Statuses() {
+		if s.Done() {
+			n++
+		}
+	}
+	return n
+}
+
+// Complete reports whether all five prayers of the day were performed
+func (p *Prayer) Complete() bool {
+	return p.Completed() == 5
+}
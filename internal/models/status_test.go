@@ -0,0 +1,34 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePrayerStatus(t *testing.T) {
+	s, err := ParsePrayerStatus("subuh", " Jamaah ")
+	assert.NoError(t, err)
+	assert.Equal(t, PrayerJamaah, s)
+
+	_, err = ParsePrayerStatus("ashar", "hadir")
+	assert.EqualError(t, err, "status ashar tidak valid: hadir")
+	_, err = ParsePrayerStatus("isya", "")
+	assert.EqualError(t, err, "status isya wajib diisi")
+
+	_, err = ParseFastingStatus("batal")
+	assert.EqualError(t, err, "status puasa tidak valid: batal")
+}
+
+func TestPrayerComplete(t *testing.T) {
+	p := &Prayer{Subuh: PrayerJamaah, Dzuhur: PrayerAlone, Ashar: PrayerJamaah, Maghrib: PrayerJamaah, Isya: PrayerPending}
+	assert.Equal(t, 4, p.Completed())
+	assert.False(t, p.Complete())
+
+	p.Isya = PrayerAlone
+	assert.True(t, p.Complete())
+
+	assert.False(t, PrayerMissed.Done())
+	assert.True(t, FastingDone.Done())
+	assert.False(t, FastingSkipped.Done())
+}
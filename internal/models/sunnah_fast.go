@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// SunnahFast is a sunnah fast on Date. Kinds lists the sunnah fasts that day
+// counts for as comma separated keys, e.g. "senin_kamis,ayyamul_bidh" for a
+// Monday that is also the 13th of the Hijri month.
+type SunnahFast struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id"`
+	Date      string    `json:"date"`
+	Kinds     string    `json:"kinds"`
+	CreatedAt time.Time `json:"created_at"`
+}
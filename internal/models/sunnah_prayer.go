@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// SunnahPrayer is one day of sunnah prayers. A rakaat count of 0 means the
+// prayer was not performed; Rawatib lists the rawatib prayers performed as
+// comma separated keys such as "qabliyah_subuh,badiyah_maghrib".
+type SunnahPrayer struct {
+	ID            int       `json:"id"`
+	UserID        int       `json:"user_id"`
+	Date          string    `json:"date"`
+	TarawihRakaat int       `json:"tarawih_rakaat"`
+	TarawihPlace  string    `json:"tarawih_place"` // masjid or rumah
+	TarawihMode   string    `json:"tarawih_mode"`  // jamaah or sendiri
+	WitirRakaat   int       `json:"witir_rakaat"`
+	DhuhaRakaat   int       `json:"dhuha_rakaat"`
+	TahajudRakaat int       `json:"tahajud_rakaat"`
+	Rawatib       string    `json:"rawatib"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
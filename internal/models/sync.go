@@ -0,0 +1,76 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// SyncRequest is the batch posted by the service worker when connectivity returns
+type SyncRequest struct {
+	Items []SyncItem `json:"items"`
+}
+
+// SyncItem is one queued offline entry. ClientID is a UUID generated on the device
+// so that retrying the same batch never applies an entry twice.
+type SyncItem struct {
+	ClientID        string          `json:"client_id"`
+	Type            string          `json:"type"` // prayer, fasting, quran, amaliah
+	Date            string          `json:"date"`
+	ClientTimestamp string          `json:"client_timestamp"` // RFC3339
+	Data            json.RawMessage `json:"data"`
+}
+
+type SyncResult struct {
+	ClientID        string `json:"client_id"`
+	Status          string `json:"status"` // applied, queued, duplicate, conflict, rejected
+	Message         string `json:"message,omitempty"`
+	ServerTimestamp string `json:"server_timestamp,omitempty"`
+}
+
+type SyncResponse struct {
+	ServerTime string       `json:"server_time"`
+	Results    []SyncResult `json:"results"`
+}
+
+// SyncEntry is the server-side record of a processed SyncItem
+type SyncEntry struct {
+	ID              int       `json:"id"`
+	UserID          int       `json:"user_id"`
+	ClientID        string    `json:"client_id"`
+	EntityType      string    `json:"entity_type"`
+	EntityKey       string    `json:"entity_key"`
+	ClientTimestamp string    `json:"client_timestamp"`
+	Status          string    `json:"status"`
+	Message         string    `json:"message"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+type SyncPrayerData struct {
+	Subuh   *string `json:"subuh"`
+	Dzuhur  *string `json:"dzuhur"`
+	Ashar   *string `json:"ashar"`
+	Maghrib *string `json:"maghrib"`
+	Isya    *string `json:"isya"`
+}
+
+type SyncFastingData struct {
+	Status string `json:"status"`
+	Reason string `json:"reason"`
+}
+
+type SyncQuranData struct {
+	StartSurahID   int    `json:"start_surah_id"`
+	StartSurahName string `json:"start_surah_name"`
+	StartAyah      int    `json:"start_ayah"`
+	EndSurahID     int    `json:"end_surah_id"`
+	EndSurahName   string `json:"end_surah_name"`
+	EndAyah        int    `json:"end_ayah"`
+	Pages          int    `json:"pages"`
+	Notes          string `json:"notes"`
+}
+
+type SyncAmaliahData struct {
+	AmaliahTypeID int    `json:"amaliah_type_id"`
+	Action        string `json:"action"` // add or remove
+	Notes         string `json:"notes"`
+}
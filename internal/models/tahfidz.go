@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// Hafalan is a memorized surah:ayah range scheduled for murajaah. Level rises
+// with every fluent review, which spaces the next review further out, and
+// drops when the student hesitates or forgets.
+type Hafalan struct {
+	ID           int       `json:"id"`
+	UserID       int       `json:"user_id"`
+	StartSurahID int       `json:"start_surah_id"`
+	StartAyah    int       `json:"start_ayah"`
+	EndSurahID   int       `json:"end_surah_id"`
+	EndAyah      int       `json:"end_ayah"`
+	Level        int       `json:"level"`
+	NextReview   string    `json:"next_review"`
+	LastReviewed string    `json:"last_reviewed"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type HafalanReview struct {
+	ID        int       `json:"id"`
+	HafalanID int       `json:"hafalan_id"`
+	UserID    int       `json:"user_id"`
+	Date      string    `json:"date"`
+	Result    string    `json:"result"` // lancar, ragu, lupa
+	Level     int       `json:"level"`  // level after the review
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Setoran is a memorization submission: the student requests a range and a
+// teacher grades it after listening
+type Setoran struct {
+	ID           int    `json:"id"`
+	StudentID    int    `json:"student_id"`
+	TeacherID    int    `json:"teacher_id"`
+	SchoolID     int    `json:"school_id"`
+	StartSurahID int    `json:"start_surah_id"`
+	StartAyah    int    `json:"start_ayah"`
+	EndSurahID   int    `json:"end_surah_id"`
+	EndAyah      int    `json:"end_ayah"`
+	Status       string `json:"status"` // pending, graded
+	Grade        string `json:"grade"`  // lancar, kurang_lancar, ulang
+	TajwidNotes  string `json:"tajwid_notes"`
+	SetoranDate  string `json:"setoran_date"`
+	Points       int    `json:"points"`
+	// Joined for lists and reports
+	StudentName  string    `json:"student_name,omitempty"`
+	StudentClass string    `json:"student_class,omitempty"`
+	TeacherName  string    `json:"teacher_name,omitempty"`
+	RequestedAt  time.Time `json:"requested_at"`
+}
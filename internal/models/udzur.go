@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// UdzurPeriod is a span of days in which a student is excused from prayer and
+// fasting, e.g. haid. Periods are private: teachers only ever see that a day
+// is excused, never the kind or the dates.
+type UdzurPeriod struct {
+	ID        int    `json:"id"`
+	UserID    int    `json:"user_id"`
+	Kind      string `json:"kind"` // haid, nifas
+	StartDate string `json:"start_date"`
+	// EndDate is the last excused day. While Ongoing it is the latest day the
+	// period may last, so a period the student forgot to end still stops.
+	EndDate   string    `json:"end_date"`
+	Ongoing   bool      `json:"ongoing"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,110 @@
+package mushaf
+
+import "sort"
+
+// Span is an inclusive run of ayat identified by their Index
+type Span struct {
+	From int
+	To   int
+}
+
+func (s Span) Len() int {
+	return s.To - s.From + 1
+}
+
+// Span returns the ayat of r. r must be valid.
+func (r Range) Span() Span {
+	return Span{Index(r.StartSurah, r.StartAyah), Index(r.EndSurah, r.EndAyah)}
+}
+
+// JuzSpan returns the ayat of juz n (1-30)
+func JuzSpan(n int) Span {
+	from := Index(juzStarts[n-1][0], juzStarts[n-1][1])
+	if n == JuzCount {
+		return Span{from, AyahCount}
+	}
+	return Span{from, Index(juzStarts[n][0], juzStarts[n][1]) - 1}
+}
+
+// SurahSpan returns the ayat of surah n (1-114)
+func SurahSpan(n int) Span {
+	return Span{surahOffsets[n-1] + 1, surahOffsets[n]}
+}
+
+// Set is a set of ayat kept as sorted, disjoint, non-adjacent spans, so
+// rereading a passage never counts twice.
+type Set struct {
+	spans []Span
+}
+
+// Add merges from..to (inclusive) into the set
+func (s *Set) Add(from, to int) {
+	if from > to {
+		return
+	}
+	// first span that ends at or after from-1 can merge with the new one
+	i := sort.Search(len(s.spans), func(i int) bool { return s.spans[i].To >= from-1 })
+	j := i
+	for j < len(s.spans) && s.spans[j].From <= to+1 {
+		if s.spans[j].From < from {
+			from = s.spans[j].From
+		}
+		if s.spans[j].To > to {
+			to = s.spans[j].To
+		}
+		j++
+	}
+	merged := append([]Span{{from, to}}, s.spans[j:]...)
+	s.spans = append(s.spans[:i], merged...)
+}
+
+// Len returns the number of ayat in the set
+func (s *Set) Len() int {
+	n := 0
+	for _, sp := range s.spans {
+		n += sp.Len()
+	}
+	return n
+}
+
+// Full reports whether every ayah of the Quran is in the set
+func (s *Set) Full() bool {
+	return len(s.spans) == 1 && s.spans[0] == Span{1, AyahCount}
+}
+
+// CountIn returns how many ayat of span are in the set
+func (s *Set) CountIn(span Span) int {
+	n := 0
+	for _, sp := range s.spans {
+		from, to := max(sp.From, span.From), min(sp.To, span.To)
+		if from <= to {
+			n += to - from + 1
+		}
+	}
+	return n
+}
+
+// LastMissing returns the highest ayah of span not in the set, or 0 when
+// span is fully covered
+func (s *Set) LastMissing(span Span) int {
+	at := span.To
+	for i := len(s.spans) - 1; i >= 0 && at >= span.From; i-- {
+		sp := s.spans[i]
+		if sp.From > at {
+			continue
+		}
+		if sp.To < at {
+			return at
+		}
+		at = sp.From - 1
+	}
+	if at >= span.From {
+		return at
+	}
+	return 0
+}
+
+// Spans returns a copy of the merged spans
+func (s *Set) Spans() []Span {
+	return append([]Span(nil), s.spans...)
+}
@@ -0,0 +1,159 @@
+// Package mushaf maps surah:ayah positions onto the pages and juz of the
+// standard Mushaf Madinah so reading progress can be derived from a range
+// instead of a page count typed by the student.
+package mushaf
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+const (
+	SurahCount = 114
+	AyahCount  = 6236
+	PageCount  = 604
+	JuzCount   = 30
+)
+
+// juzStarts holds the first surah:ayah of each juz
+var juzStarts = [JuzCount][2]int{
+	{1, 1}, {2, 142}, {2, 253}, {3, 92}, {4, 24}, {4, 148}, {5, 83}, {6, 111}, {7, 88}, {8, 41},
+	{9, 94}, {11, 6}, {12, 53}, {15, 1}, {17, 1}, {18, 75}, {21, 1}, {23, 1}, {25, 21}, {27, 56},
+	{29, 46}, {33, 31}, {36, 28}, {39, 32}, {41, 47}, {46, 1}, {51, 31}, {58, 1}, {67, 1}, {78, 1},
+}
+
+// surahOffsets[i] is the number of ayat before surah i+1
+var surahOffsets [SurahCount + 1]int
+
+func init() {
+	for i, n := range verseCounts {
+		surahOffsets[i+1] = surahOffsets[i] + n
+	}
+}
+
+// VerseCount returns the number of ayat in surah, or 0 for an unknown surah
+func VerseCount(surah int) int {
+	if surah < 1 || surah > SurahCount {
+		return 0
+	}
+	return verseCounts[surah-1]
+}
+
+// Validate reports whether surah:ayah exists
+func Validate(surah, ayah int) error {
+	if surah < 1 || surah > SurahCount {
+		return fmt.Errorf("surah harus antara 1-%d", SurahCount)
+	}
+	if ayah < 1 {
+		return fmt.Errorf("ayat minimal 1")
+	}
+	if n := verseCounts[surah-1]; ayah > n {
+		return fmt.Errorf("surah %d hanya memiliki %d ayat", surah, n)
+	}
+	return nil
+}
+
+// Index returns the position (1-6236) of surah:ayah counted from Al-Fatihah 1.
+// The position must be valid.
+func Index(surah, ayah int) int {
+	return surahOffsets[surah-1] + ayah
+}
+
+// Position is the inverse of Index
+func Position(index int) (surah, ayah int) {
+	s := sort.Search(SurahCount, func(i int) bool { return surahOffsets[i+1] >= index })
+	return s + 1, index - surahOffsets[s]
+}
+
+// Next returns the ayah after surah:ayah, continuing from Al-Fatihah after An-Nas
+func Next(surah, ayah int) (int, int) {
+	if i := Index(surah, ayah); i < AyahCount {
+		return Position(i + 1)
+	}
+	return 1, 1
+}
+
+// PageOf returns the page (1-604) on which surah:ayah is printed
+func PageOf(surah, ayah int) int {
+	return lastStart(pageStarts[:], surah, ayah)
+}
+
+// JuzOf returns the juz (1-30) containing surah:ayah
+func JuzOf(surah, ayah int) int {
+	return lastStart(juzStarts[:], surah, ayah)
+}
+
+// lastStart returns the 1-based number of the last entry in starts that
+// begins at or before surah:ayah
+func lastStart(starts [][2]int, surah, ayah int) int {
+	n := sort.Search(len(starts), func(i int) bool {
+		s := starts[i]
+		return s[0] > surah || (s[0] == surah && s[1] > ayah)
+	})
+	if n == 0 {
+		return 1
+	}
+	return n
+}
+
+// Range is a reading from StartSurah:StartAyah through EndSurah:EndAyah,
+// both inclusive. A reading past An-Nas is two ranges.
+type Range struct {
+	StartSurah int
+	StartAyah  int
+	EndSurah   int
+	EndAyah    int
+}
+
+// ErrReversed rejects a range whose end surah comes before its start surah
+var ErrReversed = errors.New("surah awal tidak boleh lebih besar dari surah akhir")
+
+func (r Range) Validate() error {
+	if err := Validate(r.StartSurah, r.StartAyah); err != nil {
+		return fmt.Errorf("awal bacaan: %v", err)
+	}
+	if err := Validate(r.EndSurah, r.EndAyah); err != nil {
+		return fmt.Errorf("akhir bacaan: %v", err)
+	}
+	if r.EndSurah < r.StartSurah {
+		return ErrReversed
+	}
+	if r.StartSurah == r.EndSurah && r.StartAyah > r.EndAyah {
+		return fmt.Errorf("ayat awal tidak boleh lebih besar dari ayat akhir pada surah yang sama")
+	}
+	return nil
+}
+
+// Coverage describes the part of the mushaf a Range spans
+type Coverage struct {
+	StartPage int
+	EndPage   int
+	StartJuz  int
+	EndJuz    int
+	// Pages counts every page touched, including partially read ones
+	Pages int
+	Ayahs int
+	// Juz lists the juz touched in reading order
+	Juz []int
+}
+
+// Cover validates r and computes the pages and juz it spans
+func (r Range) Cover() (Coverage, error) {
+	if err := r.Validate(); err != nil {
+		return Coverage{}, err
+	}
+
+	c := Coverage{
+		StartPage: PageOf(r.StartSurah, r.StartAyah),
+		EndPage:   PageOf(r.EndSurah, r.EndAyah),
+		StartJuz:  JuzOf(r.StartSurah, r.StartAyah),
+		EndJuz:    JuzOf(r.EndSurah, r.EndAyah),
+	}
+	c.Pages = c.EndPage - c.StartPage + 1
+	c.Ayahs = Index(r.EndSurah, r.EndAyah) - Index(r.StartSurah, r.StartAyah) + 1
+	for j := c.StartJuz; j <= c.EndJuz; j++ {
+		c.Juz = append(c.Juz, j)
+	}
+	return c, nil
+}
@@ -0,0 +1,100 @@
+package mushaf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndex(t *testing.T) {
+	assert.Equal(t, AyahCount, surahOffsets[SurahCount])
+	assert.Equal(t, 1, Index(1, 1))
+	assert.Equal(t, 8, Index(2, 1))
+	assert.Equal(t, AyahCount, Index(114, 6))
+
+	s, a := Position(Index(36, 28))
+	assert.Equal(t, [2]int{36, 28}, [2]int{s, a})
+
+	s, a = Next(2, 286)
+	assert.Equal(t, [2]int{3, 1}, [2]int{s, a})
+	s, a = Next(114, 6)
+	assert.Equal(t, [2]int{1, 1}, [2]int{s, a})
+}
+
+func TestPageAndJuz(t *testing.T) {
+	assert.Equal(t, 1, PageOf(1, 7))
+	assert.Equal(t, 2, PageOf(2, 1))
+	assert.Equal(t, 22, PageOf(2, 142))
+	assert.Equal(t, 582, PageOf(78, 1))
+	assert.Equal(t, 604, PageOf(114, 6))
+
+	// every juz after the first starts on page 20(n-1)+2
+	for n := 2; n <= JuzCount; n++ {
+		start := juzStarts[n-1]
+		assert.Equal(t, 20*(n-1)+2, PageOf(start[0], start[1]), "juz %d", n)
+		assert.Equal(t, n, JuzOf(start[0], start[1]))
+	}
+	assert.Equal(t, 3, JuzOf(3, 91))
+	assert.Equal(t, 4, JuzOf(3, 92))
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate(2, 286))
+	assert.EqualError(t, Validate(2, 287), "surah 2 hanya memiliki 286 ayat")
+	assert.Error(t, Validate(115, 1))
+	assert.Error(t, Validate(1, 0))
+
+	assert.Error(t, Range{2, 10, 2, 5}.Validate())
+	assert.Error(t, Range{1, 1, 108, 4}.Validate())
+}
+
+func TestCover(t *testing.T) {
+	c, err := Range{1, 1, 1, 7}.Cover()
+	require.NoError(t, err)
+	assert.Equal(t, 1, c.Pages)
+	assert.Equal(t, 7, c.Ayahs)
+
+	// Al-Baqarah 142 through Ali 'Imran 91 is exactly juz 2 and 3
+	c, err = Range{2, 142, 3, 91}.Cover()
+	require.NoError(t, err)
+	assert.Equal(t, 40, c.Pages)
+	assert.Equal(t, []int{2, 3}, c.Juz)
+
+	c, err = Range{1, 1, 114, 6}.Cover()
+	require.NoError(t, err)
+	assert.Equal(t, PageCount, c.Pages)
+	assert.Equal(t, AyahCount, c.Ayahs)
+	assert.Len(t, c.Juz, JuzCount)
+
+	// A reading past An-Nas is logged as two ranges
+	_, err = Range{78, 1, 2, 5}.Cover()
+	assert.ErrorIs(t, err, ErrReversed)
+}
+
+func TestSet(t *testing.T) {
+	var s Set
+	s.Add(10, 20)
+	s.Add(30, 40)
+	s.Add(21, 29) // adjacent on both sides
+	assert.Equal(t, []Span{{10, 40}}, s.Spans())
+
+	s.Add(15, 18) // reread
+	s.Add(50, 60)
+	s.Add(5, 12)
+	assert.Equal(t, []Span{{5, 40}, {50, 60}}, s.Spans())
+	assert.Equal(t, 36+11, s.Len())
+	assert.Equal(t, 6+3, s.CountIn(Span{35, 52}))
+	assert.Equal(t, 49, s.LastMissing(Span{1, 55}))
+	assert.Equal(t, 0, s.LastMissing(Span{50, 60}))
+
+	s.Add(1, AyahCount)
+	assert.True(t, s.Full())
+}
+
+func TestSpans(t *testing.T) {
+	assert.Equal(t, Span{1, 148}, JuzSpan(1))
+	assert.Equal(t, AyahCount, JuzSpan(JuzCount).To)
+	assert.Equal(t, Span{8, 293}, SurahSpan(2))
+	assert.Equal(t, Span{Index(78, 1), Index(114, 6)}, Range{78, 1, 114, 6}.Span())
+}
@@ -144,16 +144,22 @@ func (r *AmaliahRepository) GetTotalPoints(userID int, startDate, endDate string
 }
 
 func (r *AmaliahRepository) GetLeaderboard(limit int) ([]map[string]interface{}, error) {
+	return r.GetLeaderboardBySchool(0, limit)
+}
+
+// GetLeaderboardBySchool ranks the students of one school; schoolID 0 means all schools
+func (r *AmaliahRepository) GetLeaderboardBySchool(schoolID int, limit int) ([]map[string]interface{}, error) {
 	query := `SELECT u.id, u.full_name, u.class, u.points,
 			  COUNT(DISTINCT da.date) as active_days
 			  FROM users u
 			  LEFT JOIN daily_amaliah da ON u.id = da.user_id
 			  WHERE u.role = 'user'
+			  AND (? = 0 OR u.school_id = ?)
 			  GROUP BY u.id
 			  ORDER BY u.points DESC
 			  LIMIT ?`
 
-	rows, err := r.DB.Query(query, limit)
+	rows, err := r.DB.Query(query, schoolID, schoolID, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -181,18 +187,24 @@ func (r *AmaliahRepository) GetLeaderboard(limit int) ([]map[string]interface{},
 // Admin Methods
 
 func (r *AmaliahRepository) GetTodayStats(date string) (map[string]interface{}, error) {
+	return r.GetTodayStatsBySchool(date, 0)
+}
+
+// GetTodayStatsBySchool limits GetTodayStats to one school; schoolID 0 means all schools
+func (r *AmaliahRepository) GetTodayStatsBySchool(date string, schoolID int) (map[string]interface{}, error) {
 	query := `SELECT 
 			  COUNT(DISTINCT da.user_id) as total_users,
 			  COUNT(da.id) as total_amaliah,
 			  COALESCE(SUM(at.points), 0) as total_points
 			  FROM daily_amaliah da
 			  JOIN amaliah_types at ON da.amaliah_type_id = at.id
-			  WHERE da.date = ?`
+			  WHERE da.date = ?
+			  AND (? = 0 OR da.user_id IN (SELECT id FROM users WHERE school_id = ?))`
 
 	stats := make(map[string]interface{})
 	var totalUsers, totalAmaliah, totalPoints int
 
-	err := r.DB.QueryRow(query, date).Scan(&totalUsers, &totalAmaliah, &totalPoints)
+	err := r.DB.QueryRow(query, date, schoolID, schoolID).Scan(&totalUsers, &totalAmaliah, &totalPoints)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+)
+
+type CacheRepository struct {
+	DB *sql.DB
+}
+
+func NewCacheRepository(db *sql.DB) *CacheRepository {
+	return &CacheRepository{DB: db}
+}
+
+func (r *CacheRepository) Get(key string) (*models.CacheEntry, error) {
+	entry := &models.CacheEntry{Key: key}
+	var payload string
+	err := r.DB.QueryRow(`SELECT payload, fetched_at, expires_at FROM api_cache WHERE cache_key = ?`, key).
+		Scan(&payload, &entry.FetchedAt, &entry.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	entry.Payload = []byte(payload)
+	entry.Size = len(payload)
+	return entry, nil
+}
+
+func (r *CacheRepository) Save(entry *models.CacheEntry) error {
+	_, err := r.DB.Exec(`INSERT INTO api_cache (cache_key, payload, fetched_at, expires_at) VALUES (?, ?, ?, ?)
+			  ON CONFLICT(cache_key) DO UPDATE SET payload = excluded.payload,
+			  fetched_at = excluded.fetched_at, expires_at = excluded.expires_at`,
+		entry.Key, string(entry.Payload), entry.FetchedAt, entry.ExpiresAt)
+	return err
+}
+
+// ExpirePrefix marks entries as expired without deleting them, so they can
+// still be served if the next upstream fetch fails
+func (r *CacheRepository) ExpirePrefix(prefix string, at time.Time) (int64, error) {
+	result, err := r.DB.Exec(`UPDATE api_cache SET expires_at = ? WHERE cache_key LIKE ? ESCAPE '\'`,
+		at, escapeLike(prefix)+"%")
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (r *CacheRepository) List(limit int) ([]*models.CacheEntry, error) {
+	rows, err := r.DB.Query(`SELECT cache_key, LENGTH(payload), fetched_at, expires_at
+			  FROM api_cache ORDER BY fetched_at DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*models.CacheEntry
+	for rows.Next() {
+		entry := &models.CacheEntry{}
+		if err := rows.Scan(&entry.Key, &entry.Size, &entry.FetchedAt, &entry.ExpiresAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+func escapeLike(s string) string {
+	r := make([]rune, 0, len(s))
+	for _, c := range s {
+		if c == '%' || c == '_' || c == '\\' {
+			r = append(r, '\\')
+		}
+		r = append(r, c)
+	}
+	return string(r)
+}
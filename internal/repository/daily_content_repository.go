@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+)
+
+type DailyContentRepository struct {
+	DB *sql.DB
+}
+
+func NewDailyContentRepository(db *sql.DB) *DailyContentRepository {
+	return &DailyContentRepository{DB: db}
+}
+
+func (r *DailyContentRepository) queryPins(query string, args ...interface{}) ([]*models.DailyContentPin, error) {
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pins []*models.DailyContentPin
+	for rows.Next() {
+		p := &models.DailyContentPin{}
+		if err := rows.Scan(&p.ID, &p.SchoolID, &p.Date, &p.Kind, &p.Ref, &p.CreatedBy, &p.CreatedAt); err != nil {
+			return nil, err
+		}
+		pins = append(pins, p)
+	}
+	return pins, rows.Err()
+}
+
+// GetPins returns a school's pins for one date
+func (r *DailyContentRepository) GetPins(schoolID int, date string) ([]*models.DailyContentPin, error) {
+	return r.queryPins(`SELECT id, school_id, date(date), kind, ref, COALESCE(created_by, 0), created_at
+			  FROM daily_content_pins WHERE school_id = ? AND date(date) = ?`, schoolID, date)
+}
+
+// GetUpcomingPins returns a school's pins from a date onwards, soonest first
+func (r *DailyContentRepository) GetUpcomingPins(schoolID int, from string) ([]*models.DailyContentPin, error) {
+	return r.queryPins(`SELECT id, school_id, date(date), kind, ref, COALESCE(created_by, 0), created_at
+			  FROM daily_content_pins WHERE school_id = ? AND date(date) >= ? ORDER BY date, kind`, schoolID, from)
+}
+
+// SavePin creates a pin or replaces the school's pin of the same kind on that date
+func (r *DailyContentRepository) SavePin(p *models.DailyContentPin) error {
+	_, err := r.DB.Exec(`INSERT INTO daily_content_pins (school_id, date, kind, ref, created_by) VALUES (?, ?, ?, ?, ?)
+			  ON CONFLICT(school_id, date, kind) DO UPDATE SET ref = excluded.ref, created_by = excluded.created_by,
+			  created_at = CURRENT_TIMESTAMP`,
+		p.SchoolID, p.Date, p.Kind, p.Ref, p.CreatedBy)
+	return err
+}
+
+func (r *DailyContentRepository) DeletePin(schoolID, id int) error {
+	_, err := r.DB.Exec(`DELETE FROM daily_content_pins WHERE id = ? AND school_id = ?`, id, schoolID)
+	return err
+}
@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+)
+
+type EntryPolicyRepository struct {
+	DB *sql.DB
+}
+
+func NewEntryPolicyRepository(db *sql.DB) *EntryPolicyRepository {
+	return &EntryPolicyRepository{DB: db}
+}
+
+// GetPolicy returns the entry policy configured for a school
+func (r *EntryPolicyRepository) GetPolicy(schoolID int) (models.EntryPolicy, error) {
+	var p models.EntryPolicy
+	err := r.DB.QueryRow(`SELECT COALESCE(backdate_days, 1), COALESCE(late_approval, 0) FROM schools WHERE id = ?`,
+		schoolID).Scan(&p.BackdateDays, &p.LateApproval)
+	return p, err
+}
+
+func (r *EntryPolicyRepository) SavePolicy(schoolID int, p models.EntryPolicy) error {
+	_, err := r.DB.Exec(`UPDATE schools SET backdate_days = ?, late_approval = ? WHERE id = ?`,
+		p.BackdateDays, p.LateApproval, schoolID)
+	return err
+}
+
+// Lock freezes a date; locking an already locked date keeps the first lock
+func (r *EntryPolicyRepository) Lock(l *models.EntryLock) error {
+	_, err := r.DB.Exec(`INSERT INTO entry_locks (school_id, class, date, source, locked_by) VALUES (?, ?, ?, ?, ?)
+			  ON CONFLICT(school_id, class, date) DO NOTHING`,
+		l.SchoolID, l.Class, l.Date, l.Source, l.LockedBy)
+	return err
+}
+
+// FindLock returns the lock covering a student of schoolID and class on date.
+// Locks with school 0 cover every school and locks without a class cover
+// every class.
+func (r *EntryPolicyRepository) FindLock(schoolID int, class, date string) (*models.EntryLock, error) {
+	l := &models.EntryLock{}
+	err := r.DB.QueryRow(`SELECT id, school_id, class, date(date), source, COALESCE(locked_by, 0), created_at
+			  FROM entry_locks WHERE date = ? AND (school_id = 0 OR school_id = ?) AND (class = '' OR class = ?)
+			  ORDER BY id LIMIT 1`, date, schoolID, class).
+		Scan(&l.ID, &l.SchoolID, &l.Class, &l.Date, &l.Source, &l.LockedBy, &l.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// FindLockBetween returns the first lock covering a student of schoolID and
+// class on a date from from to to
+func (r *EntryPolicyRepository) FindLockBetween(schoolID int, class, from, to string) (*models.EntryLock, error) {
+	l := &models.EntryLock{}
+	err := r.DB.QueryRow(`SELECT id, school_id, class, date(date), source, COALESCE(locked_by, 0), created_at
+			  FROM entry_locks WHERE date >= ? AND date <= ? AND (school_id = 0 OR school_id = ?) AND (class = '' OR class = ?)
+			  ORDER BY date, id LIMIT 1`, from, to, schoolID, class).
+		Scan(&l.ID, &l.SchoolID, &l.Class, &l.Date, &l.Source, &l.LockedBy, &l.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// GetLocks returns a school's locks from since onwards, newest first.
+// schoolID 0 returns the locks of every school.
+func (r *EntryPolicyRepository) GetLocks(schoolID int, since string) ([]*models.EntryLock, error) {
+	rows, err := r.DB.Query(`SELECT l.id, l.school_id, l.class, date(l.date), l.source, COALESCE(l.locked_by, 0),
+			  l.created_at, COALESCE(u.full_name, '')
+			  FROM entry_locks l LEFT JOIN users u ON u.id = l.locked_by
+			  WHERE (? = 0 OR l.school_id = ?) AND l.date >= ?
+			  ORDER BY l.date DESC, l.class`, schoolID, schoolID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var locks []*models.EntryLock
+	for rows.Next() {
+		l := &models.EntryLock{}
+		if err := rows.Scan(&l.ID, &l.SchoolID, &l.Class, &l.Date, &l.Source, &l.LockedBy, &l.CreatedAt, &l.LockedByName); err != nil {
+			return nil, err
+		}
+		locks = append(locks, l)
+	}
+	return locks, rows.Err()
+}
+
+// DeleteLock removes a lock of the school; schoolID 0 may remove any lock
+func (r *EntryPolicyRepository) DeleteLock(id, schoolID int) error {
+	res, err := r.DB.Exec(`DELETE FROM entry_locks WHERE id = ? AND (? = 0 OR school_id = ?)`, id, schoolID, schoolID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+const lateEntrySelect = `SELECT e.id, e.user_id, e.school_id, e.type, date(e.date), e.data, e.status, e.note,
+			  COALESCE(e.reviewed_by, 0), e.reviewed_at, e.created_at, u.full_name, COALESCE(u.class, '')
+			  FROM late_entries e JOIN users u ON u.id = e.user_id`
+
+func scanLateEntries(rows *sql.Rows) ([]*models.LateEntry, error) {
+	defer rows.Close()
+
+	var entries []*models.LateEntry
+	for rows.Next() {
+		e := &models.LateEntry{}
+		var reviewedAt sql.NullTime
+		err := rows.Scan(&e.ID, &e.UserID, &e.SchoolID, &e.Type, &e.Date, &e.Data, &e.Status, &e.Note,
+			&e.ReviewedBy, &reviewedAt, &e.CreatedAt, &e.StudentName, &e.StudentClass)
+		if err != nil {
+			return nil, err
+		}
+		if reviewedAt.Valid {
+			e.ReviewedAt = &reviewedAt.Time
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+func (r *EntryPolicyRepository) CreateLateEntry(e *models.LateEntry) error {
+	result, err := r.DB.Exec(`INSERT INTO late_entries (user_id, school_id, type, date, data, status) VALUES (?, ?, ?, ?, ?, 'pending')`,
+		e.UserID, e.SchoolID, e.Type, e.Date, e.Data)
+	if err != nil {
+		return err
+	}
+	id, _ := result.LastInsertId()
+	e.ID = int(id)
+	e.Status = "pending"
+	return nil
+}
+
+func (r *EntryPolicyRepository) GetLateEntry(id int) (*models.LateEntry, error) {
+	rows, err := r.DB.Query(lateEntrySelect+` WHERE e.id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := scanLateEntries(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return entries[0], nil
+}
+
+// GetPendingLateEntries returns entries waiting for approval, oldest first.
+// schoolID 0 means every school and an empty class means every class.
+func (r *EntryPolicyRepository) GetPendingLateEntries(schoolID int, class string) ([]*models.LateEntry, error) {
+	rows, err := r.DB.Query(lateEntrySelect+` WHERE e.status = 'pending'
+			  AND (? = 0 OR e.school_id = ?) AND (? = '' OR u.class = ?)
+			  ORDER BY e.created_at, e.id`, schoolID, schoolID, class, class)
+	if err != nil {
+		return nil, err
+	}
+	return scanLateEntries(rows)
+}
+
+// GetLateEntriesByUser returns a student's late entries, newest first
+func (r *EntryPolicyRepository) GetLateEntriesByUser(userID, limit int) ([]*models.LateEntry, error) {
+	rows, err := r.DB.Query(lateEntrySelect+` WHERE e.user_id = ? ORDER BY e.created_at DESC, e.id DESC LIMIT ?`, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanLateEntries(rows)
+}
+
+// ReviewLateEntry closes a pending entry; it returns sql.ErrNoRows when the
+// entry was already reviewed
+func (r *EntryPolicyRepository) ReviewLateEntry(id int, status, note string, reviewerID int) error {
+	res, err := r.DB.Exec(`UPDATE late_entries SET status = ?, note = ?, reviewed_by = ?, reviewed_at = CURRENT_TIMESTAMP
+			  WHERE id = ? AND status = 'pending'`, status, note, reviewerID, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// UpdateLateEntryStatus overrides the status of a reviewed entry, e.g. when
+// writing an approved entry failed
+func (r *EntryPolicyRepository) UpdateLateEntryStatus(id int, status, note string) error {
+	_, err := r.DB.Exec(`UPDATE late_entries SET status = ?, note = ? WHERE id = ?`, status, note, id)
+	return err
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+)
+
+type FastingCheckinRepository struct {
+	DB *sql.DB
+}
+
+func NewFastingCheckinRepository(db *sql.DB) *FastingCheckinRepository {
+	return &FastingCheckinRepository{DB: db}
+}
+
+func (r *FastingCheckinRepository) Create(c *models.FastingCheckin) error {
+	result, err := r.DB.Exec(`INSERT INTO fasting_checkins (user_id, date, meal, checked_at, schedule_time, on_time, points)
+			  VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		c.UserID, c.Date, c.Meal, c.CheckedAt.Round(0), c.ScheduleTime, c.OnTime, c.Points)
+	if err != nil {
+		return err
+	}
+	id, _ := result.LastInsertId()
+	c.ID = int(id)
+	return nil
+}
+
+func (r *FastingCheckinRepository) Delete(id int) error {
+	_, err := r.DB.Exec(`DELETE FROM fasting_checkins WHERE id = ?`, id)
+	return err
+}
+
+// GetByUserAndDateRange returns check-ins keyed by date, then by meal
+func (r *FastingCheckinRepository) GetByUserAndDateRange(userID int, startDate, endDate string) (map[string]map[string]*models.FastingCheckin, error) {
+	rows, err := r.DB.Query(`SELECT id, user_id, date(date), meal, checked_at, schedule_time, on_time, points, created_at
+			  FROM fasting_checkins WHERE user_id = ? AND date(date) BETWEEN ? AND ?`, userID, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[string]map[string]*models.FastingCheckin)
+	for rows.Next() {
+		c := &models.FastingCheckin{}
+		if err := rows.Scan(&c.ID, &c.UserID, &c.Date, &c.Meal, &c.CheckedAt, &c.ScheduleTime, &c.OnTime, &c.Points, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		if result[c.Date] == nil {
+			result[c.Date] = make(map[string]*models.FastingCheckin)
+		}
+		result[c.Date][c.Meal] = c
+	}
+	return result, rows.Err()
+}
+
+// GetByUserAndDate returns one day's check-ins keyed by meal
+func (r *FastingCheckinRepository) GetByUserAndDate(userID int, date string) (map[string]*models.FastingCheckin, error) {
+	days, err := r.GetByUserAndDateRange(userID, date, date)
+	if err != nil {
+		return nil, err
+	}
+	if days[date] == nil {
+		return map[string]*models.FastingCheckin{}, nil
+	}
+	return days[date], nil
+}
+
+// GetStats counts a user's check-ins between two dates
+func (r *FastingCheckinRepository) GetStats(userID int, startDate, endDate string) (*models.FastingCheckinStats, error) {
+	stats := &models.FastingCheckinStats{}
+	err := r.DB.QueryRow(`SELECT
+			  COUNT(CASE WHEN meal = 'sahur' THEN 1 END),
+			  COUNT(CASE WHEN meal = 'sahur' AND on_time THEN 1 END),
+			  COUNT(CASE WHEN meal = 'iftar' THEN 1 END),
+			  COUNT(CASE WHEN meal = 'iftar' AND on_time THEN 1 END)
+			  FROM fasting_checkins
+			  WHERE user_id = ? AND date(date) BETWEEN ? AND ?`,
+		userID, startDate, endDate).Scan(&stats.Sahur, &stats.SahurOnTime, &stats.Iftar, &stats.IftarOnTime)
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
@@ -16,9 +16,9 @@ func NewFastingRepository(db *sql.DB) *FastingRepository {
 }
 
 func (r *FastingRepository) Create(fasting *models.Fasting) error {
-	query := `INSERT INTO fastings (user_id, date, status, reason) VALUES (?, ?, ?, ?)`
+	query := `INSERT INTO fastings (user_id, date, status, reason, updated_at) VALUES (?, ?, ?, ?, ?)`
 
-	result, err := r.DB.Exec(query, fasting.UserID, fasting.Date, fasting.Status, fasting.Reason)
+	result, err := r.DB.Exec(query, fasting.UserID, fasting.Date, fasting.Status, fasting.Reason, time.Now())
 	if err != nil {
 		return err
 	}
@@ -44,9 +44,9 @@ func (r *FastingRepository) GetByUserAndDate(userID int, date string) (*models.F
 }
 
 func (r *FastingRepository) Update(fasting *models.Fasting) error {
-	query := `UPDATE fastings SET status = ?, reason = ? WHERE id = ?`
+	query := `UPDATE fastings SET status = ?, reason = ?, updated_at = ? WHERE id = ?`
 
-	_, err := r.DB.Exec(query, fasting.Status, fasting.Reason, fasting.ID)
+	_, err := r.DB.Exec(query, fasting.Status, fasting.Reason, time.Now(), fasting.ID)
 	return err
 }
 
@@ -80,7 +80,7 @@ func (r *FastingRepository) GetTodayFasting(userID int) (*models.Fasting, error)
 	return r.GetByUserAndDate(userID, today)
 }
 
-func (r *FastingRepository) CreateOrUpdate(userID int, date, status, reason string) error {
+func (r *FastingRepository) CreateOrUpdate(userID int, date string, status models.FastingStatus, reason string) error {
 	existing, err := r.GetByUserAndDate(userID, date)
 	if err != nil {
 		// Create new
@@ -105,7 +105,8 @@ func (r *FastingRepository) GetFastingStats(userID int, startDate, endDate strin
 			  COUNT(CASE WHEN status = 'tidak' THEN 1 END) as not_fasting_count,
 			  COUNT(*) as total_days
 			  FROM fastings 
-			  WHERE user_id = ? AND date BETWEEN ? AND ?`
+			  WHERE user_id = ? AND date BETWEEN ? AND ?
+			  AND ` + notExcused("fastings")
 
 	stats := make(map[string]int)
 	var fasting, notFasting, total int
@@ -132,17 +133,24 @@ func (r *FastingRepository) GetTotalFasting(userID int) (int, error) {
 // Admin Methods
 
 func (r *FastingRepository) GetTodayStats(date string) (map[string]int, error) {
+	return r.GetTodayStatsBySchool(date, 0)
+}
+
+// GetTodayStatsBySchool limits GetTodayStats to one school; schoolID 0 means all schools
+func (r *FastingRepository) GetTodayStatsBySchool(date string, schoolID int) (map[string]int, error) {
 	query := `SELECT 
 			  COUNT(DISTINCT user_id) as total_users,
 			  COUNT(CASE WHEN status = 'puasa' THEN 1 END) as fasting_count,
 			  COUNT(CASE WHEN status = 'tidak' THEN 1 END) as not_fasting_count
 			  FROM fastings 
-			  WHERE date = ?`
+			  WHERE date = ?
+			  AND (? = 0 OR user_id IN (SELECT id FROM users WHERE school_id = ?))
+			  AND ` + notExcused("fastings")
 
 	stats := make(map[string]int)
 	var totalUsers, fasting, notFasting int
 
-	err := r.DB.QueryRow(query, date).Scan(&totalUsers, &fasting, &notFasting)
+	err := r.DB.QueryRow(query, date, schoolID, schoolID).Scan(&totalUsers, &fasting, &notFasting)
 	if err != nil {
 		return nil, err
 	}
@@ -210,8 +218,9 @@ func (r *FastingRepository) GetByUser(userID int, limit int) ([]*models.Fasting,
 }
 
 func (r *FastingRepository) GetFastingStreak(userID int) (int, int, error) {
+	// Excused days are left out, so udzur pauses a streak instead of breaking it
 	query := `SELECT date, status FROM fastings 
-			  WHERE user_id = ? 
+			  WHERE user_id = ? AND ` + notExcused("fastings") + `
 			  ORDER BY date DESC 
 			  LIMIT 60`
 
@@ -223,7 +232,7 @@ func (r *FastingRepository) GetFastingStreak(userID int) (int, int, error) {
 
 	type dayFasting struct {
 		date   string
-		status string
+		status models.FastingStatus
 	}
 	var fastings []dayFasting
 	for rows.Next() {
@@ -240,14 +249,14 @@ func (r *FastingRepository) GetFastingStreak(userID int) (int, int, error) {
 	tempStreak := 0
 
 	for i, f := range fastings {
-		if f.status == "puasa" {
+		if f.status.Done() {
 			tempStreak++
 			if tempStreak > bestStreak {
 				bestStreak = tempStreak
 			}
 		} else {
 			if currentStreak == 0 && i > 0 {
-				if len(fastings) > 0 && fastings[0].status == "puasa" {
+				if len(fastings) > 0 && fastings[0].status.Done() {
 					currentStreak = tempStreak
 				}
 			}
@@ -255,9 +264,31 @@ func (r *FastingRepository) GetFastingStreak(userID int) (int, int, error) {
 		}
 	}
 
-	if currentStreak == 0 && len(fastings) > 0 && fastings[0].status == "puasa" {
+	if currentStreak == 0 && len(fastings) > 0 && fastings[0].status.Done() {
 		currentStreak = tempStreak
 	}
 
 	return currentStreak, bestStreak, nil
 }
+
+// GetUpdatedAt returns when the fasting entry for a date was last written
+func (r *FastingRepository) GetUpdatedAt(userID int, date string) (time.Time, error) {
+	query := `SELECT created_at, updated_at FROM fastings WHERE user_id = ? AND date = ?`
+
+	var createdAt time.Time
+	var updatedAt sql.NullTime
+	err := r.DB.QueryRow(query, userID, date).Scan(&createdAt, &updatedAt)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if updatedAt.Valid {
+		return updatedAt.Time, nil
+	}
+	return createdAt, nil
+}
+
+// SetUpdatedAt overrides the edit timestamp, see PrayerRepository.SetUpdatedAt
+func (r *FastingRepository) SetUpdatedAt(userID int, date string, t time.Time) error {
+	_, err := r.DB.Exec(`UPDATE fastings SET updated_at = ? WHERE user_id = ? AND date = ?`, t, userID, date)
+	return err
+}
@@ -0,0 +1,207 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+)
+
+type FavoriteRepository struct {
+	DB *sql.DB
+}
+
+func NewFavoriteRepository(db *sql.DB) *FavoriteRepository {
+	return &FavoriteRepository{DB: db}
+}
+
+const favoriteColumns = `id, user_id, content_type, content_id, COALESCE(collection_id, 0), position,
+			  title, COALESCE(arab, ''), COALESCE(text, ''), created_at`
+
+func scanFavorites(rows *sql.Rows) ([]*models.Favorite, error) {
+	defer rows.Close()
+
+	var list []*models.Favorite
+	for rows.Next() {
+		f := &models.Favorite{}
+		err := rows.Scan(&f.ID, &f.UserID, &f.ContentType, &f.ContentID, &f.CollectionID, &f.Position,
+			&f.Title, &f.Arab, &f.Text, &f.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, f)
+	}
+	return list, rows.Err()
+}
+
+// collectionArg stores the default list (0) as NULL
+func collectionArg(id int) interface{} {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
+
+// Create appends a favorite to the end of its collection
+func (r *FavoriteRepository) Create(f *models.Favorite) error {
+	result, err := r.DB.Exec(`INSERT INTO favorites (user_id, content_type, content_id, collection_id, position, title, arab, text)
+			  VALUES (?, ?, ?, ?, (SELECT COALESCE(MAX(position), 0) + 1 FROM favorites WHERE user_id = ? AND COALESCE(collection_id, 0) = ?), ?, ?, ?)`,
+		f.UserID, f.ContentType, f.ContentID, collectionArg(f.CollectionID), f.UserID, f.CollectionID, f.Title, f.Arab, f.Text)
+	if err != nil {
+		return err
+	}
+	id, _ := result.LastInsertId()
+	f.ID = int(id)
+	return nil
+}
+
+func (r *FavoriteRepository) GetByID(userID, id int) (*models.Favorite, error) {
+	rows, err := r.DB.Query(`SELECT `+favoriteColumns+` FROM favorites WHERE id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	list, err := scanFavorites(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return list[0], nil
+}
+
+func (r *FavoriteRepository) GetByContent(userID int, contentType, contentID string) (*models.Favorite, error) {
+	rows, err := r.DB.Query(`SELECT `+favoriteColumns+` FROM favorites WHERE user_id = ? AND content_type = ? AND content_id = ?`,
+		userID, contentType, contentID)
+	if err != nil {
+		return nil, err
+	}
+	list, err := scanFavorites(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return list[0], nil
+}
+
+// GetByUser returns all favorites grouped by collection in their saved order
+func (r *FavoriteRepository) GetByUser(userID int) ([]*models.Favorite, error) {
+	rows, err := r.DB.Query(`SELECT `+favoriteColumns+` FROM favorites WHERE user_id = ?
+			  ORDER BY COALESCE(collection_id, 0), position, id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	return scanFavorites(rows)
+}
+
+// GetIDs returns the favorited content IDs of one type, for marking items on reader pages
+func (r *FavoriteRepository) GetIDs(userID int, contentType string) (map[string]bool, error) {
+	rows, err := r.DB.Query(`SELECT content_id FROM favorites WHERE user_id = ? AND content_type = ?`, userID, contentType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids[id] = true
+	}
+	return ids, rows.Err()
+}
+
+func (r *FavoriteRepository) Delete(userID, id int) error {
+	_, err := r.DB.Exec(`DELETE FROM favorites WHERE id = ? AND user_id = ?`, id, userID)
+	return err
+}
+
+// SetCollection moves a favorite to the end of another collection
+func (r *FavoriteRepository) SetCollection(userID, id, collectionID int) error {
+	_, err := r.DB.Exec(`UPDATE favorites SET collection_id = ?,
+			  position = (SELECT COALESCE(MAX(position), 0) + 1 FROM favorites WHERE user_id = ? AND COALESCE(collection_id, 0) = ?)
+			  WHERE id = ? AND user_id = ?`,
+		collectionArg(collectionID), userID, collectionID, id, userID)
+	return err
+}
+
+// Swap exchanges the positions of two favorites of the same user
+func (r *FavoriteRepository) Swap(userID int, a, b *models.Favorite) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE favorites SET position = ? WHERE id = ? AND user_id = ?`, b.Position, a.ID, userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE favorites SET position = ? WHERE id = ? AND user_id = ?`, a.Position, b.ID, userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *FavoriteRepository) GetCollections(userID int) ([]*models.FavoriteCollection, error) {
+	rows, err := r.DB.Query(`SELECT c.id, c.user_id, c.name, c.created_at,
+			  (SELECT COUNT(*) FROM favorites f WHERE f.collection_id = c.id)
+			  FROM favorite_collections c WHERE c.user_id = ? ORDER BY c.name`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*models.FavoriteCollection
+	for rows.Next() {
+		c := &models.FavoriteCollection{}
+		if err := rows.Scan(&c.ID, &c.UserID, &c.Name, &c.CreatedAt, &c.Count); err != nil {
+			return nil, err
+		}
+		list = append(list, c)
+	}
+	return list, rows.Err()
+}
+
+func (r *FavoriteRepository) CreateCollection(c *models.FavoriteCollection) error {
+	result, err := r.DB.Exec(`INSERT INTO favorite_collections (user_id, name) VALUES (?, ?)`, c.UserID, c.Name)
+	if err != nil {
+		return err
+	}
+	id, _ := result.LastInsertId()
+	c.ID = int(id)
+	return nil
+}
+
+// CollectionExists reports whether the collection belongs to the user
+func (r *FavoriteRepository) CollectionExists(userID, id int) bool {
+	var n int
+	r.DB.QueryRow(`SELECT COUNT(*) FROM favorite_collections WHERE id = ? AND user_id = ?`, id, userID).Scan(&n)
+	return n > 0
+}
+
+// DeleteCollection removes a collection; its favorites return to the default list
+func (r *FavoriteRepository) DeleteCollection(userID, id int) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`DELETE FROM favorite_collections WHERE id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	_, err = tx.Exec(`UPDATE favorites SET collection_id = NULL,
+			  position = position + (SELECT COALESCE(MAX(position), 0) FROM favorites WHERE user_id = ? AND collection_id IS NULL)
+			  WHERE user_id = ? AND collection_id = ?`, userID, userID, id)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
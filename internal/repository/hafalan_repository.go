@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+)
+
+type HafalanRepository struct {
+	DB *sql.DB
+}
+
+func NewHafalanRepository(db *sql.DB) *HafalanRepository {
+	return &HafalanRepository{DB: db}
+}
+
+// Dates go through date() so they scan as YYYY-MM-DD rather than timestamps
+const hafalanColumns = `id, user_id, start_surah_id, start_ayah, end_surah_id, end_ayah, level, date(next_review), COALESCE(date(last_reviewed), ''), created_at`
+
+func scanHafalan(rows *sql.Rows) ([]*models.Hafalan, error) {
+	defer rows.Close()
+
+	var items []*models.Hafalan
+	for rows.Next() {
+		h := &models.Hafalan{}
+		err := rows.Scan(&h.ID, &h.UserID, &h.StartSurahID, &h.StartAyah, &h.EndSurahID, &h.EndAyah,
+			&h.Level, &h.NextReview, &h.LastReviewed, &h.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, h)
+	}
+	return items, rows.Err()
+}
+
+func (r *HafalanRepository) Create(h *models.Hafalan) error {
+	query := `INSERT INTO hafalan (user_id, start_surah_id, start_ayah, end_surah_id, end_ayah, level, next_review)
+			  VALUES (?, ?, ?, ?, ?, ?, ?)`
+
+	result, err := r.DB.Exec(query, h.UserID, h.StartSurahID, h.StartAyah, h.EndSurahID, h.EndAyah, h.Level, h.NextReview)
+	if err != nil {
+		return err
+	}
+
+	id, _ := result.LastInsertId()
+	h.ID = int(id)
+	return nil
+}
+
+// GetByUser returns all memorized ranges of a user in mushaf order
+func (r *HafalanRepository) GetByUser(userID int) ([]*models.Hafalan, error) {
+	rows, err := r.DB.Query(`SELECT `+hafalanColumns+` FROM hafalan WHERE user_id = ?
+			  ORDER BY start_surah_id, start_ayah`, userID)
+	if err != nil {
+		return nil, err
+	}
+	return scanHafalan(rows)
+}
+
+// GetDue returns the ranges whose review is due on or before date, weakest first
+func (r *HafalanRepository) GetDue(userID int, date string) ([]*models.Hafalan, error) {
+	rows, err := r.DB.Query(`SELECT `+hafalanColumns+` FROM hafalan WHERE user_id = ? AND next_review <= ?
+			  ORDER BY next_review, level, start_surah_id, start_ayah`, userID, date)
+	if err != nil {
+		return nil, err
+	}
+	return scanHafalan(rows)
+}
+
+func (r *HafalanRepository) GetByID(userID, id int) (*models.Hafalan, error) {
+	rows, err := r.DB.Query(`SELECT `+hafalanColumns+` FROM hafalan WHERE user_id = ? AND id = ?`, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	items, err := scanHafalan(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return items[0], nil
+}
+
+func (r *HafalanRepository) UpdateSchedule(h *models.Hafalan) error {
+	_, err := r.DB.Exec(`UPDATE hafalan SET level = ?, next_review = ?, last_reviewed = ? WHERE id = ?`,
+		h.Level, h.NextReview, h.LastReviewed, h.ID)
+	return err
+}
+
+func (r *HafalanRepository) Delete(userID, id int) error {
+	if _, err := r.DB.Exec(`DELETE FROM hafalan_reviews WHERE hafalan_id = ? AND user_id = ?`, id, userID); err != nil {
+		return err
+	}
+	_, err := r.DB.Exec(`DELETE FROM hafalan WHERE id = ? AND user_id = ?`, id, userID)
+	return err
+}
+
+func (r *HafalanRepository) CreateReview(review *models.HafalanReview) error {
+	result, err := r.DB.Exec(`INSERT INTO hafalan_reviews (hafalan_id, user_id, date, result, level) VALUES (?, ?, ?, ?, ?)`,
+		review.HafalanID, review.UserID, review.Date, review.Result, review.Level)
+	if err != nil {
+		return err
+	}
+
+	id, _ := result.LastInsertId()
+	review.ID = int(id)
+	return nil
+}
+
+// GetReviews returns the latest review results of a user, newest first
+func (r *HafalanRepository) GetReviews(userID, limit int) ([]*models.HafalanReview, error) {
+	rows, err := r.DB.Query(`SELECT id, hafalan_id, user_id, date(date), result, level, created_at FROM hafalan_reviews
+			  WHERE user_id = ? ORDER BY created_at DESC, id DESC LIMIT ?`, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reviews []*models.HafalanReview
+	for rows.Next() {
+		rv := &models.HafalanReview{}
+		if err := rows.Scan(&rv.ID, &rv.HafalanID, &rv.UserID, &rv.Date, &rv.Result, &rv.Level, &rv.CreatedAt); err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, rv)
+	}
+	return reviews, rows.Err()
+}
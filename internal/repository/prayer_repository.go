@@ -86,7 +86,7 @@ func (r *PrayerRepository) GetTodayPrayer(userID int) (*models.Prayer, error) {
 	return r.GetByUserAndDate(userID, today)
 }
 
-func (r *PrayerRepository) CreateOrUpdate(userID int, date string, subuh, dzuhur, ashar, maghrib, isya string) error {
+func (r *PrayerRepository) CreateOrUpdate(userID int, date string, subuh, dzuhur, ashar, maghrib, isya models.PrayerStatus) error {
 	existing, err := r.GetByUserAndDate(userID, date)
 	if err != nil {
 		// Create new
@@ -120,7 +120,8 @@ func (r *PrayerRepository) GetPrayerStats(userID int, startDate, endDate string)
 			  COUNT(CASE WHEN isya IN ('jamaah', 'sendiri') THEN 1 END) as isya_count,
 			  COUNT(*) as total_days
 			  FROM prayers 
-			  WHERE user_id = ? AND date BETWEEN ? AND ?`
+			  WHERE user_id = ? AND date BETWEEN ? AND ?
+			  AND `+notExcused("prayers")
 
 	stats := make(map[string]int)
 	var subuh, dzuhur, ashar, maghrib, isya, total int
@@ -143,6 +144,11 @@ func (r *PrayerRepository) GetPrayerStats(userID int, startDate, endDate string)
 // Admin Methods
 
 func (r *PrayerRepository) GetTodayStats(date string) (map[string]int, error) {
+	return r.GetTodayStatsBySchool(date, 0)
+}
+
+// GetTodayStatsBySchool limits GetTodayStats to one school; schoolID 0 means all schools
+func (r *PrayerRepository) GetTodayStatsBySchool(date string, schoolID int) (map[string]int, error) {
 	query := `SELECT 
 			  COUNT(DISTINCT user_id) as total_users,
 			  COUNT(CASE WHEN subuh IN ('jamaah', 'sendiri') THEN 1 END) as subuh_count,
@@ -151,12 +157,14 @@ func (r *PrayerRepository) GetTodayStats(date string) (map[string]int, error) {
 			  COUNT(CASE WHEN maghrib IN ('jamaah', 'sendiri') THEN 1 END) as maghrib_count,
 			  COUNT(CASE WHEN isya IN ('jamaah', 'sendiri') THEN 1 END) as isya_count
 			  FROM prayers 
-			  WHERE date = ?`
+			  WHERE date = ?
+			  AND (? = 0 OR user_id IN (SELECT id FROM users WHERE school_id = ?))
+			  AND `+notExcused("prayers")
 
 	stats := make(map[string]int)
 	var totalUsers, subuh, dzuhur, ashar, maghrib, isya int
 
-	err := r.DB.QueryRow(query, date).Scan(&totalUsers, &subuh, &dzuhur, &ashar, &maghrib, &isya)
+	err := r.DB.QueryRow(query, date, schoolID, schoolID).Scan(&totalUsers, &subuh, &dzuhur, &ashar, &maghrib, &isya)
 	if err != nil {
 		return nil, err
 	}
@@ -180,7 +188,7 @@ func (r *PrayerRepository) GetDailyCompletionStats(startDate, endDate string) ([
 			  COUNT(CASE WHEN isya IN ('jamaah', 'sendiri') THEN 1 END) as isya,
 			  COUNT(DISTINCT user_id) as total_users
 			  FROM prayers 
-			  WHERE date BETWEEN ? AND ?
+			  WHERE date BETWEEN ? AND ? AND `+notExcused("prayers")+`
 			  GROUP BY date
 			  ORDER BY date ASC`
 
@@ -280,9 +288,10 @@ func (r *PrayerRepository) GetByUser(userID int, limit int) ([]*models.Prayer, e
 }
 
 func (r *PrayerRepository) GetPrayerStreak(userID int) (int, int, error) {
+	// Excused days are left out, so udzur pauses a streak instead of breaking it
 	query := `SELECT date, subuh, dzuhur, ashar, maghrib, isya 
 			  FROM prayers 
-			  WHERE user_id = ? 
+			  WHERE user_id = ? AND `+notExcused("prayers")+`
 			  ORDER BY date DESC 
 			  LIMIT 60`
 
@@ -292,53 +301,29 @@ func (r *PrayerRepository) GetPrayerStreak(userID int) (int, int, error) {
 	}
 	defer rows.Close()
 
-	type dayPrayer struct {
-		date                                string
-		subuh, dzuhur, ashar, maghrib, isya string
-	}
-	var prayers []dayPrayer
+	var prayers []*models.Prayer
 	for rows.Next() {
-		var p dayPrayer
-		err := rows.Scan(&p.date, &p.subuh, &p.dzuhur, &p.ashar, &p.maghrib, &p.isya)
+		p := &models.Prayer{}
+		err := rows.Scan(&p.Date, &p.Subuh, &p.Dzuhur, &p.Ashar, &p.Maghrib, &p.Isya)
 		if err != nil {
 			return 0, 0, err
 		}
 		prayers = append(prayers, p)
 	}
 
-	isComplete := func(p dayPrayer) bool {
-		complete := 0
-		if p.subuh == "jamaah" || p.subuh == "sendiri" {
-			complete++
-		}
-		if p.dzuhur == "jamaah" || p.dzuhur == "sendiri" {
-			complete++
-		}
-		if p.ashar == "jamaah" || p.ashar == "sendiri" {
-			complete++
-		}
-		if p.maghrib == "jamaah" || p.maghrib == "sendiri" {
-			complete++
-		}
-		if p.isya == "jamaah" || p.isya == "sendiri" {
-			complete++
-		}
-		return complete == 5
-	}
-
 	currentStreak := 0
 	bestStreak := 0
 	tempStreak := 0
 
 	for _, p := range prayers {
-		if isComplete(p) {
+		if p.Complete() {
 			tempStreak++
 			if tempStreak > bestStreak {
 				bestStreak = tempStreak
 			}
 		} else {
 			if currentStreak == 0 && len(prayers) > 0 {
-				if isComplete(prayers[0]) {
+				if prayers[0].Complete() {
 					currentStreak = tempStreak
 				}
 			}
@@ -346,9 +331,16 @@ func (r *PrayerRepository) GetPrayerStreak(userID int) (int, int, error) {
 		}
 	}
 
-	if currentStreak == 0 && len(prayers) > 0 && isComplete(prayers[0]) {
+	if currentStreak == 0 && len(prayers) > 0 && prayers[0].Complete() {
 		currentStreak = tempStreak
 	}
 
 	return currentStreak, bestStreak, nil
 }
+
+// SetUpdatedAt overrides the edit timestamp, used by offline sync so the row carries
+// the time the student made the change rather than the time it reached the server.
+func (r *PrayerRepository) SetUpdatedAt(userID int, date string, t time.Time) error {
+	_, err := r.DB.Exec(`UPDATE prayers SET updated_at = ? WHERE user_id = ? AND date = ?`, t, userID, date)
+	return err
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+)
+
+type PrayerTimingRepository struct {
+	DB *sql.DB
+}
+
+func NewPrayerTimingRepository(db *sql.DB) *PrayerTimingRepository {
+	return &PrayerTimingRepository{DB: db}
+}
+
+// Save stores the latest status change of one prayer
+func (r *PrayerTimingRepository) Save(t *models.PrayerTiming) error {
+	_, err := r.DB.Exec(`INSERT INTO prayer_timings (user_id, date, prayer, status, marked_at, timing) VALUES (?, ?, ?, ?, ?, ?)
+			  ON CONFLICT(user_id, date, prayer) DO UPDATE SET status = excluded.status,
+			  marked_at = excluded.marked_at, timing = excluded.timing`,
+		t.UserID, t.Date, t.Prayer, t.Status, t.MarkedAt.Round(0), t.Timing)
+	return err
+}
+
+// GetByUserAndDateRange returns timings keyed by date, then by prayer
+func (r *PrayerTimingRepository) GetByUserAndDateRange(userID int, startDate, endDate string) (map[string]map[string]*models.PrayerTiming, error) {
+	rows, err := r.DB.Query(`SELECT user_id, date(date), prayer, status, marked_at, timing
+			  FROM prayer_timings WHERE user_id = ? AND date(date) BETWEEN ? AND ?`, userID, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[string]map[string]*models.PrayerTiming)
+	for rows.Next() {
+		t := &models.PrayerTiming{}
+		if err := rows.Scan(&t.UserID, &t.Date, &t.Prayer, &t.Status, &t.MarkedAt, &t.Timing); err != nil {
+			return nil, err
+		}
+		if result[t.Date] == nil {
+			result[t.Date] = make(map[string]*models.PrayerTiming)
+		}
+		result[t.Date][t.Prayer] = t
+	}
+	return result, rows.Err()
+}
+
+// GetByUserAndDate returns one day's timings keyed by prayer
+func (r *PrayerTimingRepository) GetByUserAndDate(userID int, date string) (map[string]*models.PrayerTiming, error) {
+	days, err := r.GetByUserAndDateRange(userID, date, date)
+	if err != nil {
+		return nil, err
+	}
+	if days[date] == nil {
+		return map[string]*models.PrayerTiming{}, nil
+	}
+	return days[date], nil
+}
+
+// GetStats counts a user's classified prayers between two dates
+func (r *PrayerTimingRepository) GetStats(userID int, startDate, endDate string) (*models.PrayerTimingStats, error) {
+	stats := &models.PrayerTimingStats{}
+	err := r.DB.QueryRow(`SELECT
+			  COUNT(CASE WHEN timing = 'on_time' THEN 1 END),
+			  COUNT(CASE WHEN timing = 'late' THEN 1 END),
+			  COUNT(CASE WHEN timing = 'outside_window' THEN 1 END)
+			  FROM prayer_timings
+			  WHERE user_id = ? AND date(date) BETWEEN ? AND ? AND status IN ('jamaah', 'sendiri')`,
+		userID, startDate, endDate).Scan(&stats.OnTime, &stats.Late, &stats.Outside)
+	if err != nil {
+		return nil, err
+	}
+
+	stats.Total = stats.OnTime + stats.Late + stats.Outside
+	if stats.Total > 0 {
+		stats.Rate = stats.OnTime * 100 / stats.Total
+	}
+	return stats, nil
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+)
+
+type QadhaRepository struct {
+	DB *sql.DB
+}
+
+func NewQadhaRepository(db *sql.DB) *QadhaRepository {
+	return &QadhaRepository{DB: db}
+}
+
+func (r *QadhaRepository) Create(q *models.QadhaFast) error {
+	result, err := r.DB.Exec(`INSERT INTO qadha_fasts (user_id, date, ramadhan_year) VALUES (?, ?, ?)`,
+		q.UserID, q.Date, q.RamadhanYear)
+	if err != nil {
+		return err
+	}
+	id, _ := result.LastInsertId()
+	q.ID = int(id)
+	return nil
+}
+
+func (r *QadhaRepository) Delete(id, userID int) error {
+	res, err := r.DB.Exec(`DELETE FROM qadha_fasts WHERE id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetByUser returns every make-up fast of a user, newest first
+func (r *QadhaRepository) GetByUser(userID int) ([]*models.QadhaFast, error) {
+	rows, err := r.DB.Query(`SELECT id, user_id, date(date), ramadhan_year, created_at
+			  FROM qadha_fasts WHERE user_id = ? ORDER BY date DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var fasts []*models.QadhaFast
+	for rows.Next() {
+		q := &models.QadhaFast{}
+		if err := rows.Scan(&q.ID, &q.UserID, &q.Date, &q.RamadhanYear, &q.CreatedAt); err != nil {
+			return nil, err
+		}
+		fasts = append(fasts, q)
+	}
+	return fasts, rows.Err()
+}
+
+// GetFirstMissedDate returns the earliest day a user did not fast, either a
+// 'tidak' entry or the start of an udzur period; empty when there is none
+func (r *QadhaRepository) GetFirstMissedDate(userID int) (string, error) {
+	var first sql.NullString
+	err := r.DB.QueryRow(`SELECT MIN(d) FROM (
+			  SELECT date(date) AS d FROM fastings WHERE user_id = ? AND status = 'tidak'
+			  UNION ALL
+			  SELECT date(start_date) FROM udzur_periods WHERE user_id = ?)`, userID, userID).Scan(&first)
+	return first.String, err
+}
@@ -16,11 +16,12 @@ func NewQuranRepository(db *sql.DB) *QuranRepository {
 }
 
 func (r *QuranRepository) Create(reading *models.QuranReading) error {
-	query := `INSERT INTO quran_readings (user_id, date, start_surah_id, start_surah_name, start_ayah, end_surah_id, end_surah_name, end_ayah, pages, notes)
-			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	query := `INSERT INTO quran_readings (user_id, date, start_surah_id, start_surah_name, start_ayah, end_surah_id, end_surah_name, end_ayah, pages, start_page, end_page, start_juz, end_juz, notes)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
 	result, err := r.DB.Exec(query, reading.UserID, reading.Date, reading.StartSurahID,
-		reading.StartSurahName, reading.StartAyah, reading.EndSurahID, reading.EndSurahName, reading.EndAyah, reading.Pages, reading.Notes)
+		reading.StartSurahName, reading.StartAyah, reading.EndSurahID, reading.EndSurahName, reading.EndAyah, reading.Pages,
+		reading.StartPage, reading.EndPage, reading.StartJuz, reading.EndJuz, reading.Notes)
 	if err != nil {
 		return err
 	}
@@ -31,7 +32,7 @@ func (r *QuranRepository) Create(reading *models.QuranReading) error {
 }
 
 func (r *QuranRepository) GetByUserAndDate(userID int, date string) ([]*models.QuranReading, error) {
-	query := `SELECT id, user_id, date, start_surah_id, start_surah_name, start_ayah, end_surah_id, end_surah_name, end_ayah, pages, notes, created_at
+	query := `SELECT id, user_id, date, start_surah_id, start_surah_name, start_ayah, end_surah_id, end_surah_name, end_ayah, pages, start_page, end_page, start_juz, end_juz, notes, created_at
 			  FROM quran_readings WHERE user_id = ? AND date = ? ORDER BY created_at DESC`
 
 	rows, err := r.DB.Query(query, userID, date)
@@ -45,7 +46,8 @@ func (r *QuranRepository) GetByUserAndDate(userID int, date string) ([]*models.Q
 		reading := &models.QuranReading{}
 		err := rows.Scan(
 			&reading.ID, &reading.UserID, &reading.Date, &reading.StartSurahID, &reading.StartSurahName,
-			&reading.StartAyah, &reading.EndSurahID, &reading.EndSurahName, &reading.EndAyah, &reading.Pages, &reading.Notes, &reading.CreatedAt,
+			&reading.StartAyah, &reading.EndSurahID, &reading.EndSurahName, &reading.EndAyah, &reading.Pages,
+			&reading.StartPage, &reading.EndPage, &reading.StartJuz, &reading.EndJuz, &reading.Notes, &reading.CreatedAt,
 		)
 		if err != nil {
 			return nil, err
@@ -56,7 +58,7 @@ func (r *QuranRepository) GetByUserAndDate(userID int, date string) ([]*models.Q
 }
 
 func (r *QuranRepository) GetByUser(userID int, limit int) ([]*models.QuranReading, error) {
-	query := `SELECT id, user_id, date, start_surah_id, start_surah_name, start_ayah, end_surah_id, end_surah_name, end_ayah, pages, notes, created_at
+	query := `SELECT id, user_id, date, start_surah_id, start_surah_name, start_ayah, end_surah_id, end_surah_name, end_ayah, pages, start_page, end_page, start_juz, end_juz, notes, created_at
 			  FROM quran_readings WHERE user_id = ? ORDER BY date DESC, created_at DESC LIMIT ?`
 
 	rows, err := r.DB.Query(query, userID, limit)
@@ -70,7 +72,36 @@ func (r *QuranRepository) GetByUser(userID int, limit int) ([]*models.QuranReadi
 		reading := &models.QuranReading{}
 		err := rows.Scan(
 			&reading.ID, &reading.UserID, &reading.Date, &reading.StartSurahID, &reading.StartSurahName,
-			&reading.StartAyah, &reading.EndSurahID, &reading.EndSurahName, &reading.EndAyah, &reading.Pages, &reading.Notes, &reading.CreatedAt,
+			&reading.StartAyah, &reading.EndSurahID, &reading.EndSurahName, &reading.EndAyah, &reading.Pages,
+			&reading.StartPage, &reading.EndPage, &reading.StartJuz, &reading.EndJuz, &reading.Notes, &reading.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		readings = append(readings, reading)
+	}
+	return readings, nil
+}
+
+// GetAllByUserChronological returns every reading of a user oldest first, the
+// order khatam coverage is replayed in
+func (r *QuranRepository) GetAllByUserChronological(userID int) ([]*models.QuranReading, error) {
+	query := `SELECT id, user_id, date, start_surah_id, start_surah_name, start_ayah, end_surah_id, end_surah_name, end_ayah, pages, start_page, end_page, start_juz, end_juz, COALESCE(notes, ''), created_at
+			  FROM quran_readings WHERE user_id = ? ORDER BY date ASC, created_at ASC, id ASC`
+
+	rows, err := r.DB.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var readings []*models.QuranReading
+	for rows.Next() {
+		reading := &models.QuranReading{}
+		err := rows.Scan(
+			&reading.ID, &reading.UserID, &reading.Date, &reading.StartSurahID, &reading.StartSurahName,
+			&reading.StartAyah, &reading.EndSurahID, &reading.EndSurahName, &reading.EndAyah, &reading.Pages,
+			&reading.StartPage, &reading.EndPage, &reading.StartJuz, &reading.EndJuz, &reading.Notes, &reading.CreatedAt,
 		)
 		if err != nil {
 			return nil, err
@@ -127,19 +158,48 @@ func (r *QuranRepository) Delete(id int) error {
 	return err
 }
 
+// GetBookmark returns the user's last-read position, or an error when none is set
+func (r *QuranRepository) GetBookmark(userID int) (*models.QuranBookmark, error) {
+	b := &models.QuranBookmark{}
+	err := r.DB.QueryRow(`SELECT user_id, surah_id, ayah, source, updated_at FROM quran_bookmarks WHERE user_id = ?`, userID).
+		Scan(&b.UserID, &b.SurahID, &b.Ayah, &b.Source, &b.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// SaveBookmark stores b unless the user already has a bookmark newer than
+// b.UpdatedAt, so a reading synced late from an offline device does not move
+// the bookmark backwards
+func (r *QuranRepository) SaveBookmark(b *models.QuranBookmark) error {
+	query := `INSERT INTO quran_bookmarks (user_id, surah_id, ayah, source, updated_at) VALUES (?, ?, ?, ?, ?)
+			  ON CONFLICT(user_id) DO UPDATE SET surah_id = excluded.surah_id, ayah = excluded.ayah,
+			  source = excluded.source, updated_at = excluded.updated_at
+			  WHERE excluded.updated_at >= quran_bookmarks.updated_at`
+	_, err := r.DB.Exec(query, b.UserID, b.SurahID, b.Ayah, b.Source, b.UpdatedAt.UTC())
+	return err
+}
+
 // Admin Methods
 
 func (r *QuranRepository) GetTodayStats(date string) (map[string]interface{}, error) {
+	return r.GetTodayStatsBySchool(date, 0)
+}
+
+// GetTodayStatsBySchool limits GetTodayStats to one school; schoolID 0 means all schools
+func (r *QuranRepository) GetTodayStatsBySchool(date string, schoolID int) (map[string]interface{}, error) {
 	query := `SELECT 
 			  COUNT(DISTINCT user_id) as total_users,
 			  COUNT(*) as total_readings
 			  FROM quran_readings 
-			  WHERE date = ?`
+			  WHERE date = ?
+			  AND (? = 0 OR user_id IN (SELECT id FROM users WHERE school_id = ?))`
 
 	stats := make(map[string]interface{})
 	var totalUsers, totalReadings int
 
-	err := r.DB.QueryRow(query, date).Scan(&totalUsers, &totalReadings)
+	err := r.DB.QueryRow(query, date, schoolID, schoolID).Scan(&totalUsers, &totalReadings)
 	if err != nil {
 		return nil, err
 	}
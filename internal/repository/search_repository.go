@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+)
+
+type SearchRepository struct {
+	DB *sql.DB
+}
+
+func NewSearchRepository(db *sql.DB) *SearchRepository {
+	return &SearchRepository{DB: db}
+}
+
+// IsIndexed reports whether a source has been indexed before
+func (r *SearchRepository) IsIndexed(source string) bool {
+	var n int
+	r.DB.QueryRow(`SELECT COUNT(*) FROM search_sources WHERE source = ?`, source).Scan(&n)
+	return n > 0
+}
+
+// ReplaceSource swaps every document of a source for docs in one transaction
+func (r *SearchRepository) ReplaceSource(source string, docs []models.SearchDocument) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM search_index WHERE source = ?`, source); err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare(`INSERT INTO search_index (source, kind, ref, title, body, latin, arab) VALUES (?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, d := range docs {
+		if _, err := stmt.Exec(source, d.Kind, d.Ref, d.Title, d.Body, d.Latin, d.Arab); err != nil {
+			return err
+		}
+	}
+	_, err = tx.Exec(`INSERT INTO search_sources (source, documents) VALUES (?, ?)
+			  ON CONFLICT(source) DO UPDATE SET documents = excluded.documents, indexed_at = CURRENT_TIMESTAMP`,
+		source, len(docs))
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Search runs an FTS5 MATCH expression, best matches first. Titles weigh
+// more than the text; an empty kind searches every kind.
+func (r *SearchRepository) Search(match, kind string, limit int) ([]*models.SearchHit, error) {
+	query := `SELECT kind, ref, title, snippet(search_index, -1, char(2), char(3), '…', 16)
+			  FROM search_index
+			  WHERE search_index MATCH ? AND (? = '' OR kind = ?)
+			  ORDER BY bm25(search_index, 0, 0, 0, 5.0, 1.0, 2.0, 2.0)
+			  LIMIT ?`
+
+	rows, err := r.DB.Query(query, match, kind, kind, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hits []*models.SearchHit
+	for rows.Next() {
+		h := &models.SearchHit{}
+		if err := rows.Scan(&h.Kind, &h.Ref, &h.Title, &h.Snippet); err != nil {
+			return nil, err
+		}
+		hits = append(hits, h)
+	}
+	return hits, rows.Err()
+}
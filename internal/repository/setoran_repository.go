@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+)
+
+type SetoranRepository struct {
+	DB *sql.DB
+}
+
+func NewSetoranRepository(db *sql.DB) *SetoranRepository {
+	return &SetoranRepository{DB: db}
+}
+
+const setoranSelect = `SELECT s.id, s.student_id, COALESCE(s.teacher_id, 0), COALESCE(s.school_id, 0),
+			  s.start_surah_id, s.start_ayah, s.end_surah_id, s.end_ayah, s.status, COALESCE(s.grade, ''),
+			  COALESCE(s.tajwid_notes, ''), COALESCE(date(s.setoran_date), ''), s.points,
+			  st.full_name, COALESCE(st.class, ''), COALESCE(t.full_name, ''), s.requested_at
+			  FROM setoran s
+			  JOIN users st ON st.id = s.student_id
+			  LEFT JOIN users t ON t.id = s.teacher_id`
+
+func scanSetoran(rows *sql.Rows) ([]*models.Setoran, error) {
+	defer rows.Close()
+
+	var items []*models.Setoran
+	for rows.Next() {
+		s := &models.Setoran{}
+		err := rows.Scan(&s.ID, &s.StudentID, &s.TeacherID, &s.SchoolID,
+			&s.StartSurahID, &s.StartAyah, &s.EndSurahID, &s.EndAyah, &s.Status, &s.Grade,
+			&s.TajwidNotes, &s.SetoranDate, &s.Points,
+			&s.StudentName, &s.StudentClass, &s.TeacherName, &s.RequestedAt)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, s)
+	}
+	return items, rows.Err()
+}
+
+func (r *SetoranRepository) Create(s *models.Setoran) error {
+	query := `INSERT INTO setoran (student_id, school_id, start_surah_id, start_ayah, end_surah_id, end_ayah, status)
+			  VALUES (?, ?, ?, ?, ?, ?, 'pending')`
+
+	result, err := r.DB.Exec(query, s.StudentID, s.SchoolID, s.StartSurahID, s.StartAyah, s.EndSurahID, s.EndAyah)
+	if err != nil {
+		return err
+	}
+
+	id, _ := result.LastInsertId()
+	s.ID = int(id)
+	s.Status = "pending"
+	return nil
+}
+
+func (r *SetoranRepository) GetByID(id int) (*models.Setoran, error) {
+	rows, err := r.DB.Query(setoranSelect+` WHERE s.id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	items, err := scanSetoran(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return items[0], nil
+}
+
+// GetByStudent returns a student's setoran history, newest first
+func (r *SetoranRepository) GetByStudent(studentID, limit int) ([]*models.Setoran, error) {
+	rows, err := r.DB.Query(setoranSelect+` WHERE s.student_id = ? ORDER BY s.requested_at DESC, s.id DESC LIMIT ?`, studentID, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanSetoran(rows)
+}
+
+// GetPending returns ungraded requests, oldest first. schoolID 0 means every
+// school and an empty class means every class.
+func (r *SetoranRepository) GetPending(schoolID int, class string) ([]*models.Setoran, error) {
+	rows, err := r.DB.Query(setoranSelect+` WHERE s.status = 'pending'
+			  AND (? = 0 OR s.school_id = ?) AND (? = '' OR st.class = ?)
+			  ORDER BY s.requested_at, s.id`, schoolID, schoolID, class, class)
+	if err != nil {
+		return nil, err
+	}
+	return scanSetoran(rows)
+}
+
+// GetGradedByTeacher returns the setoran a teacher has graded, newest first
+func (r *SetoranRepository) GetGradedByTeacher(teacherID, limit int) ([]*models.Setoran, error) {
+	rows, err := r.DB.Query(setoranSelect+` WHERE s.teacher_id = ? AND s.status = 'graded'
+			  ORDER BY s.setoran_date DESC, s.id DESC LIMIT ?`, teacherID, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanSetoran(rows)
+}
+
+// GetGradedByClass returns graded setoran of a class between two dates for reports
+func (r *SetoranRepository) GetGradedByClass(schoolID int, class, startDate, endDate string) ([]*models.Setoran, error) {
+	rows, err := r.DB.Query(setoranSelect+` WHERE s.status = 'graded'
+			  AND (? = 0 OR s.school_id = ?) AND (? = '' OR st.class = ?)
+			  AND date(s.setoran_date) BETWEEN ? AND ?
+			  ORDER BY st.class, st.full_name, s.setoran_date, s.id`, schoolID, schoolID, class, class, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	return scanSetoran(rows)
+}
+
+func (r *SetoranRepository) Grade(s *models.Setoran) error {
+	_, err := r.DB.Exec(`UPDATE setoran SET teacher_id = ?, status = 'graded', grade = ?, tajwid_notes = ?,
+			  setoran_date = ?, points = ?, graded_at = CURRENT_TIMESTAMP WHERE id = ?`,
+		s.TeacherID, s.Grade, s.TajwidNotes, s.SetoranDate, s.Points, s.ID)
+	return err
+}
+
+// DeletePending removes a request the student has not been graded on yet
+func (r *SetoranRepository) DeletePending(studentID, id int) error {
+	_, err := r.DB.Exec(`DELETE FROM setoran WHERE id = ? AND student_id = ? AND status = 'pending'`, id, studentID)
+	return err
+}
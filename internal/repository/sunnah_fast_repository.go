@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+)
+
+type SunnahFastRepository struct {
+	DB *sql.DB
+}
+
+func NewSunnahFastRepository(db *sql.DB) *SunnahFastRepository {
+	return &SunnahFastRepository{DB: db}
+}
+
+// Save records the user's fast on f.Date, replacing the kinds of an earlier one
+func (r *SunnahFastRepository) Save(f *models.SunnahFast) error {
+	_, err := r.DB.Exec(`INSERT INTO sunnah_fasts (user_id, date, kinds) VALUES (?, ?, ?)
+			  ON CONFLICT(user_id, date) DO UPDATE SET kinds = excluded.kinds`, f.UserID, f.Date, f.Kinds)
+	return err
+}
+
+func (r *SunnahFastRepository) Delete(userID int, date string) error {
+	res, err := r.DB.Exec(`DELETE FROM sunnah_fasts WHERE user_id = ? AND date(date) = ?`, userID, date)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *SunnahFastRepository) GetByUserAndDate(userID int, date string) (*models.SunnahFast, error) {
+	f := &models.SunnahFast{}
+	err := r.DB.QueryRow(`SELECT id, user_id, date(date), kinds, created_at FROM sunnah_fasts
+			  WHERE user_id = ? AND date(date) = ?`, userID, date).Scan(&f.ID, &f.UserID, &f.Date, &f.Kinds, &f.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// GetByUserAndDateRange returns a user's fasts between two dates, newest first
+func (r *SunnahFastRepository) GetByUserAndDateRange(userID int, startDate, endDate string) ([]*models.SunnahFast, error) {
+	rows, err := r.DB.Query(`SELECT id, user_id, date(date), kinds, created_at FROM sunnah_fasts
+			  WHERE user_id = ? AND date(date) BETWEEN ? AND ? ORDER BY date DESC`, userID, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*models.SunnahFast
+	for rows.Next() {
+		f := &models.SunnahFast{}
+		if err := rows.Scan(&f.ID, &f.UserID, &f.Date, &f.Kinds, &f.CreatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, f)
+	}
+	return list, rows.Err()
+}
+
+// Count counts a user's fasts of one kind, or of every kind when kind is empty
+func (r *SunnahFastRepository) Count(userID int, kind string) (int, error) {
+	var count int
+	err := r.DB.QueryRow(`SELECT COUNT(*) FROM sunnah_fasts
+			  WHERE user_id = ? AND (? = '' OR ',' || kinds || ',' LIKE '%,' || ? || ',%')`, userID, kind, kind).Scan(&count)
+	return count, err
+}
@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+)
+
+type SunnahPrayerRepository struct {
+	DB *sql.DB
+}
+
+func NewSunnahPrayerRepository(db *sql.DB) *SunnahPrayerRepository {
+	return &SunnahPrayerRepository{DB: db}
+}
+
+const sunnahPrayerColumns = `id, user_id, date(date), tarawih_rakaat, tarawih_place, tarawih_mode,
+			  witir_rakaat, dhuha_rakaat, tahajud_rakaat, rawatib, created_at, updated_at`
+
+func scanSunnahPrayer(scan func(dest ...interface{}) error) (*models.SunnahPrayer, error) {
+	p := &models.SunnahPrayer{}
+	err := scan(&p.ID, &p.UserID, &p.Date, &p.TarawihRakaat, &p.TarawihPlace, &p.TarawihMode,
+		&p.WitirRakaat, &p.DhuhaRakaat, &p.TahajudRakaat, &p.Rawatib, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (r *SunnahPrayerRepository) GetByUserAndDate(userID int, date string) (*models.SunnahPrayer, error) {
+	row := r.DB.QueryRow(`SELECT `+sunnahPrayerColumns+` FROM sunnah_prayers WHERE user_id = ? AND date(date) = ?`, userID, date)
+	return scanSunnahPrayer(row.Scan)
+}
+
+// GetByUserAndDateRange returns a user's days between two dates, newest first
+func (r *SunnahPrayerRepository) GetByUserAndDateRange(userID int, startDate, endDate string) ([]*models.SunnahPrayer, error) {
+	rows, err := r.DB.Query(`SELECT `+sunnahPrayerColumns+` FROM sunnah_prayers
+			  WHERE user_id = ? AND date(date) BETWEEN ? AND ? ORDER BY date DESC`, userID, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*models.SunnahPrayer
+	for rows.Next() {
+		p, err := scanSunnahPrayer(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, p)
+	}
+	return list, rows.Err()
+}
+
+// Save creates or replaces the user's record for p.Date
+func (r *SunnahPrayerRepository) Save(p *models.SunnahPrayer) error {
+	_, err := r.DB.Exec(`INSERT INTO sunnah_prayers (user_id, date, tarawih_rakaat, tarawih_place, tarawih_mode,
+			  witir_rakaat, dhuha_rakaat, tahajud_rakaat, rawatib, updated_at)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			  ON CONFLICT(user_id, date) DO UPDATE SET tarawih_rakaat = excluded.tarawih_rakaat,
+			  tarawih_place = excluded.tarawih_place, tarawih_mode = excluded.tarawih_mode,
+			  witir_rakaat = excluded.witir_rakaat, dhuha_rakaat = excluded.dhuha_rakaat,
+			  tahajud_rakaat = excluded.tahajud_rakaat, rawatib = excluded.rawatib, updated_at = excluded.updated_at`,
+		p.UserID, p.Date, p.TarawihRakaat, p.TarawihPlace, p.TarawihMode,
+		p.WitirRakaat, p.DhuhaRakaat, p.TahajudRakaat, p.Rawatib, time.Now().Round(0))
+	return err
+}
+
+// GetStats counts a user's days with each sunnah prayer between two dates
+func (r *SunnahPrayerRepository) GetStats(userID int, startDate, endDate string) (map[string]int, error) {
+	query := `SELECT
+			  COUNT(CASE WHEN tarawih_rakaat > 0 THEN 1 END),
+			  COUNT(CASE WHEN tarawih_rakaat > 0 AND tarawih_place = 'masjid' THEN 1 END),
+			  COALESCE(SUM(tarawih_rakaat), 0),
+			  COUNT(CASE WHEN witir_rakaat > 0 THEN 1 END),
+			  COUNT(CASE WHEN dhuha_rakaat > 0 THEN 1 END),
+			  COUNT(CASE WHEN tahajud_rakaat > 0 THEN 1 END),
+			  COUNT(CASE WHEN rawatib != '' THEN 1 END),
+			  COUNT(*)
+			  FROM sunnah_prayers
+			  WHERE user_id = ? AND date(date) BETWEEN ? AND ?`
+
+	var tarawih, masjid, rakaat, witir, dhuha, tahajud, rawatib, total int
+	err := r.DB.QueryRow(query, userID, startDate, endDate).Scan(&tarawih, &masjid, &rakaat, &witir, &dhuha, &tahajud, &rawatib, &total)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]int{
+		"tarawih":        tarawih,
+		"tarawih_masjid": masjid,
+		"tarawih_rakaat": rakaat,
+		"witir":          witir,
+		"dhuha":          dhuha,
+		"tahajud":        tahajud,
+		"rawatib":        rawatib,
+		"total_days":     total,
+	}, nil
+}
+
+// GetDailyCompletionStats counts per day how many users performed each
+// sunnah prayer; percentage is the share of those users who prayed tarawih
+func (r *SunnahPrayerRepository) GetDailyCompletionStats(startDate, endDate string) ([]map[string]interface{}, error) {
+	rows, err := r.DB.Query(`SELECT date(date),
+			  COUNT(CASE WHEN tarawih_rakaat > 0 THEN 1 END),
+			  COUNT(CASE WHEN witir_rakaat > 0 THEN 1 END),
+			  COUNT(CASE WHEN dhuha_rakaat > 0 THEN 1 END),
+			  COUNT(CASE WHEN tahajud_rakaat > 0 THEN 1 END),
+			  COUNT(CASE WHEN rawatib != '' THEN 1 END),
+			  COUNT(DISTINCT user_id)
+			  FROM sunnah_prayers
+			  WHERE date(date) BETWEEN ? AND ?
+			  GROUP BY date(date)
+			  ORDER BY date(date) ASC`, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []map[string]interface{}
+	for rows.Next() {
+		var date string
+		var tarawih, witir, dhuha, tahajud, rawatib, users int
+		if err := rows.Scan(&date, &tarawih, &witir, &dhuha, &tahajud, &rawatib, &users); err != nil {
+			return nil, err
+		}
+		if users == 0 {
+			continue
+		}
+		result = append(result, map[string]interface{}{
+			"date":       date,
+			"percentage": float64(tarawih) / float64(users) * 100,
+			"tarawih":    tarawih,
+			"witir":      witir,
+			"dhuha":      dhuha,
+			"tahajud":    tahajud,
+			"rawatib":    rawatib,
+			"users":      users,
+		})
+	}
+	return result, rows.Err()
+}
+
+// GetDates returns the dates on which the user performed a sunnah prayer,
+// newest first. column must be one of the rakaat columns or rawatib.
+func (r *SunnahPrayerRepository) GetDates(userID int, column string, limit int) ([]string, error) {
+	cond := column + " > 0"
+	if column == "rawatib" {
+		cond = "rawatib != ''"
+	}
+	rows, err := r.DB.Query(`SELECT date(date) FROM sunnah_prayers WHERE user_id = ? AND `+cond+`
+			  ORDER BY date DESC LIMIT ?`, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var dates []string
+	for rows.Next() {
+		var d string
+		if err := rows.Scan(&d); err != nil {
+			return nil, err
+		}
+		dates = append(dates, d)
+	}
+	return dates, rows.Err()
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+)
+
+type SyncRepository struct {
+	DB *sql.DB
+}
+
+func NewSyncRepository(db *sql.DB) *SyncRepository {
+	return &SyncRepository{DB: db}
+}
+
+func (r *SyncRepository) GetByClientID(userID int, clientID string) (*models.SyncEntry, error) {
+	query := `SELECT id, user_id, client_id, entity_type, entity_key, client_timestamp, status, COALESCE(message, ''), created_at
+			  FROM sync_entries WHERE user_id = ? AND client_id = ?`
+
+	e := &models.SyncEntry{}
+	err := r.DB.QueryRow(query, userID, clientID).Scan(
+		&e.ID, &e.UserID, &e.ClientID, &e.EntityType, &e.EntityKey,
+		&e.ClientTimestamp, &e.Status, &e.Message, &e.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (r *SyncRepository) Create(e *models.SyncEntry) error {
+	query := `INSERT INTO sync_entries (user_id, client_id, entity_type, entity_key, client_timestamp, status, message)
+			  VALUES (?, ?, ?, ?, ?, ?, ?)`
+
+	result, err := r.DB.Exec(query, e.UserID, e.ClientID, e.EntityType, e.EntityKey,
+		e.ClientTimestamp, e.Status, e.Message)
+	if err != nil {
+		return err
+	}
+
+	id, _ := result.LastInsertId()
+	e.ID = int(id)
+	return nil
+}
+
+// GetLatestApplied returns the newest client timestamp (RFC3339, UTC) that was applied
+// to an entity, or "" when the entity has never been synced.
+func (r *SyncRepository) GetLatestApplied(userID int, entityType, entityKey string) (string, error) {
+	query := `SELECT COALESCE(MAX(client_timestamp), '') FROM sync_entries
+			  WHERE user_id = ? AND entity_type = ? AND entity_key = ? AND status = 'applied'`
+
+	var latest string
+	err := r.DB.QueryRow(query, userID, entityType, entityKey).Scan(&latest)
+	return latest, err
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+)
+
+type UdzurRepository struct {
+	DB *sql.DB
+}
+
+func NewUdzurRepository(db *sql.DB) *UdzurRepository {
+	return &UdzurRepository{DB: db}
+}
+
+// notExcused filters rows of table to the days its user was not in udzur, so
+// excused days neither count towards nor break streaks and percentages
+func notExcused(table string) string {
+	return `NOT EXISTS (SELECT 1 FROM udzur_periods up WHERE up.user_id = ` + table + `.user_id
+			  AND date(` + table + `.date) BETWEEN up.start_date AND up.end_date)`
+}
+
+const udzurColumns = `id, user_id, kind, date(start_date), date(end_date), ongoing, created_at`
+
+func scanUdzurPeriods(rows *sql.Rows) ([]*models.UdzurPeriod, error) {
+	defer rows.Close()
+
+	var periods []*models.UdzurPeriod
+	for rows.Next() {
+		p := &models.UdzurPeriod{}
+		if err := rows.Scan(&p.ID, &p.UserID, &p.Kind, &p.StartDate, &p.EndDate, &p.Ongoing, &p.CreatedAt); err != nil {
+			return nil, err
+		}
+		periods = append(periods, p)
+	}
+	return periods, rows.Err()
+}
+
+func (r *UdzurRepository) Create(p *models.UdzurPeriod) error {
+	result, err := r.DB.Exec(`INSERT INTO udzur_periods (user_id, kind, start_date, end_date, ongoing) VALUES (?, ?, ?, ?, ?)`,
+		p.UserID, p.Kind, p.StartDate, p.EndDate, p.Ongoing)
+	if err != nil {
+		return err
+	}
+	id, _ := result.LastInsertId()
+	p.ID = int(id)
+	return nil
+}
+
+// End closes a period of the user on endDate
+func (r *UdzurRepository) End(id, userID int, endDate string) error {
+	res, err := r.DB.Exec(`UPDATE udzur_periods SET end_date = ?, ongoing = 0 WHERE id = ? AND user_id = ?`, endDate, id, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *UdzurRepository) Delete(id, userID int) error {
+	res, err := r.DB.Exec(`DELETE FROM udzur_periods WHERE id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *UdzurRepository) GetByID(id, userID int) (*models.UdzurPeriod, error) {
+	rows, err := r.DB.Query(`SELECT `+udzurColumns+` FROM udzur_periods WHERE id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	periods, err := scanUdzurPeriods(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(periods) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return periods[0], nil
+}
+
+// GetByUser returns a user's periods, newest first
+func (r *UdzurRepository) GetByUser(userID, limit int) ([]*models.UdzurPeriod, error) {
+	rows, err := r.DB.Query(`SELECT `+udzurColumns+` FROM udzur_periods WHERE user_id = ?
+			  ORDER BY start_date DESC LIMIT ?`, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanUdzurPeriods(rows)
+}
+
+// GetInRange returns a user's periods overlapping startDate..endDate, oldest first
+func (r *UdzurRepository) GetInRange(userID int, startDate, endDate string) ([]*models.UdzurPeriod, error) {
+	rows, err := r.DB.Query(`SELECT `+udzurColumns+` FROM udzur_periods
+			  WHERE user_id = ? AND start_date <= ? AND end_date >= ? ORDER BY start_date`, userID, endDate, startDate)
+	if err != nil {
+		return nil, err
+	}
+	return scanUdzurPeriods(rows)
+}
+
+// GetExcusedUsers returns the students of a school excused on date; schoolID
+// 0 means all schools
+func (r *UdzurRepository) GetExcusedUsers(schoolID int, date string) (map[int]bool, error) {
+	rows, err := r.DB.Query(`SELECT DISTINCT up.user_id FROM udzur_periods up JOIN users u ON u.id = up.user_id
+			  WHERE ? BETWEEN up.start_date AND up.end_date AND (? = 0 OR u.school_id = ?)`, date, schoolID, schoolID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	excused := make(map[int]bool)
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		excused[id] = true
+	}
+	return excused, rows.Err()
+}
@@ -2,6 +2,8 @@ package repository
 
 import (
 	"database/sql"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/ramadhan/amaliah-monitoring/internal/models"
@@ -39,6 +41,7 @@ func (r *UserRepository) GetByID(id int) (*models.User, error) {
 			  COALESCE(target_khatam, 30) as target_khatam, 
 			  COALESCE(provinsi, '') as provinsi,
 			  COALESCE(kabkota, '') as kabkota,
+			  COALESCE(school_id, 0) as school_id,
 			  created_at, updated_at
 			  FROM users WHERE id = ?`
 
@@ -47,7 +50,7 @@ func (r *UserRepository) GetByID(id int) (*models.User, error) {
 		&user.ID, &user.Username, &user.Email, &user.PasswordHash,
 		&user.FullName, &user.Class, &user.Role, &user.Points,
 		&user.Avatar, &user.Bio, &user.Theme, &user.TargetKhatam,
-		&user.Provinsi, &user.Kabkota,
+		&user.Provinsi, &user.Kabkota, &user.SchoolID,
 		&user.CreatedAt, &user.UpdatedAt,
 	)
 	if err != nil {
@@ -197,6 +200,11 @@ func (r *UserRepository) GetStats() (map[string]interface{}, error) {
 }
 
 func (r *UserRepository) GetActiveUsersCount(date string) (int, error) {
+	return r.GetActiveUsersCountBySchool(date, 0)
+}
+
+// GetActiveUsersCountBySchool limits GetActiveUsersCount to one school; schoolID 0 means all schools
+func (r *UserRepository) GetActiveUsersCountBySchool(date string, schoolID int) (int, error) {
 	query := `SELECT COUNT(DISTINCT user_id) FROM (
 			  SELECT user_id FROM prayers WHERE date = ?
 			  UNION
@@ -205,10 +213,19 @@ func (r *UserRepository) GetActiveUsersCount(date string) (int, error) {
 			  SELECT user_id FROM quran_readings WHERE date = ?
 			  UNION
 			  SELECT user_id FROM daily_amaliah WHERE date = ?
-			)`
+			)
+			WHERE ? = 0 OR user_id IN (SELECT id FROM users WHERE school_id = ?)`
+
+	var count int
+	err := r.DB.QueryRow(query, date, date, date, date, schoolID, schoolID).Scan(&count)
+	return count, err
+}
 
+// CountStudents returns the number of students in a school; schoolID 0 means all schools
+func (r *UserRepository) CountStudents(schoolID int) (int, error) {
 	var count int
-	err := r.DB.QueryRow(query, date, date, date, date).Scan(&count)
+	err := r.DB.QueryRow(`SELECT COUNT(*) FROM users WHERE role = 'user' AND (? = 0 OR school_id = ?)`,
+		schoolID, schoolID).Scan(&count)
 	return count, err
 }
 
@@ -238,6 +255,33 @@ func (r *UserRepository) GetByClass(class string) ([]*models.User, error) {
 	return users, nil
 }
 
+// GetStudentsBySchool returns the students of a school, of one class when class is set
+func (r *UserRepository) GetStudentsBySchool(schoolID int, class string) ([]*models.User, error) {
+	query := `SELECT id, username, email, full_name, COALESCE(class, ''), role, points, avatar, bio, theme, target_khatam, created_at, updated_at
+			  FROM users WHERE school_id = ? AND role = 'user' AND (? = '' OR class = ?) ORDER BY class, full_name`
+
+	rows, err := r.DB.Query(query, schoolID, class, class)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []*models.User
+	for rows.Next() {
+		user := &models.User{SchoolID: schoolID}
+		err := rows.Scan(
+			&user.ID, &user.Username, &user.Email, &user.FullName,
+			&user.Class, &user.Role, &user.Points, &user.Avatar, &user.Bio,
+			&user.Theme, &user.TargetKhatam, &user.CreatedAt, &user.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
 func (r *UserRepository) GetAllClasses() ([]string, error) {
 	query := `SELECT DISTINCT class FROM users WHERE role = 'user' AND class IS NOT NULL AND class != '' ORDER BY class`
 
@@ -259,34 +303,123 @@ func (r *UserRepository) GetAllClasses() ([]string, error) {
 	return classes, nil
 }
 
-func (r *UserRepository) SearchUsers(query string) ([]*models.User, error) {
-	sqlQuery := `SELECT id, username, email, full_name, class, role, points, avatar, bio, theme, target_khatam, created_at, updated_at
-				 FROM users 
-				 WHERE role = 'user' AND 
-				 (full_name LIKE ? OR username LIKE ? OR email LIKE ? OR class LIKE ?)
-				 ORDER BY full_name`
+// userSortColumns whitelists the columns the user list may be ordered by
+var userSortColumns = map[string]string{
+	"name":        "u.full_name",
+	"points":      "u.points",
+	"class":       "u.class",
+	"created":     "u.created_at",
+	"last_active": "last_active",
+}
+
+// lastActiveExpr is the most recent date a user saved any tracking entry
+const lastActiveExpr = `(SELECT MAX(d) FROM (
+			  SELECT MAX(date) AS d FROM prayers WHERE user_id = u.id
+			  UNION ALL SELECT MAX(date) FROM fastings WHERE user_id = u.id
+			  UNION ALL SELECT MAX(date) FROM quran_readings WHERE user_id = u.id
+			  UNION ALL SELECT MAX(date) FROM daily_amaliah WHERE user_id = u.id
+			))`
+
+// NormalizeUserQuery fills defaults and clamps paging so the query is always valid
+func NormalizeUserQuery(q *models.UserQuery) {
+	q.Search = strings.TrimSpace(q.Search)
+	if _, ok := userSortColumns[q.Sort]; !ok {
+		q.Sort = "created"
+	}
+	if q.Order != "asc" && q.Order != "desc" {
+		if q.Sort == "name" || q.Sort == "class" {
+			q.Order = "asc"
+		} else {
+			q.Order = "desc"
+		}
+	}
+	if q.PerPage < 1 {
+		q.PerPage = 25
+	}
+	if q.PerPage > 100 {
+		q.PerPage = 100
+	}
+	if q.Page < 1 {
+		q.Page = 1
+	}
+	if _, err := time.Parse("2006-01-02", q.ActiveFrom); err != nil {
+		q.ActiveFrom = ""
+	}
+	if _, err := time.Parse("2006-01-02", q.ActiveUntil); err != nil {
+		q.ActiveUntil = ""
+	}
+}
+
+// Query returns one page of users matching the filters, with the total match count
+func (r *UserRepository) Query(q models.UserQuery) (*models.UserPage, error) {
+	NormalizeUserQuery(&q)
+
+	var where []string
+	var args []interface{}
+	if q.Search != "" {
+		term := "%" + q.Search + "%"
+		where = append(where, "(u.full_name LIKE ? OR u.username LIKE ? OR u.email LIKE ? OR u.class LIKE ?)")
+		args = append(args, term, term, term, term)
+	}
+	if q.Role != "" {
+		where = append(where, "u.role = ?")
+		args = append(args, q.Role)
+	}
+	if q.Class != "" {
+		where = append(where, "u.class = ?")
+		args = append(args, q.Class)
+	}
+	if q.SchoolID > 0 {
+		where = append(where, "u.school_id = ?")
+		args = append(args, q.SchoolID)
+	}
+	if q.ActiveFrom != "" {
+		where = append(where, "last_active >= ?")
+		args = append(args, q.ActiveFrom)
+	}
+	if q.ActiveUntil != "" {
+		where = append(where, "last_active <= ?")
+		args = append(args, q.ActiveUntil)
+	}
+
+	base := `FROM (SELECT u.*, ` + lastActiveExpr + ` AS last_active FROM users u) u`
+	if len(where) > 0 {
+		base += " WHERE " + strings.Join(where, " AND ")
+	}
+
+	page := &models.UserPage{Page: q.Page, PerPage: q.PerPage, Users: []*models.User{}}
+	if err := r.DB.QueryRow("SELECT COUNT(*) "+base, args...).Scan(&page.Total); err != nil {
+		return nil, err
+	}
+	page.TotalPages = (page.Total + q.PerPage - 1) / q.PerPage
+
+	// NULLs (never active) always sort last; id keeps paging stable on ties
+	orderBy := fmt.Sprintf("%s IS NULL, %s %s, u.id %s", userSortColumns[q.Sort], userSortColumns[q.Sort], q.Order, q.Order)
+	sqlQuery := `SELECT u.id, u.username, u.email, u.full_name, COALESCE(u.class, ''), u.role, u.points,
+			  COALESCE(u.avatar, 'default'), COALESCE(u.bio, ''), COALESCE(u.theme, 'emerald'), COALESCE(u.target_khatam, 30),
+			  COALESCE(u.school_id, 0), COALESCE(last_active, ''), u.created_at, u.updated_at
+			  ` + base + ` ORDER BY ` + orderBy + ` LIMIT ? OFFSET ?`
 
-	searchTerm := "%" + query + "%"
-	rows, err := r.DB.Query(sqlQuery, searchTerm, searchTerm, searchTerm, searchTerm)
+	rows, err := r.DB.Query(sqlQuery, append(args, q.PerPage, (q.Page-1)*q.PerPage)...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var users []*models.User
 	for rows.Next() {
 		user := &models.User{}
 		err := rows.Scan(
 			&user.ID, &user.Username, &user.Email, &user.FullName,
 			&user.Class, &user.Role, &user.Points, &user.Avatar, &user.Bio,
-			&user.Theme, &user.TargetKhatam, &user.CreatedAt, &user.UpdatedAt,
+			&user.Theme, &user.TargetKhatam, &user.SchoolID, &user.LastActive,
+			&user.CreatedAt, &user.UpdatedAt,
 		)
 		if err != nil {
 			return nil, err
 		}
-		users = append(users, user)
+		page.Users = append(page.Users, user)
 	}
-	return users, nil
+	return page, rows.Err()
 }
 
 func (r *UserRepository) GetTopStudents(limit int) ([]*models.User, error) {
@@ -316,3 +449,47 @@ func (r *UserRepository) GetTopStudents(limit int) ([]*models.User, error) {
 }
 
 
+// GetByCalendarToken resolves a calendar subscription URL to its owner
+func (r *UserRepository) GetByCalendarToken(token string) (*models.User, error) {
+	var id int
+	err := r.DB.QueryRow(`SELECT id FROM users WHERE calendar_token = ? AND calendar_token != ''`, token).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+	return r.GetByID(id)
+}
+
+func (r *UserRepository) GetCalendarToken(userID int) (string, error) {
+	var token string
+	err := r.DB.QueryRow(`SELECT COALESCE(calendar_token, '') FROM users WHERE id = ?`, userID).Scan(&token)
+	return token, err
+}
+
+func (r *UserRepository) SetCalendarToken(userID int, token string) error {
+	_, err := r.DB.Exec(`UPDATE users SET calendar_token = ? WHERE id = ?`, token, userID)
+	return err
+}
+
+// GetClassesBySchool lists the student classes of a school; schoolID 0 lists every class
+func (r *UserRepository) GetClassesBySchool(schoolID int) ([]string, error) {
+	if schoolID == 0 {
+		return r.GetAllClasses()
+	}
+
+	query := `SELECT DISTINCT class FROM users WHERE role = 'user' AND school_id = ? AND class IS NOT NULL AND class != '' ORDER BY class`
+	rows, err := r.DB.Query(query, schoolID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var classes []string
+	for rows.Next() {
+		var class string
+		if err := rows.Scan(&class); err != nil {
+			return nil, err
+		}
+		classes = append(classes, class)
+	}
+	return classes, rows.Err()
+}
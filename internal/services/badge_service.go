@@ -4,6 +4,7 @@ import (
 	"log"
 
 	"github.com/ramadhan/amaliah-monitoring/internal/models"
+	"github.com/ramadhan/amaliah-monitoring/internal/mushaf"
 	"github.com/ramadhan/amaliah-monitoring/internal/repository"
 )
 
@@ -12,6 +13,9 @@ type BadgeService struct {
 	PrayerRepo  *repository.PrayerRepository
 	AmaliahRepo *repository.AmaliahRepository
 	QuranRepo   *repository.QuranRepository
+	Khatam      *KhatamService
+	// SunnahFastRepo, when set, enables the sunnah fasting badges
+	SunnahFastRepo *repository.SunnahFastRepository
 }
 
 func NewBadgeService(
@@ -19,12 +23,14 @@ func NewBadgeService(
 	prayerRepo *repository.PrayerRepository,
 	amaliahRepo *repository.AmaliahRepository,
 	quranRepo *repository.QuranRepository,
+	khatam *KhatamService,
 ) *BadgeService {
 	return &BadgeService{
 		BadgeRepo:   badgeRepo,
 		PrayerRepo:  prayerRepo,
 		AmaliahRepo: amaliahRepo,
 		QuranRepo:   quranRepo,
+		Khatam:      khatam,
 	}
 }
 
@@ -37,6 +43,18 @@ func (s *BadgeService) CheckAndAwardBadges(userID int) ([]models.Badge, error) {
 		return nil, err
 	}
 
+	// Khatam coverage replays every reading, so load it once for all badges
+	var khatam *KhatamProgress
+	khatamProgress := func() *KhatamProgress {
+		if khatam == nil {
+			khatam, _ = s.Khatam.Progress(userID)
+			if khatam == nil {
+				khatam = &KhatamProgress{}
+			}
+		}
+		return khatam
+	}
+
 	for _, badge := range allBadges {
 		// Skip if already earned
 		has, _ := s.BadgeRepo.HasBadge(userID, badge.ID)
@@ -61,6 +79,28 @@ func (s *BadgeService) CheckAndAwardBadges(userID int) ([]models.Badge, error) {
 			if count >= badge.CriteriaValue {
 				earned = true
 			}
+		case "quran_juz":
+			// Juz read in full, counting all 30 of each completed khatam
+			if khatamProgress().TotalJuzDone >= badge.CriteriaValue {
+				earned = true
+			}
+		case "quran_khatam":
+			// Criteria value is in juz (30 = one khatam)
+			if len(khatamProgress().Completed)*mushaf.JuzCount >= badge.CriteriaValue {
+				earned = true
+			}
+		case "sunnah_fasting_count", "senin_kamis_count":
+			if s.SunnahFastRepo == nil {
+				continue
+			}
+			kind := ""
+			if badge.CriteriaType == "senin_kamis_count" {
+				kind = "senin_kamis"
+			}
+			count, _ := s.SunnahFastRepo.Count(userID, kind)
+			if count >= badge.CriteriaValue {
+				earned = true
+			}
 		// Add more criteria logic here
 		}
 
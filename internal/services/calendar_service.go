@@ -0,0 +1,221 @@
+package services
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+)
+
+// MaxCalendarAlarms caps how many VALARMs one event may carry
+const MaxCalendarAlarms = 3
+
+type CalendarService struct {
+	ShalatService *ShalatService
+}
+
+func NewCalendarService(shalatService *ShalatService) *CalendarService {
+	return &CalendarService{ShalatService: shalatService}
+}
+
+// CalendarOptions controls one generated feed
+type CalendarOptions struct {
+	Provinsi string
+	Kabkota  string
+	Months   int   // number of months starting at From, 1-3
+	Alarms   []int // minutes before each event
+	From     time.Time
+}
+
+// CalendarFeed is a rendered .ics body with its validator
+type CalendarFeed struct {
+	Body []byte
+	ETag string
+}
+
+// BuildFeed fetches the monthly schedules for a location and renders them as iCalendar
+func (s *CalendarService) BuildFeed(opts CalendarOptions) (*CalendarFeed, error) {
+	if opts.Months < 1 {
+		opts.Months = 1
+	}
+	if opts.Months > 3 {
+		opts.Months = 3
+	}
+
+	start := time.Date(opts.From.Year(), opts.From.Month(), 1, 0, 0, 0, 0, time.Local)
+	var months []models.ShalatData
+	for i := 0; i < opts.Months; i++ {
+		m := start.AddDate(0, i, 0)
+		data, err := s.ShalatService.GetShalat(opts.Provinsi, opts.Kabkota, int(m.Month()), m.Year())
+		if err != nil {
+			if i == 0 {
+				return nil, err
+			}
+			// Later months may not be published yet
+			break
+		}
+		months = append(months, *data)
+	}
+
+	body := RenderICS(opts.Kabkota+", "+opts.Provinsi, months, opts.Alarms)
+	sum := sha1.Sum([]byte(body))
+
+	return &CalendarFeed{
+		Body: []byte(body),
+		ETag: `"` + hex.EncodeToString(sum[:]) + `"`,
+	}, nil
+}
+
+type calendarEvent struct {
+	key         string
+	summary     string
+	description string
+	clock       string
+}
+
+// RenderICS renders schedules as an RFC 5545 calendar. Times are written as
+// floating local times so they show as published by Kemenag regardless of the
+// device time zone. Output is deterministic for the same input so it can be
+// validated with an ETag.
+func RenderICS(locationName string, months []models.ShalatData, alarms []int) string {
+	var b strings.Builder
+	writeLine := func(line string) {
+		b.WriteString(foldICSLine(line))
+		b.WriteString("\r\n")
+	}
+
+	locationSlug := slugify(locationName)
+
+	writeLine("BEGIN:VCALENDAR")
+	writeLine("VERSION:2.0")
+	writeLine("PRODID:-//Amaliah Ramadhan//Jadwal Shalat//ID")
+	writeLine("CALSCALE:GREGORIAN")
+	writeLine("METHOD:PUBLISH")
+	writeLine("X-WR-CALNAME:" + escapeICSText("Jadwal Shalat "+locationName))
+	writeLine("X-PUBLISHED-TTL:PT12H")
+	writeLine("REFRESH-INTERVAL;VALUE=DURATION:PT12H")
+
+	for _, month := range months {
+		// DTSTAMP is pinned to the schedule month so regenerating the feed
+		// does not change its bytes (and therefore its ETag)
+		stamp := fmt.Sprintf("%04d%02d01T000000Z", month.Tahun, month.Bulan)
+
+		for _, day := range month.Jadwal {
+			date, ok := scheduleDate(month, day)
+			if !ok {
+				continue
+			}
+
+			events := []calendarEvent{
+				{"imsak", "Imsak", "Batas akhir makan sahur", day.Imsak},
+				{"subuh", "Shalat Subuh", "", day.Subuh},
+				{"dzuhur", "Shalat Dzuhur", "", day.Dzuhur},
+				{"ashar", "Shalat Ashar", "", day.Ashar},
+				{"maghrib", "Shalat Maghrib / Berbuka", "Waktu berbuka puasa", day.Maghrib},
+				{"isya", "Shalat Isya", "", day.Isya},
+			}
+
+			for _, ev := range events {
+				clock, err := time.Parse("15:04", ev.clock)
+				if err != nil {
+					continue
+				}
+				startAt := time.Date(date.Year(), date.Month(), date.Day(), clock.Hour(), clock.Minute(), 0, 0, time.UTC)
+
+				writeLine("BEGIN:VEVENT")
+				writeLine(fmt.Sprintf("UID:%s-%s-%s@amaliah-ramadhan", date.Format("20060102"), ev.key, locationSlug))
+				writeLine("DTSTAMP:" + stamp)
+				writeLine("DTSTART:" + startAt.Format("20060102T150405"))
+				writeLine("DURATION:PT15M")
+				writeLine("SUMMARY:" + escapeICSText(ev.summary))
+				if ev.description != "" {
+					writeLine("DESCRIPTION:" + escapeICSText(ev.description))
+				}
+				writeLine("LOCATION:" + escapeICSText(locationName))
+				writeLine("TRANSP:TRANSPARENT")
+				for _, minutes := range alarms {
+					writeLine("BEGIN:VALARM")
+					writeLine("ACTION:DISPLAY")
+					writeLine("DESCRIPTION:" + escapeICSText(ev.summary))
+					writeLine(fmt.Sprintf("TRIGGER:-PT%dM", minutes))
+					writeLine("END:VALARM")
+				}
+				writeLine("END:VEVENT")
+			}
+		}
+	}
+
+	writeLine("END:VCALENDAR")
+	return b.String()
+}
+
+// ParseAlarmOffsets parses "10,5" into minute offsets, ignoring invalid values
+func ParseAlarmOffsets(raw string) []int {
+	var alarms []int
+	seen := make(map[int]bool)
+	for _, part := range strings.Split(raw, ",") {
+		var minutes int
+		if _, err := fmt.Sscanf(strings.TrimSpace(part), "%d", &minutes); err != nil {
+			continue
+		}
+		if minutes < 0 || minutes > 180 || seen[minutes] {
+			continue
+		}
+		seen[minutes] = true
+		alarms = append(alarms, minutes)
+		if len(alarms) == MaxCalendarAlarms {
+			break
+		}
+	}
+	return alarms
+}
+
+func scheduleDate(month models.ShalatData, day models.ShalatSchedule) (time.Time, bool) {
+	if t, err := time.Parse("2006-01-02", day.TanggalLengkap); err == nil {
+		return t, true
+	}
+	if month.Tahun == 0 || month.Bulan == 0 || day.Tanggal == 0 {
+		return time.Time{}, false
+	}
+	return time.Date(month.Tahun, time.Month(month.Bulan), day.Tanggal, 0, 0, 0, 0, time.UTC), true
+}
+
+func escapeICSText(s string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+	return replacer.Replace(s)
+}
+
+// foldICSLine splits lines longer than 75 octets as required by RFC 5545
+func foldICSLine(line string) string {
+	if len(line) <= 75 {
+		return line
+	}
+	var b strings.Builder
+	width := 0
+	for _, r := range line {
+		size := len(string(r))
+		if width+size > 75 {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	return b.String()
+}
+
+func slugify(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case b.Len() > 0 && !strings.HasSuffix(b.String(), "-"):
+			b.WriteRune('-')
+		}
+	}
+	return strings.Trim(b.String(), "-")
+}
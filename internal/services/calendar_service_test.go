@@ -0,0 +1,43 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderICS(t *testing.T) {
+	months := []models.ShalatData{{
+		Provinsi: "Jawa Barat",
+		Kabkota:  "Kota Bandung",
+		Bulan:    3,
+		Tahun:    2026,
+		Jadwal: []models.ShalatSchedule{
+			{Tanggal: 1, TanggalLengkap: "2026-03-01", Imsak: "04:20", Subuh: "04:30", Dzuhur: "12:00", Ashar: "15:15", Maghrib: "18:10", Isya: "19:20"},
+		},
+	}}
+
+	ics := RenderICS("Kota Bandung, Jawa Barat", months, []int{10, 5})
+
+	assert.True(t, strings.HasPrefix(ics, "BEGIN:VCALENDAR\r\n"))
+	assert.Equal(t, 6, strings.Count(ics, "BEGIN:VEVENT"))
+	assert.Equal(t, 12, strings.Count(ics, "BEGIN:VALARM"))
+	assert.Contains(t, ics, "UID:20260301-subuh-kota-bandung-jawa-barat@amaliah-ramadhan")
+	assert.Contains(t, ics, "DTSTART:20260301T181000")
+	assert.Contains(t, ics, "TRIGGER:-PT10M")
+
+	// Same input must give identical output so ETags stay stable
+	assert.Equal(t, ics, RenderICS("Kota Bandung, Jawa Barat", months, []int{10, 5}))
+
+	for _, line := range strings.Split(ics, "\r\n") {
+		assert.LessOrEqual(t, len(line), 75)
+	}
+}
+
+func TestParseAlarmOffsets(t *testing.T) {
+	assert.Equal(t, []int{15, 5}, ParseAlarmOffsets("15, 5, 15, abc, -1"))
+	assert.Equal(t, []int{1, 2, 3}, ParseAlarmOffsets("1,2,3,4"))
+	assert.Nil(t, ParseAlarmOffsets(""))
+}
@@ -61,7 +61,11 @@ func (s *CertificateService) Generate(user *models.User, stats map[string]interf
 	pdf.SetY(120)
 	pdf.SetFont("Poppins", "", 12)
 	pdf.SetTextColor(50, 50, 50)
-	pdf.MultiCell(0, 6, "Atas partisipasi aktif dan pencapaian amaliah selama bulan suci Ramadhan 1447 H / 2026 M\ndalam kegiatan Smartren dan Program Monitoring Ibadah Ramadhan.", "", "C", false)
+	period, _ := stats["period"].(string)
+	if period == "" {
+		period = "Ramadhan"
+	}
+	pdf.MultiCell(0, 6, "Atas partisipasi aktif dan pencapaian amaliah selama bulan suci "+period+"\ndalam kegiatan Smartren dan Program Monitoring Ibadah Ramadhan.", "", "C", false)
 
 	// 7. Stats Summary Box
 	statsY := 145.0
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"os"
+	"strings"
+)
+
+// ErrContentUnavailable is returned when a provider has no data for a request
+var ErrContentUnavailable = errors.New("konten tidak tersedia")
+
+// ContentProvider supplies the Quran, doa and hadits shown on the reader pages.
+// MuslimAPIService (remote) and EmbeddedContentProvider (compiled in) both implement it.
+type ContentProvider interface {
+	GetAllDoa() ([]Doa, error)
+	GetDoaBySource(source string) ([]Doa, error)
+	SearchDoa(query string) ([]Doa, error)
+	GetAllHadits() ([]Hadits, error)
+	GetHaditsByNumber(nomor int) (*Hadits, error)
+	SearchHadits(query string) ([]Hadits, error)
+	GetAllSurah() ([]Surah, error)
+	GetSurahByID(id int) (*Surah, error)
+	GetAyahBySurah(surahID int) ([]Ayah, error)
+}
+
+// NewContentProvider builds the provider chain selected by CONTENT_PROVIDER:
+// "embedded" never leaves the binary, "remote" only uses the API, and the
+// default tries the embedded dataset first and the API for anything missing.
+func NewContentProvider() ContentProvider {
+	embedded := NewEmbeddedContentProvider()
+
+	switch strings.ToLower(os.Getenv("CONTENT_PROVIDER")) {
+	case "embedded":
+		return embedded
+	case "remote":
+		return NewMuslimAPIService()
+	}
+	if _, err := embedded.GetAyahBySurah(1); err != nil {
+		log.Printf("Warning: embedded Quran text missing (run go generate ./internal/services); ayat come from the remote API")
+	}
+	return &FallbackContentProvider{Providers: []ContentProvider{embedded, NewMuslimAPIService()}}
+}
+
+// FallbackContentProvider asks each provider in order and returns the first
+// non-empty answer. A search that returns no matches is a valid answer, so only
+// errors move on to the next provider for searches.
+type FallbackContentProvider struct {
+	Providers []ContentProvider
+}
+
+func (f *FallbackContentProvider) GetAllDoa() ([]Doa, error) {
+	return firstList(f.Providers, true, func(p ContentProvider) ([]Doa, error) { return p.GetAllDoa() })
+}
+
+func (f *FallbackContentProvider) GetDoaBySource(source string) ([]Doa, error) {
+	return firstList(f.Providers, true, func(p ContentProvider) ([]Doa, error) { return p.GetDoaBySource(source) })
+}
+
+func (f *FallbackContentProvider) SearchDoa(query string) ([]Doa, error) {
+	return firstList(f.Providers, false, func(p ContentProvider) ([]Doa, error) { return p.SearchDoa(query) })
+}
+
+func (f *FallbackContentProvider) GetAllHadits() ([]Hadits, error) {
+	return firstList(f.Providers, true, func(p ContentProvider) ([]Hadits, error) { return p.GetAllHadits() })
+}
+
+func (f *FallbackContentProvider) GetHaditsByNumber(nomor int) (*Hadits, error) {
+	return firstItem(f.Providers, func(p ContentProvider) (*Hadits, error) { return p.GetHaditsByNumber(nomor) })
+}
+
+func (f *FallbackContentProvider) SearchHadits(query string) ([]Hadits, error) {
+	return firstList(f.Providers, false, func(p ContentProvider) ([]Hadits, error) { return p.SearchHadits(query) })
+}
+
+func (f *FallbackContentProvider) GetAllSurah() ([]Surah, error) {
+	return firstList(f.Providers, true, func(p ContentProvider) ([]Surah, error) { return p.GetAllSurah() })
+}
+
+func (f *FallbackContentProvider) GetSurahByID(id int) (*Surah, error) {
+	return firstItem(f.Providers, func(p ContentProvider) (*Surah, error) { return p.GetSurahByID(id) })
+}
+
+func (f *FallbackContentProvider) GetAyahBySurah(surahID int) ([]Ayah, error) {
+	return firstList(f.Providers, true, func(p ContentProvider) ([]Ayah, error) { return p.GetAyahBySurah(surahID) })
+}
+
+func firstList[T any](providers []ContentProvider, needItems bool, get func(ContentProvider) ([]T, error)) ([]T, error) {
+	err := ErrContentUnavailable
+	for _, p := range providers {
+		var list []T
+		list, err = get(p)
+		if err == nil && (len(list) > 0 || !needItems) {
+			return list, nil
+		}
+	}
+	if err == nil {
+		err = ErrContentUnavailable
+	}
+	return nil, err
+}
+
+func firstItem[T any](providers []ContentProvider, get func(ContentProvider) (*T, error)) (*T, error) {
+	err := ErrContentUnavailable
+	for _, p := range providers {
+		var item *T
+		item, err = get(p)
+		if err == nil && item != nil {
+			return item, nil
+		}
+	}
+	if err == nil {
+		err = ErrContentUnavailable
+	}
+	return nil, err
+}
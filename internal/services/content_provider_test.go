@@ -0,0 +1,127 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmbeddedSurahMetadata(t *testing.T) {
+	p := NewEmbeddedContentProvider()
+
+	surahs, err := p.GetAllSurah()
+	assert.NoError(t, err)
+	assert.Len(t, surahs, 114)
+
+	total := 0
+	for i, s := range surahs {
+		assert.Equal(t, strconv.Itoa(i+1), s.Number)
+		n, _ := strconv.Atoi(s.NumberOfVerses)
+		total += n
+	}
+	assert.Equal(t, 6236, total)
+
+	yasin, err := p.GetSurahByID(36)
+	assert.NoError(t, err)
+	assert.Equal(t, "Yasin", yasin.NameID)
+
+	_, err = p.GetSurahByID(115)
+	assert.Error(t, err)
+}
+
+func TestEmbeddedDatasetComplete(t *testing.T) {
+	p := NewEmbeddedContentProvider()
+	surahs, err := p.GetAllSurah()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	for _, s := range surahs {
+		n, _ := strconv.Atoi(s.Number)
+		name := fmt.Sprintf("content/ayah/%03d.json", n)
+		if _, err := fs.Stat(contentFS, name); err != nil {
+			t.Errorf("%s missing, run go generate ./internal/services", name)
+			continue
+		}
+		ayat, err := p.GetAyahBySurah(n)
+		assert.NoError(t, err, name)
+		assert.Equal(t, s.NumberOfVerses, strconv.Itoa(len(ayat)), name)
+	}
+
+	for _, name := range []string{"content/doa.json", "content/hadits.json"} {
+		if _, err := fs.Stat(contentFS, name); err != nil {
+			t.Errorf("%s missing, run go generate ./internal/services", name)
+		}
+	}
+	doa, err := p.GetAllDoa()
+	assert.NoError(t, err)
+	assert.NotEmpty(t, doa)
+	hadits, err := p.GetAllHadits()
+	assert.NoError(t, err)
+	assert.NotEmpty(t, hadits)
+}
+
+func TestJuzOf(t *testing.T) {
+	assert.Equal(t, 1, JuzOf(1, 1))
+	assert.Equal(t, 1, JuzOf(2, 141))
+	assert.Equal(t, 2, JuzOf(2, 142))
+	assert.Equal(t, 15, JuzOf(17, 1))
+	assert.Equal(t, 29, JuzOf(77, 50))
+	assert.Equal(t, 30, JuzOf(114, 6))
+}
+
+type stubContent struct {
+	ContentProvider
+	doa []Doa
+	err error
+}
+
+func (s *stubContent) GetAllDoa() ([]Doa, error)             { return s.doa, s.err }
+func (s *stubContent) SearchDoa(query string) ([]Doa, error) { return s.doa, s.err }
+func (s *stubContent) GetAyahBySurah(surahID int) ([]Ayah, error) {
+	return nil, s.err
+}
+
+func TestFallbackContentProvider(t *testing.T) {
+	remote := &stubContent{doa: []Doa{{Judul: "Doa berbuka puasa"}}}
+
+	// Missing embedded data falls through to the next provider
+	f := &FallbackContentProvider{Providers: []ContentProvider{&stubContent{err: ErrContentUnavailable}, remote}}
+	list, err := f.GetAllDoa()
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+
+	// An empty search result is an answer, not a failure
+	f = &FallbackContentProvider{Providers: []ContentProvider{&stubContent{doa: []Doa{}}, remote}}
+	list, err = f.SearchDoa("tidak ada")
+	assert.NoError(t, err)
+	assert.Len(t, list, 0)
+
+	down := errors.New("timeout")
+	f = &FallbackContentProvider{Providers: []ContentProvider{&stubContent{err: ErrContentUnavailable}, &stubContent{err: down}}}
+	_, err = f.GetAllDoa()
+	assert.Equal(t, down, err)
+}
+
+func TestEmbeddedAyahWithoutRemote(t *testing.T) {
+	embedded := &EmbeddedContentProvider{fsys: fstest.MapFS{
+		"content/ayah/001.json": {Data: []byte(`[{"arab":"بِسْمِ اللّٰهِ الرَّحْمٰنِ الرَّحِيْمِ","text":"Dengan nama Allah Yang Maha Pengasih, Maha Penyayang.","ayah":"1","surah":"1"}]`)},
+	}}
+	f := &FallbackContentProvider{Providers: []ContentProvider{embedded, &stubContent{err: errors.New("timeout")}}}
+
+	ayat, err := f.GetAyahBySurah(1)
+	assert.NoError(t, err)
+	if assert.Len(t, ayat, 1) {
+		assert.Equal(t, "1", ayat[0].Ayah)
+		assert.Equal(t, "1", ayat[0].Juz)
+	}
+
+	// Surahs missing from the dataset surface the remote error
+	_, err = f.GetAyahBySurah(2)
+	assert.EqualError(t, err, "timeout")
+}
@@ -0,0 +1,339 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+	"github.com/ramadhan/amaliah-monitoring/internal/mushaf"
+	"github.com/ramadhan/amaliah-monitoring/internal/repository"
+)
+
+// RamadhanPhase is one of the three ten-day parts of Ramadhan. During a phase
+// the daily doa and hadits are drawn from items mentioning one of Keywords,
+// and the ayah from Ayat.
+type RamadhanPhase struct {
+	Number   int      `json:"number"`
+	Name     string   `json:"name"`
+	Theme    string   `json:"theme"`
+	Keywords []string `json:"-"`
+	Ayat     [][2]int `json:"-"`
+}
+
+var RamadhanPhases = []RamadhanPhase{
+	{
+		Number: 1, Name: "Sepuluh hari pertama", Theme: "Rahmah",
+		Keywords: []string{"rahmat", "kasih", "sayang", "syukur", "nikmat"},
+		Ayat:     [][2]int{{7, 156}, {21, 107}, {6, 54}, {7, 56}, {3, 159}, {40, 7}, {2, 218}, {14, 7}},
+	},
+	{
+		Number: 2, Name: "Sepuluh hari kedua", Theme: "Maghfirah",
+		Keywords: []string{"ampun", "taubat", "istighfar", "dosa"},
+		Ayat:     [][2]int{{3, 135}, {3, 133}, {4, 110}, {66, 8}, {71, 10}, {39, 53}, {11, 3}, {25, 70}, {110, 3}, {3, 16}},
+	},
+	{
+		Number: 3, Name: "Sepuluh hari terakhir", Theme: "Itqun minan nar",
+		Keywords: []string{"neraka", "lailatul", "qadar", "akhirat", "surga"},
+		Ayat:     [][2]int{{97, 1}, {97, 3}, {97, 5}, {44, 3}, {3, 185}, {2, 201}, {66, 6}, {3, 191}, {25, 65}},
+	},
+}
+
+// dailyAyat is the ayah rotation outside Ramadhan
+var dailyAyat = [][2]int{
+	{2, 183}, {2, 185}, {2, 186}, {2, 152}, {2, 153}, {2, 286}, {3, 139}, {13, 28}, {94, 5}, {94, 6},
+	{65, 3}, {29, 45}, {20, 114}, {49, 13}, {17, 23}, {31, 17}, {3, 200}, {39, 10}, {16, 97}, {33, 21},
+	{51, 56}, {2, 45}, {8, 46}, {103, 3}, {16, 128}, {3, 104}, {4, 36}, {17, 80}, {28, 77}, {59, 18},
+}
+
+// DailyContentKinds lists the kinds that rotate daily and can be pinned
+var DailyContentKinds = []struct {
+	Value string
+	Label string
+}{
+	{"ayah", "Ayat"},
+	{"doa", "Doa"},
+	{"hadits", "Hadits"},
+}
+
+var ErrInvalidDailyPin = errors.New("pilihan konten tidak valid")
+
+// PhaseOf returns the phase of a Ramadhan day (1-30), or nil outside Ramadhan
+func PhaseOf(ramadhanDay int) *RamadhanPhase {
+	if ramadhanDay < 1 {
+		return nil
+	}
+	n := min((ramadhanDay-1)/10, len(RamadhanPhases)-1)
+	return &RamadhanPhases[n]
+}
+
+// DailyIndex returns the position in a list of n items shown on day. It walks
+// the list one step per day, so an item repeats only after the whole list has
+// been shown, and kind shifts the start so the kinds do not move in lockstep.
+func DailyIndex(day time.Time, kind string, n int) int {
+	if n <= 0 {
+		return 0
+	}
+	y, m, d := day.Date()
+	days := time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix() / 86400
+	h := fnv.New32a()
+	h.Write([]byte(kind))
+	return int((days + int64(h.Sum32()%1024)) % int64(n))
+}
+
+// PickDaily picks the item of the day from items. With a phase, items whose
+// text mentions a phase keyword are preferred when there are any.
+func PickDaily[T any](items []T, day time.Time, kind string, phase *RamadhanPhase, text func(T) string) (T, bool) {
+	var zero T
+	if phase != nil {
+		var themed []T
+		for _, item := range items {
+			t := strings.ToLower(text(item))
+			for _, k := range phase.Keywords {
+				if strings.Contains(t, k) {
+					themed = append(themed, item)
+					break
+				}
+			}
+		}
+		if len(themed) > 0 {
+			items = themed
+		}
+	}
+	if len(items) == 0 {
+		return zero, false
+	}
+	return items[DailyIndex(day, kind, len(items))], true
+}
+
+type DailyAyah struct {
+	Surah     int    `json:"surah"`
+	Ayah      int    `json:"ayah"`
+	SurahName string `json:"surah_name"`
+	Arab      string `json:"arab"`
+	Latin     string `json:"latin"`
+	Text      string `json:"text"`
+	URL       string `json:"url"`
+}
+
+type DailyContent struct {
+	Date   string         `json:"date"`
+	Phase  *RamadhanPhase `json:"phase,omitempty"`
+	Ayah   *DailyAyah     `json:"ayah"`
+	Doa    *Doa           `json:"doa"`
+	Hadits *Hadits        `json:"hadits"`
+	// Pinned lists the kinds chosen by the school instead of the rotation
+	Pinned []string `json:"pinned"`
+}
+
+func (d *DailyContent) IsPinned(kind string) bool {
+	for _, k := range d.Pinned {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// DailyContentRetry is how long an incomplete pick is served before the
+// provider is asked again, so an outage does not slow every dashboard load
+const DailyContentRetry = 5 * time.Minute
+
+// DailyContentService picks the ayah, doa and hadits of the day. The pick
+// depends only on the date, the Ramadhan phase and the school's pins, so
+// complete results are kept in memory for the rest of the day and
+// incomplete ones for DailyContentRetry.
+type DailyContentService struct {
+	Repo    *repository.DailyContentRepository
+	Content ContentProvider
+
+	mu        sync.Mutex
+	cacheDate string
+	cache     map[string]dailyContentEntry
+}
+
+type dailyContentEntry struct {
+	content *DailyContent
+	retryAt time.Time // zero for a complete pick
+}
+
+func NewDailyContentService(repo *repository.DailyContentRepository, content ContentProvider) *DailyContentService {
+	return &DailyContentService{Repo: repo, Content: content}
+}
+
+// Get returns the content for day. ramadhanDay is 0 outside Ramadhan.
+func (s *DailyContentService) Get(day time.Time, schoolID, ramadhanDay int) *DailyContent {
+	date := day.Format("2006-01-02")
+	phase := PhaseOf(ramadhanDay)
+	key := fmt.Sprintf("%d|%d", schoolID, ramadhanDay)
+
+	s.mu.Lock()
+	if s.cacheDate != date {
+		s.cacheDate, s.cache = date, map[string]dailyContentEntry{}
+	}
+	cached, ok := s.cache[key]
+	s.mu.Unlock()
+	if ok && (cached.retryAt.IsZero() || time.Now().Before(cached.retryAt)) {
+		return cached.content
+	}
+
+	dc := &DailyContent{Date: date, Phase: phase, Pinned: []string{}}
+	pins := map[string]string{}
+	if schoolID > 0 {
+		list, _ := s.Repo.GetPins(schoolID, date)
+		for _, p := range list {
+			pins[p.Kind] = p.Ref
+		}
+	}
+
+	if ref, ok := pins["ayah"]; ok {
+		if surah, ayah, err := parseAyahRef(ref); err == nil {
+			dc.Ayah = s.loadAyah(surah, ayah)
+		}
+		if dc.Ayah != nil {
+			dc.Pinned = append(dc.Pinned, "ayah")
+		}
+	}
+	if dc.Ayah == nil {
+		pool := dailyAyat
+		if phase != nil {
+			pool = phase.Ayat
+		}
+		ref := pool[DailyIndex(day, "ayah", len(pool))]
+		dc.Ayah = s.loadAyah(ref[0], ref[1])
+	}
+
+	if all, err := s.Content.GetAllDoa(); err == nil {
+		if ref, ok := pins["doa"]; ok {
+			for i := range all {
+				if all[i].ID == ref {
+					dc.Doa = &all[i]
+					dc.Pinned = append(dc.Pinned, "doa")
+					break
+				}
+			}
+		}
+		if dc.Doa == nil {
+			if d, ok := PickDaily(all, day, "doa", phase, func(d Doa) string { return d.Judul + " " + d.Indo }); ok {
+				dc.Doa = &d
+			}
+		}
+	}
+
+	if ref, ok := pins["hadits"]; ok {
+		if n, err := strconv.Atoi(ref); err == nil {
+			if h, err := s.Content.GetHaditsByNumber(n); err == nil && h != nil && h.No != "" {
+				dc.Hadits = h
+				dc.Pinned = append(dc.Pinned, "hadits")
+			}
+		}
+	}
+	if dc.Hadits == nil {
+		if all, err := s.Content.GetAllHadits(); err == nil {
+			if h, ok := PickDaily(all, day, "hadits", phase, func(h Hadits) string { return h.Judul + " " + h.Indo }); ok {
+				dc.Hadits = &h
+			}
+		}
+	}
+
+	// An incomplete pick is retried after a while, in case the provider is back
+	entry := dailyContentEntry{content: dc}
+	if dc.Ayah == nil || dc.Doa == nil || dc.Hadits == nil {
+		entry.retryAt = time.Now().Add(DailyContentRetry)
+	}
+	s.mu.Lock()
+	if s.cacheDate == date {
+		s.cache[key] = entry
+	}
+	s.mu.Unlock()
+	return dc
+}
+
+func (s *DailyContentService) loadAyah(surah, ayah int) *DailyAyah {
+	ayat, err := s.Content.GetAyahBySurah(surah)
+	if err != nil {
+		return nil
+	}
+	for _, a := range ayat {
+		if a.Ayah != strconv.Itoa(ayah) {
+			continue
+		}
+		da := &DailyAyah{
+			Surah: surah, Ayah: ayah, Arab: a.Arab, Latin: a.Latin, Text: a.Text,
+			URL: fmt.Sprintf("/user/quran-indonesia?surah=%d#ayat-%d", surah, ayah),
+		}
+		if su, err := s.Content.GetSurahByID(surah); err == nil {
+			da.SurahName = su.NameID
+		}
+		return da
+	}
+	return nil
+}
+
+// parseAyahRef parses "surah:ayah"
+func parseAyahRef(ref string) (int, int, error) {
+	a, b, ok := strings.Cut(strings.TrimSpace(ref), ":")
+	if !ok {
+		return 0, 0, ErrInvalidDailyPin
+	}
+	surah, err1 := strconv.Atoi(strings.TrimSpace(a))
+	ayah, err2 := strconv.Atoi(strings.TrimSpace(b))
+	if err1 != nil || err2 != nil {
+		return 0, 0, ErrInvalidDailyPin
+	}
+	if err := mushaf.Validate(surah, ayah); err != nil {
+		return 0, 0, err
+	}
+	return surah, ayah, nil
+}
+
+// Pin stores a school's pick for a date after checking the reference
+func (s *DailyContentService) Pin(pin *models.DailyContentPin) error {
+	if _, err := time.Parse("2006-01-02", pin.Date); err != nil {
+		return fmt.Errorf("tanggal tidak valid")
+	}
+	switch pin.Kind {
+	case "ayah":
+		surah, ayah, err := parseAyahRef(pin.Ref)
+		if err != nil {
+			return err
+		}
+		pin.Ref = fmt.Sprintf("%d:%d", surah, ayah)
+	case "hadits":
+		if n, err := strconv.Atoi(strings.TrimSpace(pin.Ref)); err != nil || n < 1 {
+			return ErrInvalidDailyPin
+		}
+		pin.Ref = strings.TrimSpace(pin.Ref)
+	case "doa":
+		if strings.TrimSpace(pin.Ref) == "" {
+			return ErrInvalidDailyPin
+		}
+	default:
+		return ErrInvalidDailyPin
+	}
+
+	if err := s.Repo.SavePin(pin); err != nil {
+		return err
+	}
+	s.forget()
+	return nil
+}
+
+func (s *DailyContentService) Unpin(schoolID, id int) error {
+	if err := s.Repo.DeletePin(schoolID, id); err != nil {
+		return err
+	}
+	s.forget()
+	return nil
+}
+
+// forget drops cached picks after the pins change
+func (s *DailyContentService) forget() {
+	s.mu.Lock()
+	s.cacheDate, s.cache = "", nil
+	s.mu.Unlock()
+}
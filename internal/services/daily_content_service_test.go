@@ -0,0 +1,74 @@
+package services_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ramadhan/amaliah-monitoring/internal/mushaf"
+	"github.com/ramadhan/amaliah-monitoring/internal/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDailyIndexRotates(t *testing.T) {
+	day := time.Date(2026, 2, 18, 21, 0, 0, 0, time.Local)
+	first := services.DailyIndex(day, "doa", 7)
+	assert.Equal(t, first, services.DailyIndex(time.Date(2026, 2, 18, 4, 0, 0, 0, time.Local), "doa", 7))
+
+	seen := map[int]bool{}
+	for i := 0; i < 7; i++ {
+		seen[services.DailyIndex(day.AddDate(0, 0, i), "doa", 7)] = true
+	}
+	assert.Len(t, seen, 7, "a full cycle shows every item once")
+	assert.Equal(t, first, services.DailyIndex(day.AddDate(0, 0, 7), "doa", 7))
+}
+
+func TestPickDailyTheme(t *testing.T) {
+	doa := []services.Doa{
+		{Judul: "Doa sebelum makan"},
+		{Judul: "Doa mohon ampunan"},
+		{Judul: "Doa keluar rumah"},
+	}
+	text := func(d services.Doa) string { return d.Judul }
+	day := time.Date(2026, 3, 5, 0, 0, 0, 0, time.Local)
+
+	for i := 0; i < 5; i++ {
+		d, ok := services.PickDaily(doa, day.AddDate(0, 0, i), "doa", services.PhaseOf(15), text)
+		assert.True(t, ok)
+		assert.Equal(t, "Doa mohon ampunan", d.Judul)
+	}
+	// no item matches the first phase, so the whole list is used
+	_, ok := services.PickDaily(doa, day, "doa", services.PhaseOf(3), text)
+	assert.True(t, ok)
+
+	assert.Nil(t, services.PhaseOf(0))
+	assert.Equal(t, 3, services.PhaseOf(30).Number)
+	for _, p := range services.RamadhanPhases {
+		for _, ref := range p.Ayat {
+			assert.NoError(t, mushaf.Validate(ref[0], ref[1]))
+		}
+	}
+}
+
+type failingContent struct {
+	services.ContentProvider
+	calls int
+}
+
+func (f *failingContent) GetAyahBySurah(surahID int) ([]services.Ayah, error) {
+	f.calls++
+	return nil, errors.New("timeout")
+}
+func (f *failingContent) GetAllDoa() ([]services.Doa, error)       { return nil, errors.New("timeout") }
+func (f *failingContent) GetAllHadits() ([]services.Hadits, error) { return nil, errors.New("timeout") }
+
+func TestDailyContentOutageIsNotRetriedEveryLoad(t *testing.T) {
+	content := &failingContent{}
+	s := services.NewDailyContentService(nil, content)
+	day := time.Now()
+
+	dc := s.Get(day, 0, 0)
+	assert.Nil(t, dc.Ayah)
+	assert.Same(t, dc, s.Get(day, 0, 0))
+	assert.Equal(t, 1, content.calls)
+}
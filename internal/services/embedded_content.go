@@ -0,0 +1,183 @@
+package services
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ramadhan/amaliah-monitoring/internal/mushaf"
+)
+
+// The dataset is regenerated with `go generate ./internal/services` (see
+// cmd/contentgen) and committed: surah.json, ayah/001.json to ayah/114.json,
+// doa.json and hadits.json. TestEmbeddedDatasetComplete fails when any of
+// them is missing; the remote API only covers a dataset left incomplete.
+//
+//go:generate go run ../../cmd/contentgen -out content
+
+//go:embed content
+var contentFS embed.FS
+
+// JuzOf returns the juz (1-30) containing surah:ayah
+func JuzOf(surah, ayah int) int {
+	return mushaf.JuzOf(surah, ayah)
+}
+
+type EmbeddedContentProvider struct {
+	fsys fs.FS
+
+	once   sync.Once
+	surah  []Surah
+	doa    []Doa
+	hadits []Hadits
+	err    error
+}
+
+func NewEmbeddedContentProvider() *EmbeddedContentProvider {
+	return &EmbeddedContentProvider{fsys: contentFS}
+}
+
+func (p *EmbeddedContentProvider) load() error {
+	p.once.Do(func() {
+		if err := p.readJSON("content/surah.json", &p.surah); err != nil {
+			p.err = err
+			return
+		}
+		// Optional parts of the dataset
+		p.readJSON("content/doa.json", &p.doa)
+		p.readJSON("content/hadits.json", &p.hadits)
+	})
+	return p.err
+}
+
+func (p *EmbeddedContentProvider) readJSON(name string, v interface{}) error {
+	data, err := fs.ReadFile(p.fsys, name)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func (p *EmbeddedContentProvider) GetAllDoa() ([]Doa, error) {
+	if err := p.load(); err != nil {
+		return nil, err
+	}
+	if len(p.doa) == 0 {
+		return nil, ErrContentUnavailable
+	}
+	return p.doa, nil
+}
+
+func (p *EmbeddedContentProvider) GetDoaBySource(source string) ([]Doa, error) {
+	all, err := p.GetAllDoa()
+	if err != nil || source == "" {
+		return all, err
+	}
+	var list []Doa
+	for _, d := range all {
+		if strings.EqualFold(d.Source, source) {
+			list = append(list, d)
+		}
+	}
+	return list, nil
+}
+
+func (p *EmbeddedContentProvider) SearchDoa(query string) ([]Doa, error) {
+	all, err := p.GetAllDoa()
+	if err != nil {
+		return nil, err
+	}
+	list := []Doa{}
+	for _, d := range all {
+		if containsFold(query, d.Judul, d.Indo, d.Arab) {
+			list = append(list, d)
+		}
+	}
+	return list, nil
+}
+
+func (p *EmbeddedContentProvider) GetAllHadits() ([]Hadits, error) {
+	if err := p.load(); err != nil {
+		return nil, err
+	}
+	if len(p.hadits) == 0 {
+		return nil, ErrContentUnavailable
+	}
+	return p.hadits, nil
+}
+
+func (p *EmbeddedContentProvider) GetHaditsByNumber(nomor int) (*Hadits, error) {
+	all, err := p.GetAllHadits()
+	if err != nil {
+		return nil, err
+	}
+	for i := range all {
+		if all[i].No == strconv.Itoa(nomor) {
+			return &all[i], nil
+		}
+	}
+	return nil, ErrContentUnavailable
+}
+
+func (p *EmbeddedContentProvider) SearchHadits(query string) ([]Hadits, error) {
+	all, err := p.GetAllHadits()
+	if err != nil {
+		return nil, err
+	}
+	list := []Hadits{}
+	for _, h := range all {
+		if containsFold(query, h.Judul, h.Indo, h.Arab) {
+			list = append(list, h)
+		}
+	}
+	return list, nil
+}
+
+func (p *EmbeddedContentProvider) GetAllSurah() ([]Surah, error) {
+	if err := p.load(); err != nil {
+		return nil, err
+	}
+	return p.surah, nil
+}
+
+func (p *EmbeddedContentProvider) GetSurahByID(id int) (*Surah, error) {
+	if err := p.load(); err != nil {
+		return nil, err
+	}
+	if id < 1 || id > len(p.surah) {
+		return nil, ErrContentUnavailable
+	}
+	surah := p.surah[id-1]
+	return &surah, nil
+}
+
+func (p *EmbeddedContentProvider) GetAyahBySurah(surahID int) ([]Ayah, error) {
+	if surahID < 1 || surahID > 114 {
+		return nil, ErrContentUnavailable
+	}
+	var ayat []Ayah
+	if err := p.readJSON(fmt.Sprintf("content/ayah/%03d.json", surahID), &ayat); err != nil || len(ayat) == 0 {
+		return nil, ErrContentUnavailable
+	}
+	for i := range ayat {
+		if ayat[i].Juz == "" {
+			n, _ := strconv.Atoi(ayat[i].Ayah)
+			ayat[i].Juz = strconv.Itoa(JuzOf(surahID, n))
+		}
+	}
+	return ayat, nil
+}
+
+func containsFold(query string, fields ...string) bool {
+	query = strings.ToLower(strings.TrimSpace(query))
+	for _, f := range fields {
+		if strings.Contains(strings.ToLower(f), query) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,345 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+	"github.com/ramadhan/amaliah-monitoring/internal/repository"
+)
+
+// MaxBackdateDays caps how far back a school may allow entries
+const MaxBackdateDays = 30
+
+// DefaultEntryPolicy applies to students without a school: yesterday can
+// still be fixed, nothing needs approval
+var DefaultEntryPolicy = models.EntryPolicy{BackdateDays: 1}
+
+// LateEntryTypes lists the daily entries covered by the policy
+var LateEntryTypes = []struct {
+	Value string
+	Label string
+}{
+	{"prayer", "Shalat"},
+	{"fasting", "Puasa"},
+	{"quran", "Tilawah"},
+	{"amaliah", "Amaliah"},
+}
+
+// EntryLockSources describes why a date was locked
+var EntryLockSources = map[string]string{
+	"export":  "sudah diekspor guru",
+	"signoff": "sudah disahkan guru",
+}
+
+// LateEntryQueuedMessage is shown to students whose entry waits for a teacher
+const LateEntryQueuedMessage = "Data hari sebelumnya dikirim ke guru dan menunggu persetujuan"
+
+var (
+	ErrEntryInvalidDate   = errors.New("format tanggal tidak valid")
+	ErrEntryFutureDate    = errors.New("tidak bisa mengisi tanggal yang akan datang")
+	ErrEntryNeedsApproval = errors.New("perubahan data hari sebelumnya perlu persetujuan guru")
+	ErrLateEntryReviewed  = errors.New("pengajuan sudah diproses")
+	ErrLateEntryForbidden = errors.New("pengajuan ini bukan dari siswa sekolah Anda")
+	ErrEntryNoSchool      = errors.New("Anda belum terdaftar di sekolah")
+)
+
+// EntryTooOldError rejects a date before the school's backdating window
+type EntryTooOldError struct {
+	Days int
+}
+
+func (e *EntryTooOldError) Error() string {
+	if e.Days == 0 {
+		return "hanya data hari ini yang bisa diisi"
+	}
+	return fmt.Sprintf("data hanya bisa diisi hingga %d hari ke belakang", e.Days)
+}
+
+// EntryLockedError rejects a date the teacher has exported or signed off
+type EntryLockedError struct {
+	Lock *models.EntryLock
+}
+
+func (e *EntryLockedError) Error() string {
+	reason, ok := EntryLockSources[e.Lock.Source]
+	if !ok {
+		reason = "sudah dikunci guru"
+	}
+	return fmt.Sprintf("data tanggal %s %s dan tidak bisa diubah", e.Lock.Date, reason)
+}
+
+// LateEntryTypeLabel returns the display label of an entry type
+func LateEntryTypeLabel(t string) string {
+	for _, lt := range LateEntryTypes {
+		if lt.Value == t {
+			return lt.Label
+		}
+	}
+	return t
+}
+
+// ClampEntryPolicy keeps a configured policy within the supported range
+func ClampEntryPolicy(p models.EntryPolicy) models.EntryPolicy {
+	p.BackdateDays = max(0, p.BackdateDays)
+	if p.BackdateDays > MaxBackdateDays {
+		p.BackdateDays = MaxBackdateDays
+	}
+	return p
+}
+
+// CheckEntryDate applies p to an entry for date made at now. late reports a
+// past day that p sends to a teacher for approval.
+func CheckEntryDate(p models.EntryPolicy, date string, now time.Time) (late bool, err error) {
+	day, err := time.ParseInLocation("2006-01-02", date, now.Location())
+	if err != nil {
+		return false, ErrEntryInvalidDate
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	days := int(math.Round(today.Sub(day).Hours() / 24))
+	if days < 0 {
+		return false, ErrEntryFutureDate
+	}
+	if days > p.BackdateDays {
+		return false, &EntryTooOldError{Days: p.BackdateDays}
+	}
+	return days > 0 && p.LateApproval, nil
+}
+
+// EarliestEntryDate returns the first date p still accepts at now
+func EarliestEntryDate(p models.EntryPolicy, now time.Time) string {
+	return now.AddDate(0, 0, -p.BackdateDays).Format("2006-01-02")
+}
+
+// CanReviewLateEntry reports whether teacher may approve e. Teachers and
+// school admins are limited to their own school; the superadmin may review any.
+func CanReviewLateEntry(teacher *models.User, e *models.LateEntry) bool {
+	if teacher.Role == "superadmin" {
+		return true
+	}
+	return IsTeacher(teacher.Role) && teacher.SchoolID != 0 && teacher.SchoolID == e.SchoolID
+}
+
+type EntryPolicyService struct {
+	Repo *repository.EntryPolicyRepository
+	// Sync writes approved entries the same way the offline sync API does
+	Sync *SyncService
+}
+
+func NewEntryPolicyService(repo *repository.EntryPolicyRepository) *EntryPolicyService {
+	return &EntryPolicyService{Repo: repo}
+}
+
+// Policy returns the policy of the user's school
+func (s *EntryPolicyService) Policy(user *models.User) models.EntryPolicy {
+	if user.SchoolID == 0 {
+		return DefaultEntryPolicy
+	}
+	p, err := s.Repo.GetPolicy(user.SchoolID)
+	if err != nil {
+		return DefaultEntryPolicy
+	}
+	return ClampEntryPolicy(p)
+}
+
+// Check validates an entry of user for date against the school's policy and
+// the teacher's locks
+func (s *EntryPolicyService) Check(user *models.User, date string, now time.Time) (late bool, err error) {
+	late, err = CheckEntryDate(s.Policy(user), date, now)
+	if err != nil {
+		return false, err
+	}
+	if lock, err := s.Repo.FindLock(user.SchoolID, user.Class, date); err == nil {
+		return false, &EntryLockedError{Lock: lock}
+	}
+	return late, nil
+}
+
+// CheckRange validates an entry covering from to to: the first day against
+// the school's policy and every day against the teacher's locks
+func (s *EntryPolicyService) CheckRange(user *models.User, from, to string, now time.Time) (late bool, err error) {
+	late, err = s.Check(user, from, now)
+	if err != nil {
+		return false, err
+	}
+	if lock, err := s.Repo.FindLockBetween(user.SchoolID, user.Class, from, to); err == nil {
+		return false, &EntryLockedError{Lock: lock}
+	}
+	return late, nil
+}
+
+// Queue stores an entry for teacher approval; data is the sync API payload
+// of entryType
+func (s *EntryPolicyService) Queue(user *models.User, entryType, date string, data interface{}) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return s.Repo.CreateLateEntry(&models.LateEntry{
+		UserID:   user.ID,
+		SchoolID: user.SchoolID,
+		Type:     entryType,
+		Date:     date,
+		Data:     string(raw),
+	})
+}
+
+// Admit checks an entry and queues it when it needs approval. queued tells
+// the caller not to write the entry itself.
+func (s *EntryPolicyService) Admit(user *models.User, entryType, date string, data interface{}, now time.Time) (queued bool, err error) {
+	late, err := s.Check(user, date, now)
+	if err != nil || !late {
+		return false, err
+	}
+	return true, s.Queue(user, entryType, date, data)
+}
+
+// Approve writes a pending entry. An entry that no longer validates, or
+// whose date was locked while it waited, is closed as rejected and its
+// reason returned.
+func (s *EntryPolicyService) Approve(teacher *models.User, id int) (*models.LateEntry, error) {
+	e, err := s.review(teacher, id, "approved", "")
+	if err != nil {
+		return nil, err
+	}
+
+	if lock, err := s.Repo.FindLock(e.SchoolID, e.StudentClass, e.Date); err == nil {
+		locked := &EntryLockedError{Lock: lock}
+		s.Repo.UpdateLateEntryStatus(id, "rejected", locked.Error())
+		return nil, locked
+	}
+
+	item := models.SyncItem{Type: e.Type, Data: json.RawMessage(e.Data)}
+	if err := s.Sync.write(e.UserID, item, e.Date, e.CreatedAt.UTC(), e.CreatedAt.UTC()); err != nil {
+		if rej, ok := err.(*syncRejection); ok {
+			s.Repo.UpdateLateEntryStatus(id, "rejected", rej.msg)
+			return nil, rej
+		}
+		s.Repo.UpdateLateEntryStatus(id, "pending", "")
+		return nil, err
+	}
+	e.Status = "approved"
+	return e, nil
+}
+
+// Reject closes a pending entry without writing it
+func (s *EntryPolicyService) Reject(teacher *models.User, id int, note string) (*models.LateEntry, error) {
+	e, err := s.review(teacher, id, "rejected", note)
+	if err != nil {
+		return nil, err
+	}
+	e.Status = "rejected"
+	return e, nil
+}
+
+func (s *EntryPolicyService) review(teacher *models.User, id int, status, note string) (*models.LateEntry, error) {
+	e, err := s.Repo.GetLateEntry(id)
+	if err != nil {
+		return nil, err
+	}
+	if !CanReviewLateEntry(teacher, e) {
+		return nil, ErrLateEntryForbidden
+	}
+	// Claiming the entry first keeps a double click from applying it twice
+	if err := s.Repo.ReviewLateEntry(id, status, note, teacher.ID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrLateEntryReviewed
+		}
+		return nil, err
+	}
+	return e, nil
+}
+
+// Lock freezes date for a class of the teacher's school; an empty class locks
+// the whole school and the superadmin locks every school
+func (s *EntryPolicyService) Lock(teacher *models.User, class, date, source string) error {
+	// A lock with school 0 covers every school
+	if !HasSchoolScope(teacher) {
+		return ErrEntryNoSchool
+	}
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		return ErrEntryInvalidDate
+	}
+	schoolID := teacher.SchoolID
+	if teacher.Role == "superadmin" {
+		schoolID = 0
+	}
+	return s.Repo.Lock(&models.EntryLock{
+		SchoolID: schoolID,
+		Class:    class,
+		Date:     date,
+		Source:   source,
+		LockedBy: teacher.ID,
+	})
+}
+
+// Unlock removes a lock of the teacher's school; the superadmin may remove any
+func (s *EntryPolicyService) Unlock(teacher *models.User, id int) error {
+	if !HasSchoolScope(teacher) {
+		return ErrEntryNoSchool
+	}
+	schoolID := teacher.SchoolID
+	if teacher.Role == "superadmin" {
+		schoolID = 0
+	}
+	return s.Repo.DeleteLock(id, schoolID)
+}
+
+// Describe summarises the payload of a late entry for the teacher
+func (s *EntryPolicyService) Describe(e *models.LateEntry) string {
+	switch e.Type {
+	case "prayer":
+		var data models.SyncPrayerData
+		if json.Unmarshal([]byte(e.Data), &data) != nil {
+			break
+		}
+		var parts []string
+		for _, p := range []struct {
+			name  string
+			value *string
+		}{
+			{"Subuh", data.Subuh}, {"Dzuhur", data.Dzuhur}, {"Ashar", data.Ashar}, {"Maghrib", data.Maghrib}, {"Isya", data.Isya},
+		} {
+			if p.value != nil {
+				parts = append(parts, p.name+": "+*p.value)
+			}
+		}
+		return strings.Join(parts, ", ")
+	case "fasting":
+		var data models.SyncFastingData
+		if json.Unmarshal([]byte(e.Data), &data) != nil {
+			break
+		}
+		if data.Reason != "" {
+			return data.Status + " (" + TeacherFastingReason(data.Reason) + ")"
+		}
+		return data.Status
+	case "quran":
+		var data models.SyncQuranData
+		if json.Unmarshal([]byte(e.Data), &data) != nil {
+			break
+		}
+		return fmt.Sprintf("%s %d - %s %d", data.StartSurahName, data.StartAyah, data.EndSurahName, data.EndAyah)
+	case "amaliah":
+		var data models.SyncAmaliahData
+		if json.Unmarshal([]byte(e.Data), &data) != nil {
+			break
+		}
+		name := fmt.Sprintf("amaliah #%d", data.AmaliahTypeID)
+		if s.Sync != nil {
+			if t, err := s.Sync.AmaliahRepo.GetTypeByID(data.AmaliahTypeID); err == nil {
+				name = t.Name
+			}
+		}
+		if data.Action == "remove" {
+			return "Batal: " + name
+		}
+		return name
+	}
+	return e.Data
+}
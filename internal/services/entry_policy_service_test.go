@@ -0,0 +1,57 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+	"github.com/ramadhan/amaliah-monitoring/internal/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckEntryDate(t *testing.T) {
+	now := time.Date(2026, 3, 10, 21, 30, 0, 0, time.Local)
+	policy := models.EntryPolicy{BackdateDays: 2}
+
+	late, err := services.CheckEntryDate(policy, "2026-03-10", now)
+	assert.NoError(t, err)
+	assert.False(t, late)
+
+	late, err = services.CheckEntryDate(policy, "2026-03-08", now)
+	assert.NoError(t, err)
+	assert.False(t, late, "past days need no approval unless the school asks for it")
+
+	_, err = services.CheckEntryDate(policy, "2026-03-07", now)
+	assert.Equal(t, &services.EntryTooOldError{Days: 2}, err)
+
+	_, err = services.CheckEntryDate(policy, "2026-03-11", now)
+	assert.Equal(t, services.ErrEntryFutureDate, err)
+
+	_, err = services.CheckEntryDate(policy, "10/03/2026", now)
+	assert.Equal(t, services.ErrEntryInvalidDate, err)
+
+	policy.LateApproval = true
+	late, err = services.CheckEntryDate(policy, "2026-03-09", now)
+	assert.NoError(t, err)
+	assert.True(t, late)
+	late, _ = services.CheckEntryDate(policy, "2026-03-10", now)
+	assert.False(t, late, "today never needs approval")
+
+	_, err = services.CheckEntryDate(models.EntryPolicy{}, "2026-03-09", now)
+	assert.EqualError(t, err, "hanya data hari ini yang bisa diisi")
+}
+
+func TestClampEntryPolicy(t *testing.T) {
+	assert.Equal(t, 0, services.ClampEntryPolicy(models.EntryPolicy{BackdateDays: -3}).BackdateDays)
+	assert.Equal(t, services.MaxBackdateDays, services.ClampEntryPolicy(models.EntryPolicy{BackdateDays: 400}).BackdateDays)
+}
+
+func TestLockNeedsSchool(t *testing.T) {
+	// A lock or unlock with school 0 would reach every school
+	s := &services.EntryPolicyService{}
+	guru := &models.User{Role: "guru"}
+	assert.Equal(t, services.ErrEntryNoSchool, s.Lock(guru, "7A", "2026-03-10", "signoff"))
+	assert.Equal(t, services.ErrEntryNoSchool, s.Unlock(guru, 1))
+	assert.Equal(t, services.ErrEntryNoSchool, s.Unlock(&models.User{Role: "admin"}, 1))
+	assert.True(t, services.HasSchoolScope(&models.User{Role: "superadmin"}))
+}
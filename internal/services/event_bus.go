@@ -0,0 +1,79 @@
+package services
+
+import (
+	"sync"
+
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+)
+
+// recentActivityLimit is how many events the bus keeps for late subscribers
+const recentActivityLimit = 20
+
+// EventBus fans activity events out to live dashboards in this process.
+// Publishing never blocks: a subscriber that falls behind misses events but
+// still receives the next one, which is enough to trigger a fresh snapshot.
+type EventBus struct {
+	mu     sync.RWMutex
+	subs   map[chan models.ActivityEvent]int // channel -> school filter, 0 = all
+	recent []models.ActivityEvent
+}
+
+func NewEventBus() *EventBus {
+	return &EventBus{subs: make(map[chan models.ActivityEvent]int)}
+}
+
+// Subscribe returns a channel of events for one school (0 for every school) and
+// a function that must be called to release it.
+func (b *EventBus) Subscribe(schoolID int) (<-chan models.ActivityEvent, func()) {
+	ch := make(chan models.ActivityEvent, 16)
+
+	b.mu.Lock()
+	b.subs[ch] = schoolID
+	b.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs, ch)
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+func (b *EventBus) Publish(ev models.ActivityEvent) {
+	b.mu.Lock()
+	b.recent = append(b.recent, ev)
+	if len(b.recent) > recentActivityLimit {
+		b.recent = b.recent[len(b.recent)-recentActivityLimit:]
+	}
+	b.mu.Unlock()
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for ch, schoolID := range b.subs {
+		if schoolID != 0 && schoolID != ev.SchoolID {
+			continue
+		}
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// Recent returns up to limit of the latest events for a school, newest first
+func (b *EventBus) Recent(schoolID int, limit int) []models.ActivityEvent {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	events := make([]models.ActivityEvent, 0, limit)
+	for i := len(b.recent) - 1; i >= 0 && len(events) < limit; i-- {
+		if schoolID != 0 && b.recent[i].SchoolID != schoolID {
+			continue
+		}
+		events = append(events, b.recent[i])
+	}
+	return events
+}
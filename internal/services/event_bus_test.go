@@ -0,0 +1,43 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventBusSchoolScope(t *testing.T) {
+	bus := NewEventBus()
+
+	all, unsubAll := bus.Subscribe(0)
+	defer unsubAll()
+	school1, unsub1 := bus.Subscribe(1)
+	defer unsub1()
+
+	bus.Publish(models.ActivityEvent{Kind: "prayer", UserID: 10, SchoolID: 2})
+	bus.Publish(models.ActivityEvent{Kind: "quran", UserID: 11, SchoolID: 1})
+
+	assert.Equal(t, 10, (<-all).UserID)
+	assert.Equal(t, 11, (<-all).UserID)
+	assert.Equal(t, 11, (<-school1).UserID)
+	assert.Len(t, school1, 0)
+
+	recent := bus.Recent(0, 10)
+	assert.Len(t, recent, 2)
+	assert.Equal(t, "quran", recent[0].Kind, "newest first")
+	assert.Len(t, bus.Recent(2, 10), 1)
+}
+
+func TestEventBusDoesNotBlockSlowSubscriber(t *testing.T) {
+	bus := NewEventBus()
+	_, unsub := bus.Subscribe(0)
+
+	for i := 0; i < 100; i++ {
+		bus.Publish(models.ActivityEvent{UserID: i})
+	}
+	assert.Len(t, bus.Recent(0, 100), recentActivityLimit)
+
+	unsub()
+	unsub()
+}
@@ -2,6 +2,8 @@ package services
 
 import (
 	"fmt"
+	"sort"
+	"time"
 
 	"github.com/ramadhan/amaliah-monitoring/internal/models"
 	"github.com/ramadhan/amaliah-monitoring/internal/repository"
@@ -14,6 +16,18 @@ type ExportService struct {
 	FastingRepo *repository.FastingRepository
 	QuranRepo   *repository.QuranRepository
 	AmaliahRepo *repository.AmaliahRepository
+	SetoranRepo *repository.SetoranRepository
+	// PrayerTimingRepo, when set, adds on-time classifications to the reports
+	PrayerTimingRepo *repository.PrayerTimingRepository
+	// SunnahRepo, when set, adds a sunnah prayer sheet to the reports
+	SunnahRepo *repository.SunnahPrayerRepository
+	// UdzurRepo, when set, replaces the entries of excused days with a plain
+	// udzur flag
+	UdzurRepo *repository.UdzurRepository
+	// SunnahFastRepo, when set, adds a sunnah fasting sheet to the reports
+	SunnahFastRepo *repository.SunnahFastRepository
+	// FastingCheckinRepo, when set, adds sahur and iftar times to the reports
+	FastingCheckinRepo *repository.FastingCheckinRepository
 }
 
 func NewExportService(
@@ -32,7 +46,8 @@ func NewExportService(
 	}
 }
 
-func (s *ExportService) GenerateDailyReportExcel(date string, className string) (*excelize.File, error) {
+// GenerateDailyReportExcel exports one day of entries; schoolID 0 covers every school
+func (s *ExportService) GenerateDailyReportExcel(schoolID int, date string, className string) (*excelize.File, error) {
 	f := excelize.NewFile()
 	
 	// Create Sheet for Prayer
@@ -52,7 +67,9 @@ func (s *ExportService) GenerateDailyReportExcel(date string, className string)
 	var users []*models.User
 	var err error
 
-	if className != "" {
+	if schoolID != 0 {
+		users, err = s.UserRepo.GetStudentsBySchool(schoolID, className)
+	} else if className != "" {
 		users, err = s.UserRepo.GetByClass(className)
 	} else {
 		users, err = s.UserRepo.GetAll()
@@ -61,6 +78,7 @@ func (s *ExportService) GenerateDailyReportExcel(date string, className string)
 	if err != nil {
 		return nil, err
 	}
+	excused := s.excusedUsers(schoolID, date)
 	
 	row := 2
 	for _, user := range users {
@@ -69,16 +87,19 @@ func (s *ExportService) GenerateDailyReportExcel(date string, className string)
 		}
 		
 		prayer, _ := s.PrayerRepo.GetByUserAndDate(user.ID, date)
+		timings := s.prayerTimings(user.ID, date, date)[date]
 		
 		f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), user.FullName)
 		f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), user.Class)
 		
-		if prayer != nil {
-			f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), prayer.Subuh)
-			f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), prayer.Dzuhur)
-			f.SetCellValue(sheetName, fmt.Sprintf("E%d", row), prayer.Ashar)
-			f.SetCellValue(sheetName, fmt.Sprintf("F%d", row), prayer.Maghrib)
-			f.SetCellValue(sheetName, fmt.Sprintf("G%d", row), prayer.Isya)
+		if excused[user.ID] {
+			f.SetSheetRow(sheetName, fmt.Sprintf("C%d", row), &[]interface{}{UdzurLabel, UdzurLabel, UdzurLabel, UdzurLabel, UdzurLabel})
+		} else if prayer != nil {
+			f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), prayerCell(prayer.Subuh, timings["subuh"]))
+			f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), prayerCell(prayer.Dzuhur, timings["dzuhur"]))
+			f.SetCellValue(sheetName, fmt.Sprintf("E%d", row), prayerCell(prayer.Ashar, timings["ashar"]))
+			f.SetCellValue(sheetName, fmt.Sprintf("F%d", row), prayerCell(prayer.Maghrib, timings["maghrib"]))
+			f.SetCellValue(sheetName, fmt.Sprintf("G%d", row), prayerCell(prayer.Isya, timings["isya"]))
 		} else {
 			f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), "-")
 			f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), "-")
@@ -94,6 +115,9 @@ func (s *ExportService) GenerateDailyReportExcel(date string, className string)
 	f.NewSheet(sheetName)
 	
 	headers = []string{"Nama Siswa", "Kelas", "Status", "Alasan"}
+	if s.FastingCheckinRepo != nil {
+		headers = append(headers, "Sahur", "Berbuka")
+	}
 	for i, h := range headers {
 		cell := string(rune('A'+i)) + "1"
 		f.SetCellValue(sheetName, cell, h)
@@ -110,16 +134,62 @@ func (s *ExportService) GenerateDailyReportExcel(date string, className string)
 		f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), user.FullName)
 		f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), user.Class)
 		
-		if fasting != nil {
-			f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), fasting.Status)
-			f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), fasting.Reason)
+		if excused[user.ID] {
+			// The reason could reveal the kind of udzur
+			f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), UdzurLabel)
+			f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), "-")
+		} else if fasting != nil {
+			f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), string(fasting.Status))
+			f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), TeacherFastingReason(fasting.Reason))
 		} else {
 			f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), "-")
 			f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), "-")
 		}
+		if s.FastingCheckinRepo != nil && !excused[user.ID] {
+			checkins, _ := s.FastingCheckinRepo.GetByUserAndDate(user.ID, date)
+			f.SetSheetRow(sheetName, fmt.Sprintf("E%d", row), &[]interface{}{checkinCell(checkins["sahur"], UserLocation(user)), checkinCell(checkins["iftar"], UserLocation(user))})
+		}
 		row++
 	}
 
+	if s.SunnahRepo != nil {
+		sheetName = "Shalat Sunnah"
+		f.NewSheet(sheetName)
+		f.SetSheetRow(sheetName, "A1", &[]interface{}{"Nama Siswa", "Kelas", "Tarawih", "Tempat", "Tarawih Jamaah/Sendiri", "Witir", "Dhuha", "Tahajud", "Rawatib"})
+
+		row = 2
+		for _, user := range users {
+			if user.Role == "admin" {
+				continue
+			}
+			f.SetSheetRow(sheetName, fmt.Sprintf("A%d", row), &[]interface{}{user.FullName, user.Class})
+			p, _ := s.SunnahRepo.GetByUserAndDate(user.ID, date)
+			f.SetSheetRow(sheetName, fmt.Sprintf("C%d", row), sunnahCells(p))
+			row++
+		}
+	}
+
+	if s.SunnahFastRepo != nil {
+		sheetName = "Puasa Sunnah"
+		f.NewSheet(sheetName)
+		f.SetSheetRow(sheetName, "A1", &[]interface{}{"Nama Siswa", "Kelas", "Puasa Sunnah"})
+
+		row = 2
+		for _, user := range users {
+			if user.Role == "admin" {
+				continue
+			}
+			cell := "-"
+			if excused[user.ID] {
+				cell = UdzurLabel
+			} else if fast, err := s.SunnahFastRepo.GetByUserAndDate(user.ID, date); err == nil {
+				cell = SunnahFastingLabels(fast.Kinds)
+			}
+			f.SetSheetRow(sheetName, fmt.Sprintf("A%d", row), &[]interface{}{user.FullName, user.Class, cell})
+			row++
+		}
+	}
+
 	return f, nil
 }
 
@@ -144,6 +214,20 @@ func (s *ExportService) GenerateStudentReportExcel(userID int, startDate, endDat
 	f.SetCellValue(sheetName, "B4", user.Class)
 	f.SetCellValue(sheetName, "A5", "Periode:")
 	f.SetCellValue(sheetName, "B5", fmt.Sprintf("%s sd %s", startDate, endDate))
+	if s.PrayerTimingRepo != nil {
+		if stats, err := s.PrayerTimingRepo.GetStats(userID, startDate, endDate); err == nil && stats.Total > 0 {
+			f.SetCellValue(sheetName, "A6", "Shalat Tepat Waktu:")
+			f.SetCellValue(sheetName, "B6", fmt.Sprintf("%d%% (%d dari %d)", stats.Rate, stats.OnTime, stats.Total))
+		}
+	}
+	if s.FastingCheckinRepo != nil {
+		if stats, err := s.FastingCheckinRepo.GetStats(userID, startDate, endDate); err == nil && stats.Sahur+stats.Iftar > 0 {
+			f.SetCellValue(sheetName, "A7", "Sahur Sebelum Imsak:")
+			f.SetCellValue(sheetName, "B7", fmt.Sprintf("%d dari %d", stats.SahurOnTime, stats.Sahur))
+			f.SetCellValue(sheetName, "A8", "Berbuka Tepat Waktu:")
+			f.SetCellValue(sheetName, "B8", fmt.Sprintf("%d dari %d", stats.IftarOnTime, stats.Iftar))
+		}
+	}
 
 	// Sheet 2: Shalat
 	sheetName = "Shalat"
@@ -156,14 +240,21 @@ func (s *ExportService) GenerateStudentReportExcel(userID int, startDate, endDat
 	}
 
 	prayers, _ := s.PrayerRepo.GetByUserAndDateRange(userID, startDate, endDate)
+	timings := s.prayerTimings(userID, startDate, endDate)
+	excused := s.excusedDates(userID, startDate, endDate)
 	row := 2
 	for _, p := range prayers {
 		f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), p.Date)
-		f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), p.Subuh)
-		f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), p.Dzuhur)
-		f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), p.Ashar)
-		f.SetCellValue(sheetName, fmt.Sprintf("E%d", row), p.Maghrib)
-		f.SetCellValue(sheetName, fmt.Sprintf("F%d", row), p.Isya)
+		if excused[p.Date] {
+			f.SetSheetRow(sheetName, fmt.Sprintf("B%d", row), &[]interface{}{UdzurLabel, UdzurLabel, UdzurLabel, UdzurLabel, UdzurLabel})
+			row++
+			continue
+		}
+		f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), prayerCell(p.Subuh, timings[p.Date]["subuh"]))
+		f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), prayerCell(p.Dzuhur, timings[p.Date]["dzuhur"]))
+		f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), prayerCell(p.Ashar, timings[p.Date]["ashar"]))
+		f.SetCellValue(sheetName, fmt.Sprintf("E%d", row), prayerCell(p.Maghrib, timings[p.Date]["maghrib"]))
+		f.SetCellValue(sheetName, fmt.Sprintf("F%d", row), prayerCell(p.Isya, timings[p.Date]["isya"]))
 		row++
 	}
 
@@ -189,5 +280,254 @@ func (s *ExportService) GenerateStudentReportExcel(userID int, startDate, endDat
 		row++
 	}
 
+	if s.SunnahRepo != nil {
+		sheetName = "Shalat Sunnah"
+		f.NewSheet(sheetName)
+		f.SetSheetRow(sheetName, "A1", &[]interface{}{"Tanggal", "Tarawih", "Tempat", "Tarawih Jamaah/Sendiri", "Witir", "Dhuha", "Tahajud", "Rawatib"})
+
+		list, _ := s.SunnahRepo.GetByUserAndDateRange(userID, startDate, endDate)
+		for i, p := range list {
+			f.SetSheetRow(sheetName, fmt.Sprintf("A%d", i+2), &[]interface{}{p.Date})
+			f.SetSheetRow(sheetName, fmt.Sprintf("B%d", i+2), sunnahCells(p))
+		}
+	}
+
+	if s.SunnahFastRepo != nil {
+		sheetName = "Puasa Sunnah"
+		f.NewSheet(sheetName)
+		f.SetSheetRow(sheetName, "A1", &[]interface{}{"Tanggal", "Puasa Sunnah"})
+
+		list, _ := s.SunnahFastRepo.GetByUserAndDateRange(userID, startDate, endDate)
+		for i, fast := range list {
+			f.SetSheetRow(sheetName, fmt.Sprintf("A%d", i+2), &[]interface{}{fast.Date, SunnahFastingLabels(fast.Kinds)})
+		}
+	}
+
+	if s.FastingCheckinRepo != nil {
+		sheetName = "Sahur & Berbuka"
+		f.NewSheet(sheetName)
+		f.SetSheetRow(sheetName, "A1", &[]interface{}{"Tanggal", "Sahur", "Imsak", "Berbuka", "Maghrib"})
+
+		days, _ := s.FastingCheckinRepo.GetByUserAndDateRange(userID, startDate, endDate)
+		dates := make([]string, 0, len(days))
+		for d := range days {
+			dates = append(dates, d)
+		}
+		sort.Strings(dates)
+		for i, d := range dates {
+			sahur, iftar := days[d]["sahur"], days[d]["iftar"]
+			f.SetSheetRow(sheetName, fmt.Sprintf("A%d", i+2), &[]interface{}{d, checkinCell(sahur, UserLocation(user)), scheduleTime(sahur), checkinCell(iftar, UserLocation(user)), scheduleTime(iftar)})
+		}
+	}
+
 	return f, nil
 }
+
+// GenerateSetoranReportExcel lists the graded setoran of a class between two
+// dates, one row per setoran, plus a per-student recap. names maps surah
+// numbers to the names printed in the range column.
+func (s *ExportService) GenerateSetoranReportExcel(schoolID int, className, startDate, endDate string, names map[int]string) (*excelize.File, error) {
+	items, err := s.SetoranRepo.GetGradedByClass(schoolID, className, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	f := excelize.NewFile()
+
+	sheetName := "Setoran"
+	index, _ := f.NewSheet(sheetName)
+	f.SetActiveSheet(index)
+	f.DeleteSheet("Sheet1")
+
+	headers := []string{"Tanggal", "Nama Siswa", "Kelas", "Hafalan", "Ayat", "Nilai", "Catatan Tajwid", "Penguji", "Poin"}
+	for i, h := range headers {
+		cell := string(rune('A'+i)) + "1"
+		f.SetCellValue(sheetName, cell, h)
+	}
+
+	type recap struct {
+		name, class           string
+		total, passed, points int
+		ayahs                 int
+		grades                map[string]int
+	}
+	var order []int
+	recaps := make(map[int]*recap)
+
+	row := 2
+	for _, item := range items {
+		r := SetoranRange(item)
+		ayahs := 0
+		if c, err := r.Cover(); err == nil {
+			ayahs = c.Ayahs
+		}
+
+		f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), item.SetoranDate)
+		f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), item.StudentName)
+		f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), item.StudentClass)
+		f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), RangeLabel(names, r))
+		f.SetCellValue(sheetName, fmt.Sprintf("E%d", row), ayahs)
+		f.SetCellValue(sheetName, fmt.Sprintf("F%d", row), SetoranGradeLabel(item.Grade))
+		f.SetCellValue(sheetName, fmt.Sprintf("G%d", row), item.TajwidNotes)
+		f.SetCellValue(sheetName, fmt.Sprintf("H%d", row), item.TeacherName)
+		f.SetCellValue(sheetName, fmt.Sprintf("I%d", row), item.Points)
+		row++
+
+		rc, ok := recaps[item.StudentID]
+		if !ok {
+			rc = &recap{name: item.StudentName, class: item.StudentClass, grades: make(map[string]int)}
+			recaps[item.StudentID] = rc
+			order = append(order, item.StudentID)
+		}
+		rc.total++
+		rc.grades[item.Grade]++
+		rc.points += item.Points
+		if item.Points > 0 {
+			rc.passed++
+			rc.ayahs += ayahs
+		}
+	}
+
+	sheetName = "Rekap"
+	f.NewSheet(sheetName)
+
+	headers = []string{"Nama Siswa", "Kelas", "Jumlah Setoran"}
+	for _, g := range SetoranGrades {
+		headers = append(headers, g.Label)
+	}
+	headers = append(headers, "Ayat Lulus", "Total Poin")
+	for i, h := range headers {
+		cell := string(rune('A'+i)) + "1"
+		f.SetCellValue(sheetName, cell, h)
+	}
+
+	row = 2
+	for _, id := range order {
+		rc := recaps[id]
+		values := []interface{}{rc.name, rc.class, rc.total}
+		for _, g := range SetoranGrades {
+			values = append(values, rc.grades[g.Value])
+		}
+		values = append(values, rc.ayahs, rc.points)
+		for i, v := range values {
+			f.SetCellValue(sheetName, fmt.Sprintf("%c%d", 'A'+i, row), v)
+		}
+		row++
+	}
+
+	return f, nil
+}
+
+// GenerateQadhaReportExcel lists the make-up fasts each student of a class
+// owes. rate is the rupiah value of one day of fidyah, 0 to leave it out.
+func (s *ExportService) GenerateQadhaReportExcel(rows []QadhaStudent, rate int) (*excelize.File, error) {
+	f := excelize.NewFile()
+
+	sheetName := "Qadha"
+	index, _ := f.NewSheet(sheetName)
+	f.SetActiveSheet(index)
+	f.DeleteSheet("Sheet1")
+
+	headers := []string{"Nama Siswa", "Kelas", "Wajib Qadha", "Sudah Diganti", "Sisa", "Fidyah (hari)"}
+	if rate > 0 {
+		headers = append(headers, "Fidyah (Rp)")
+	}
+	for i, h := range headers {
+		cell := string(rune('A'+i)) + "1"
+		f.SetCellValue(sheetName, cell, h)
+	}
+
+	for i, r := range rows {
+		values := []interface{}{r.User.FullName, r.User.Class, r.Owed, r.Paid, r.Outstanding, r.FidyahDays}
+		if rate > 0 {
+			values = append(values, r.FidyahDays*rate)
+		}
+		for j, v := range values {
+			f.SetCellValue(sheetName, fmt.Sprintf("%c%d", 'A'+j, i+2), v)
+		}
+	}
+
+	return f, nil
+}
+
+// excusedUsers returns the students of a school excused on date
+func (s *ExportService) excusedUsers(schoolID int, date string) map[int]bool {
+	if s.UdzurRepo == nil {
+		return nil
+	}
+	excused, _ := s.UdzurRepo.GetExcusedUsers(schoolID, date)
+	return excused
+}
+
+// excusedDates returns a student's excused dates between two dates
+func (s *ExportService) excusedDates(userID int, startDate, endDate string) map[string]bool {
+	if s.UdzurRepo == nil {
+		return nil
+	}
+	periods, _ := s.UdzurRepo.GetInRange(userID, startDate, endDate)
+	return ExcusedDates(periods, startDate, endDate)
+}
+
+func (s *ExportService) prayerTimings(userID int, startDate, endDate string) map[string]map[string]*models.PrayerTiming {
+	if s.PrayerTimingRepo == nil {
+		return nil
+	}
+	timings, _ := s.PrayerTimingRepo.GetByUserAndDateRange(userID, startDate, endDate)
+	return timings
+}
+
+// prayerCell prints a prayer status with its timing, e.g. "jamaah (Tepat waktu)"
+func prayerCell(status models.PrayerStatus, timing *models.PrayerTiming) string {
+	if timing != nil && timing.Status == status {
+		if label := PrayerTimingLabel(timing.Timing); label != "" {
+			return string(status) + " (" + label + ")"
+		}
+	}
+	return string(status)
+}
+
+// checkinCell prints the time of a sahur or iftar with whether it was on
+// time, e.g. "04:05 (sebelum imsak)"
+func checkinCell(c *models.FastingCheckin, loc *time.Location) string {
+	if c == nil {
+		return "-"
+	}
+	label := "tepat waktu"
+	switch {
+	case c.Meal == "sahur" && c.OnTime:
+		label = "sebelum imsak"
+	case c.Meal == "sahur":
+		label = "lewat imsak"
+	case !c.OnTime:
+		label = "terlambat"
+	}
+	return c.ClockIn(loc) + " (" + label + ")"
+}
+
+func scheduleTime(c *models.FastingCheckin) string {
+	if c == nil || c.ScheduleTime == "" {
+		return "-"
+	}
+	return c.ScheduleTime
+}
+
+// sunnahCells lists the rakaat of each sunnah prayer of a day, "-" when not prayed
+func sunnahCells(p *models.SunnahPrayer) *[]interface{} {
+	if p == nil {
+		p = &models.SunnahPrayer{}
+	}
+	rakaat := func(n int) interface{} {
+		if n == 0 {
+			return "-"
+		}
+		return n
+	}
+	place, mode, rawatib := "-", "-", "-"
+	if p.TarawihRakaat > 0 {
+		place, mode = p.TarawihPlace, p.TarawihMode
+	}
+	if p.Rawatib != "" {
+		rawatib = RawatibLabels(p.Rawatib)
+	}
+	return &[]interface{}{rakaat(p.TarawihRakaat), place, mode, rakaat(p.WitirRakaat), rakaat(p.DhuhaRakaat), rakaat(p.TahajudRakaat), rawatib}
+}
@@ -0,0 +1,306 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/ramadhan/amaliah-monitoring/internal/hijri"
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+	"github.com/ramadhan/amaliah-monitoring/internal/repository"
+)
+
+// FastingMeals lists the two check-ins of a fasting day
+var FastingMeals = []struct {
+	Value string
+	Label string
+}{
+	{"sahur", "Sahur"},
+	{"iftar", "Berbuka"},
+}
+
+const (
+	// IftarOnTimeLimit is how long after Maghrib breaking the fast still
+	// counts as hastening it
+	IftarOnTimeLimit = 30 * time.Minute
+	// CheckinReminderLead is how long before imsak or Maghrib the reminder
+	// starts counting down
+	CheckinReminderLead = time.Hour
+	// DefaultSahurPoints are awarded for a sahur before imsak unless
+	// SAHUR_POINTS says otherwise
+	DefaultSahurPoints = 5
+)
+
+var (
+	ErrCheckinMeal       = errors.New("jenis check-in tidak dikenal")
+	ErrCheckinNoLocation = errors.New("atur provinsi dan kabupaten/kota di profil untuk mencatat sahur dan berbuka")
+	ErrCheckinNoSchedule = errors.New("jadwal imsak dan maghrib belum tersedia, coba lagi nanti")
+	ErrCheckinDuplicate  = errors.New("check-in ini sudah dicatat hari ini")
+	ErrCheckinNotFasting = errors.New("hari ini tercatat tidak puasa")
+	ErrCheckinExcused    = errors.New("hari ini tercatat udzur")
+	ErrCheckinNoFast     = errors.New("sahur dan berbuka hanya dicatat pada hari Ramadhan atau hari puasa sunnah yang sudah dicatat")
+	ErrCheckinNotFound   = errors.New("check-in tidak ditemukan")
+)
+
+// CheckinTooEarlyError rejects breaking the fast before Maghrib
+type CheckinTooEarlyError struct {
+	Maghrib time.Time
+}
+
+func (e *CheckinTooEarlyError) Error() string {
+	return fmt.Sprintf("belum waktu berbuka (Maghrib pukul %s)", e.Maghrib.Format("15:04"))
+}
+
+// SahurClosedError rejects a sahur logged after Subuh, when the fast has begun
+type SahurClosedError struct {
+	Subuh time.Time
+}
+
+func (e *SahurClosedError) Error() string {
+	return fmt.Sprintf("waktu sahur sudah lewat (Subuh pukul %s)", e.Subuh.Format("15:04"))
+}
+
+func FastingMealLabel(meal string) string {
+	for _, m := range FastingMeals {
+		if m.Value == meal {
+			return m.Label
+		}
+	}
+	return ""
+}
+
+// SahurPointsFromEnv reads SAHUR_POINTS, the amaliah points for a sahur
+// before imsak; 0 turns the reward off
+func SahurPointsFromEnv() int {
+	v, ok := os.LookupEnv("SAHUR_POINTS")
+	if !ok {
+		return DefaultSahurPoints
+	}
+	points, err := strconv.Atoi(v)
+	if err != nil || points < 0 {
+		return DefaultSahurPoints
+	}
+	return points
+}
+
+// FastingTimes are the moments of a fasting day the check-ins are held against
+type FastingTimes struct {
+	Imsak   time.Time
+	Subuh   time.Time
+	Maghrib time.Time
+}
+
+// FastingTimesOf reads the imsak, Subuh and Maghrib times of day from its
+// schedule
+func FastingTimesOf(day time.Time, s models.ImsakiyahSchedule) (FastingTimes, error) {
+	clock := func(v string) (time.Time, error) {
+		t, err := time.ParseInLocation("15:04", v, day.Location())
+		if err != nil {
+			return time.Time{}, fmt.Errorf("jam jadwal tidak valid: %q", v)
+		}
+		return time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), 0, 0, day.Location()), nil
+	}
+
+	var ft FastingTimes
+	var err error
+	if ft.Imsak, err = clock(s.Imsak); err != nil {
+		return ft, err
+	}
+	if ft.Subuh, err = clock(s.Subuh); err != nil {
+		return ft, err
+	}
+	if ft.Maghrib, err = clock(s.Maghrib); err != nil {
+		return ft, err
+	}
+	return ft, nil
+}
+
+// ClassifyCheckin validates a check-in made at against the day's times and
+// reports whether it was on time: a sahur before imsak, or an iftar within
+// IftarOnTimeLimit of Maghrib. A sahur between imsak and Subuh is accepted
+// but late.
+func ClassifyCheckin(meal string, ft FastingTimes, at time.Time) (bool, error) {
+	switch meal {
+	case "sahur":
+		if !at.Before(ft.Subuh) {
+			return false, &SahurClosedError{Subuh: ft.Subuh}
+		}
+		return at.Before(ft.Imsak), nil
+	case "iftar":
+		if at.Before(ft.Maghrib) {
+			return false, &CheckinTooEarlyError{Maghrib: ft.Maghrib}
+		}
+		return at.Before(ft.Maghrib.Add(IftarOnTimeLimit)), nil
+	}
+	return false, ErrCheckinMeal
+}
+
+// CheckinReminder returns the reminder to show at now, or "" when none is
+// due: a countdown to imsak and to Maghrib in the hour before them, and a
+// nudge while a check-in is open but not yet made.
+func CheckinReminder(ft FastingTimes, now time.Time, done map[string]*models.FastingCheckin) string {
+	minutes := func(d time.Duration) int { return int(math.Ceil(d.Minutes())) }
+	switch {
+	case done["sahur"] == nil && now.Before(ft.Imsak) && ft.Imsak.Sub(now) <= CheckinReminderLead:
+		return fmt.Sprintf("Imsak pukul %s, %d menit lagi. Jangan lewatkan sahur!", ft.Imsak.Format("15:04"), minutes(ft.Imsak.Sub(now)))
+	case done["sahur"] == nil && !now.Before(ft.Imsak) && now.Before(ft.Subuh):
+		return fmt.Sprintf("Sudah masuk imsak. Sahur masih bisa dicatat sampai Subuh pukul %s.", ft.Subuh.Format("15:04"))
+	case done["iftar"] == nil && now.Before(ft.Maghrib) && ft.Maghrib.Sub(now) <= CheckinReminderLead:
+		return fmt.Sprintf("Berbuka pukul %s, %d menit lagi.", ft.Maghrib.Format("15:04"), minutes(ft.Maghrib.Sub(now)))
+	case done["iftar"] == nil && !now.Before(ft.Maghrib) && now.Before(ft.Maghrib.Add(IftarOnTimeLimit)):
+		return "Sudah masuk waktu Maghrib, segerakan berbuka!"
+	}
+	return ""
+}
+
+type FastingCheckinService struct {
+	Repo        *repository.FastingCheckinRepository
+	FastingRepo *repository.FastingRepository
+	UdzurRepo   *repository.UdzurRepository
+	UserRepo    *repository.UserRepository
+	Imsakiyah   *ImsakiyahService
+	Shalat      *ShalatService
+	// SunnahFastRepo, when set, opens check-ins on days with a logged
+	// sunnah fast; otherwise only Ramadhan days qualify
+	SunnahFastRepo *repository.SunnahFastRepository
+	// SahurPoints are awarded for a sahur before imsak
+	SahurPoints int
+}
+
+func NewFastingCheckinService(repo *repository.FastingCheckinRepository, fastingRepo *repository.FastingRepository, udzurRepo *repository.UdzurRepository, userRepo *repository.UserRepository, imsakiyah *ImsakiyahService, shalat *ShalatService) *FastingCheckinService {
+	return &FastingCheckinService{
+		Repo:        repo,
+		FastingRepo: fastingRepo,
+		UdzurRepo:   udzurRepo,
+		UserRepo:    userRepo,
+		Imsakiyah:   imsakiyah,
+		Shalat:      shalat,
+		SahurPoints: SahurPointsFromEnv(),
+	}
+}
+
+// Schedule returns the user's schedule of day: the imsakiyah table during
+// Ramadhan and the monthly prayer schedule outside it
+func (s *FastingCheckinService) Schedule(user *models.User, day time.Time, offset int) (*models.ImsakiyahSchedule, error) {
+	if user.Provinsi == "" || user.Kabkota == "" {
+		return nil, ErrCheckinNoLocation
+	}
+	if n, ok := hijri.RamadhanFor(day, offset).Day(day); ok {
+		return s.Imsakiyah.GetTodaySchedule(user.Provinsi, user.Kabkota, n, offset)
+	}
+	sh, err := s.Shalat.GetSchedule(user.Provinsi, user.Kabkota, day)
+	if err != nil {
+		return nil, err
+	}
+	// Outside Ramadhan the imsakiyah table has no row for the day
+	return &models.ImsakiyahSchedule{
+		Tanggal: day.Day(), Imsak: sh.Imsak, Subuh: sh.Subuh, Terbit: sh.Terbit, Dhuha: sh.Dhuha,
+		Dzuhur: sh.Dzuhur, Ashar: sh.Ashar, Maghrib: sh.Maghrib, Isya: sh.Isya,
+	}, nil
+}
+
+// FastingDay reports whether the user fasts on day: a day of Ramadhan or a
+// day they logged a sunnah fast for
+func (s *FastingCheckinService) FastingDay(user *models.User, day time.Time, offset int) bool {
+	if _, ok := hijri.RamadhanFor(day, offset).Day(day); ok {
+		return true
+	}
+	if s.SunnahFastRepo == nil {
+		return false
+	}
+	_, err := s.SunnahFastRepo.GetByUserAndDate(user.ID, day.Format("2006-01-02"))
+	return err == nil
+}
+
+// Times returns the fasting times of the user's day in the time zone of
+// their kabupaten/kota
+func (s *FastingCheckinService) Times(user *models.User, day time.Time, offset int) (FastingTimes, error) {
+	day = day.In(UserLocation(user))
+	schedule, err := s.Schedule(user, day, offset)
+	if err != nil {
+		if err == ErrCheckinNoLocation {
+			return FastingTimes{}, err
+		}
+		return FastingTimes{}, ErrCheckinNoSchedule
+	}
+	return FastingTimesOf(day, *schedule)
+}
+
+// CheckIn records a sahur or iftar made now. Check-ins are always for
+// today, which must be a fasting day; a sahur before imsak earns SahurPoints.
+func (s *FastingCheckinService) CheckIn(user *models.User, meal string, offset int, now time.Time) (*models.FastingCheckin, error) {
+	if FastingMealLabel(meal) == "" {
+		return nil, ErrCheckinMeal
+	}
+	// The student's day, not the server's
+	now = now.In(UserLocation(user))
+	date := now.Format("2006-01-02")
+
+	done, err := s.Repo.GetByUserAndDate(user.ID, date)
+	if err != nil {
+		return nil, err
+	}
+	if done[meal] != nil {
+		return nil, ErrCheckinDuplicate
+	}
+	if periods, err := s.UdzurRepo.GetInRange(user.ID, date, date); err != nil {
+		return nil, err
+	} else if len(periods) > 0 {
+		return nil, ErrCheckinExcused
+	}
+	if f, err := s.FastingRepo.GetByUserAndDate(user.ID, date); err == nil && !f.Status.Done() {
+		return nil, ErrCheckinNotFasting
+	}
+	if !s.FastingDay(user, now, offset) {
+		return nil, ErrCheckinNoFast
+	}
+
+	ft, err := s.Times(user, now, offset)
+	if err != nil {
+		return nil, err
+	}
+	onTime, err := ClassifyCheckin(meal, ft, now)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &models.FastingCheckin{UserID: user.ID, Date: date, Meal: meal, CheckedAt: now, OnTime: onTime}
+	c.ScheduleTime = ft.Imsak.Format("15:04")
+	if meal == "iftar" {
+		c.ScheduleTime = ft.Maghrib.Format("15:04")
+	}
+	if meal == "sahur" && onTime {
+		c.Points = s.SahurPoints
+	}
+	if err := s.Repo.Create(c); err != nil {
+		return nil, err
+	}
+	if c.Points != 0 {
+		if err := s.UserRepo.UpdatePoints(user.ID, c.Points); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// Undo removes today's check-in of meal and takes back its points
+func (s *FastingCheckinService) Undo(user *models.User, meal string, now time.Time) error {
+	done, err := s.Repo.GetByUserAndDate(user.ID, now.In(UserLocation(user)).Format("2006-01-02"))
+	if err != nil {
+		return err
+	}
+	c := done[meal]
+	if c == nil {
+		return ErrCheckinNotFound
+	}
+	if err := s.Repo.Delete(c.ID); err != nil {
+		return err
+	}
+	if c.Points != 0 {
+		return s.UserRepo.UpdatePoints(user.ID, -c.Points)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+	"github.com/ramadhan/amaliah-monitoring/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyCheckin(t *testing.T) {
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local)
+	ft, err := services.FastingTimesOf(day, models.ImsakiyahSchedule{Imsak: "04:30", Subuh: "04:40", Maghrib: "18:10"})
+	require.NoError(t, err)
+
+	at := func(clock string) time.Time {
+		c, _ := time.Parse("15:04", clock)
+		return time.Date(2026, 3, 1, c.Hour(), c.Minute(), 0, 0, time.Local)
+	}
+
+	onTime, err := services.ClassifyCheckin("sahur", ft, at("04:00"))
+	assert.NoError(t, err)
+	assert.True(t, onTime)
+
+	// Between imsak and Subuh the sahur is still valid, but late
+	onTime, err = services.ClassifyCheckin("sahur", ft, at("04:35"))
+	assert.NoError(t, err)
+	assert.False(t, onTime)
+
+	_, err = services.ClassifyCheckin("sahur", ft, at("04:40"))
+	var closed *services.SahurClosedError
+	assert.ErrorAs(t, err, &closed)
+
+	_, err = services.ClassifyCheckin("iftar", ft, at("18:05"))
+	var early *services.CheckinTooEarlyError
+	assert.ErrorAs(t, err, &early)
+
+	onTime, _ = services.ClassifyCheckin("iftar", ft, at("18:30"))
+	assert.True(t, onTime)
+	onTime, _ = services.ClassifyCheckin("iftar", ft, at("18:45"))
+	assert.False(t, onTime)
+
+	_, err = services.ClassifyCheckin("makan", ft, at("12:00"))
+	assert.ErrorIs(t, err, services.ErrCheckinMeal)
+}
+
+func TestCheckinReminder(t *testing.T) {
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local)
+	ft, err := services.FastingTimesOf(day, models.ImsakiyahSchedule{Imsak: "04:30", Subuh: "04:40", Maghrib: "18:10"})
+	require.NoError(t, err)
+	at := func(h, m int) time.Time { return time.Date(2026, 3, 1, h, m, 0, 0, time.Local) }
+	none := map[string]*models.FastingCheckin{}
+
+	assert.Empty(t, services.CheckinReminder(ft, at(3, 0), none))
+	assert.Contains(t, services.CheckinReminder(ft, at(4, 0), none), "30 menit lagi")
+	assert.Contains(t, services.CheckinReminder(ft, at(4, 32), none), "sampai Subuh pukul 04:40")
+	assert.Empty(t, services.CheckinReminder(ft, at(4, 0), map[string]*models.FastingCheckin{"sahur": {}}))
+
+	assert.Contains(t, services.CheckinReminder(ft, at(17, 50), none), "20 menit lagi")
+	assert.Contains(t, services.CheckinReminder(ft, at(18, 15), none), "segerakan berbuka")
+	assert.Empty(t, services.CheckinReminder(ft, at(18, 15), map[string]*models.FastingCheckin{"iftar": {}}))
+	assert.Empty(t, services.CheckinReminder(ft, at(19, 0), none))
+}
+
+func TestFastingTimesInUserZone(t *testing.T) {
+	user := &models.User{Provinsi: "Papua", Kabkota: "Kota Jayapura"}
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, services.UserLocation(user))
+	ft, err := services.FastingTimesOf(day, models.ImsakiyahSchedule{Imsak: "04:10", Subuh: "04:20", Maghrib: "18:00"})
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC), ft.Maghrib.UTC())
+
+	c := &models.FastingCheckin{CheckedAt: time.Date(2026, 3, 1, 9, 5, 0, 0, time.UTC)}
+	assert.Equal(t, "18:05", c.ClockIn(services.UserLocation(user)))
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+	"github.com/ramadhan/amaliah-monitoring/internal/repository"
+)
+
+// FavoriteTypes lists the content that can be saved as a favorite
+var FavoriteTypes = []struct {
+	Value string
+	Label string
+}{
+	{"doa", "Doa"},
+	{"hadits", "Hadits"},
+	{"ayah", "Ayat"},
+}
+
+var (
+	ErrInvalidFavorite       = errors.New("konten favorit tidak valid")
+	ErrInvalidCollectionName = errors.New("nama koleksi harus 1-100 karakter")
+	ErrCollectionNotFound    = errors.New("koleksi tidak ditemukan")
+)
+
+type FavoriteService struct {
+	Repo    *repository.FavoriteRepository
+	Content ContentProvider
+}
+
+func NewFavoriteService(repo *repository.FavoriteRepository, content ContentProvider) *FavoriteService {
+	return &FavoriteService{Repo: repo, Content: content}
+}
+
+// Resolve looks up a doa, hadits or ayah and copies it into an unsaved favorite
+func (s *FavoriteService) Resolve(contentType, contentID string) (*models.Favorite, error) {
+	f := &models.Favorite{ContentType: contentType, ContentID: strings.TrimSpace(contentID)}
+
+	switch contentType {
+	case "doa":
+		all, err := s.Content.GetAllDoa()
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range all {
+			if d.ID == f.ContentID {
+				f.Title, f.Arab, f.Text = d.Judul, d.Arab, d.Indo
+				return f, nil
+			}
+		}
+	case "hadits":
+		n, err := strconv.Atoi(f.ContentID)
+		if err != nil {
+			return nil, ErrInvalidFavorite
+		}
+		h, err := s.Content.GetHaditsByNumber(n)
+		if err != nil {
+			return nil, err
+		}
+		if h != nil && h.No != "" {
+			f.ContentID = h.No
+			f.Title, f.Arab, f.Text = fmt.Sprintf("Hadits %s: %s", h.No, h.Judul), h.Arab, h.Indo
+			return f, nil
+		}
+	case "ayah":
+		surah, ayah, err := parseAyahRef(f.ContentID)
+		if err != nil {
+			return nil, ErrInvalidFavorite
+		}
+		ayat, err := s.Content.GetAyahBySurah(surah)
+		if err != nil {
+			return nil, err
+		}
+		name := fmt.Sprintf("Surah %d", surah)
+		if su, err := s.Content.GetSurahByID(surah); err == nil {
+			name = su.NameID
+		}
+		for _, a := range ayat {
+			if a.Ayah == strconv.Itoa(ayah) {
+				f.ContentID = fmt.Sprintf("%d:%d", surah, ayah)
+				f.Title, f.Arab, f.Text = fmt.Sprintf("%s: %d", name, ayah), a.Arab, a.Text
+				return f, nil
+			}
+		}
+	}
+	return nil, ErrInvalidFavorite
+}
+
+// Toggle saves the content as a favorite, or removes it when it already is
+// one. It reports whether the content is a favorite afterwards.
+func (s *FavoriteService) Toggle(userID int, contentType, contentID string) (bool, error) {
+	if existing, err := s.Repo.GetByContent(userID, contentType, strings.TrimSpace(contentID)); err == nil {
+		return false, s.Repo.Delete(userID, existing.ID)
+	}
+
+	f, err := s.Resolve(contentType, contentID)
+	if err != nil {
+		return false, err
+	}
+	f.UserID = userID
+	return true, s.Repo.Create(f)
+}
+
+// Move shifts a favorite one place up (-1) or down (+1) within its collection
+func (s *FavoriteService) Move(userID, id, step int) error {
+	f, err := s.Repo.GetByID(userID, id)
+	if err != nil {
+		return err
+	}
+	all, err := s.Repo.GetByUser(userID)
+	if err != nil {
+		return err
+	}
+
+	var list []*models.Favorite
+	for _, item := range all {
+		if item.CollectionID == f.CollectionID {
+			list = append(list, item)
+		}
+	}
+	for i, item := range list {
+		if item.ID != f.ID {
+			continue
+		}
+		j := i + step
+		if j < 0 || j >= len(list) {
+			return nil
+		}
+		other := list[j]
+		if other.Position == item.Position {
+			// Positions collide after older moves; spread them out first
+			other.Position = item.Position + step
+		}
+		return s.Repo.Swap(userID, item, other)
+	}
+	return nil
+}
+
+func (s *FavoriteService) SetCollection(userID, id, collectionID int) error {
+	if collectionID != 0 && !s.Repo.CollectionExists(userID, collectionID) {
+		return ErrCollectionNotFound
+	}
+	if _, err := s.Repo.GetByID(userID, id); err != nil {
+		return err
+	}
+	return s.Repo.SetCollection(userID, id, collectionID)
+}
+
+func (s *FavoriteService) CreateCollection(userID int, name string) (*models.FavoriteCollection, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > 100 {
+		return nil, ErrInvalidCollectionName
+	}
+	c := &models.FavoriteCollection{UserID: userID, Name: name}
+	if err := s.Repo.CreateCollection(c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (s *FavoriteService) DeleteCollection(userID, id int) error {
+	if err := s.Repo.DeleteCollection(userID, id); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrCollectionNotFound
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveFavorite(t *testing.T) {
+	s := NewFavoriteService(nil, &stubContent{doa: []Doa{
+		{ID: "a1", Judul: "Doa sahur", Arab: "نَوَيْتُ", Indo: "Aku berniat puasa"},
+		{ID: "b2", Judul: "Doa berbuka puasa", Arab: "ذَهَبَ الظَّمَأُ", Indo: "Telah hilang dahaga"},
+	}})
+
+	f, err := s.Resolve("doa", " b2 ")
+	require.NoError(t, err)
+	assert.Equal(t, "b2", f.ContentID)
+	assert.Equal(t, "Doa berbuka puasa", f.Title)
+	assert.Equal(t, "Telah hilang dahaga", f.Text)
+
+	_, err = s.Resolve("doa", "zz")
+	assert.Equal(t, ErrInvalidFavorite, err)
+	_, err = s.Resolve("hadits", "satu")
+	assert.Equal(t, ErrInvalidFavorite, err)
+	_, err = s.Resolve("ayah", "2:287")
+	assert.Equal(t, ErrInvalidFavorite, err)
+	_, err = s.Resolve("surah", "1")
+	assert.Equal(t, ErrInvalidFavorite, err)
+}
@@ -8,13 +8,20 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/ramadhan/amaliah-monitoring/internal/hijri"
 	"github.com/ramadhan/amaliah-monitoring/internal/models"
 )
 
 const baseURL = "https://equran.id/api/v2/imsakiyah"
 
 type ImsakiyahService struct {
-	client *http.Client
+	client     *http.Client
+	Cache      *ScheduleCache    // optional
+	Calculator *PrayerCalculator // optional; used when the API is unreachable
+	LocalFirst bool              // prefer Calculator over the API
+	// RamadhanStart overrides the first day of Ramadhan for calculated
+	// schedules; when zero it comes from the tabular Hijri calendar
+	RamadhanStart time.Time
 }
 
 func NewImsakiyahService() *ImsakiyahService {
@@ -26,6 +33,10 @@ func NewImsakiyahService() *ImsakiyahService {
 }
 
 func (s *ImsakiyahService) GetProvinsi() ([]string, error) {
+	return cachedJSON(s.Cache, "imsakiyah-provinsi:", locationCacheTTL, s.fetchProvinsi)
+}
+
+func (s *ImsakiyahService) fetchProvinsi() ([]string, error) {
 	resp, err := s.client.Get(baseURL + "/provinsi")
 	if err != nil {
 		return nil, err
@@ -41,11 +52,21 @@ func (s *ImsakiyahService) GetProvinsi() ([]string, error) {
 	if err := json.Unmarshal(body, &result); err != nil {
 		return nil, err
 	}
+	if len(result.Data) == 0 {
+		// Don't let an error body be cached as an empty list
+		return nil, fmt.Errorf("API error: %s", result.Message)
+	}
 
 	return result.Data, nil
 }
 
 func (s *ImsakiyahService) GetKabkota(provinsi string) ([]string, error) {
+	return cachedJSON(s.Cache, "imsakiyah-kabkota:"+provinsi, locationCacheTTL, func() ([]string, error) {
+		return s.fetchKabkota(provinsi)
+	})
+}
+
+func (s *ImsakiyahService) fetchKabkota(provinsi string) ([]string, error) {
 	payload := map[string]string{"provinsi": provinsi}
 	jsonPayload, _ := json.Marshal(payload)
 
@@ -64,11 +85,63 @@ func (s *ImsakiyahService) GetKabkota(provinsi string) ([]string, error) {
 	if err := json.Unmarshal(body, &result); err != nil {
 		return nil, err
 	}
+	if len(result.Data) == 0 {
+		// Don't let an error body be cached as an empty list
+		return nil, fmt.Errorf("API error: %s", result.Message)
+	}
 
 	return result.Data, nil
 }
 
-func (s *ImsakiyahService) GetImsakiyah(provinsi, kabkota string) (*models.ImsakiyahData, error) {
+// GetImsakiyah returns the table of the Ramadhan that offset, the school's
+// Hijri offset, puts around today
+func (s *ImsakiyahService) GetImsakiyah(provinsi, kabkota string, offset int) (*models.ImsakiyahData, error) {
+	if s.LocalFirst {
+		if data, err := s.calculate(provinsi, kabkota, offset); err == nil {
+			return data, nil
+		}
+	}
+
+	// The table changes every Hijri year, so a cached one must not outlive
+	// it, and schools with another offset may start Ramadhan on another day
+	key := fmt.Sprintf("imsakiyah:%d:%d:%s:%s", s.ramadhanYear(offset), offset, provinsi, kabkota)
+	data, err := cachedJSON(s.Cache, key, scheduleCacheTTL, func() (*models.ImsakiyahData, error) {
+		return s.fetchImsakiyah(provinsi, kabkota)
+	})
+	if err != nil && !s.LocalFirst {
+		if local, calcErr := s.calculate(provinsi, kabkota, offset); calcErr == nil {
+			return local, nil
+		}
+	}
+	return data, err
+}
+
+// ramadhanYear is the Hijri year of the Ramadhan the table is for
+func (s *ImsakiyahService) ramadhanYear(offset int) int {
+	if !s.RamadhanStart.IsZero() {
+		return hijri.FromTime(s.RamadhanStart, 0).Year
+	}
+	return hijri.RamadhanFor(time.Now(), offset).Year
+}
+
+func (s *ImsakiyahService) calculate(provinsi, kabkota string, offset int) (*models.ImsakiyahData, error) {
+	if s.Calculator == nil {
+		return nil, fmt.Errorf("jadwal imsakiyah lokal tidak tersedia")
+	}
+	ramadhan := hijri.RamadhanFor(time.Now(), offset)
+	start, days := ramadhan.Start, ramadhan.Days
+	if !s.RamadhanStart.IsZero() {
+		start, days = s.RamadhanStart, 30
+	}
+	data, err := s.Calculator.Imsakiyah(provinsi, kabkota, start, days)
+	if err != nil {
+		return nil, err
+	}
+	data.Hijriah = fmt.Sprint(hijri.FromTime(start, offset).Year)
+	return data, nil
+}
+
+func (s *ImsakiyahService) fetchImsakiyah(provinsi, kabkota string) (*models.ImsakiyahData, error) {
 	payload := map[string]string{
 		"provinsi": provinsi,
 		"kabkota":  kabkota,
@@ -98,17 +171,20 @@ func (s *ImsakiyahService) GetImsakiyah(provinsi, kabkota string) (*models.Imsak
 	return &result.Data, nil
 }
 
-func (s *ImsakiyahService) GetTodaySchedule(provinsi, kabkota string, dayOfMonth int) (*models.ImsakiyahSchedule, error) {
-	data, err := s.GetImsakiyah(provinsi, kabkota)
+// GetTodaySchedule looks up a row of the imsakiyah table, which is numbered
+// by day of Ramadhan (see hijri.RamadhanDay) with the same offset, not by day
+// of the month
+func (s *ImsakiyahService) GetTodaySchedule(provinsi, kabkota string, ramadhanDay, offset int) (*models.ImsakiyahSchedule, error) {
+	data, err := s.GetImsakiyah(provinsi, kabkota, offset)
 	if err != nil {
 		return nil, err
 	}
 
 	for _, schedule := range data.Imsakiyah {
-		if schedule.Tanggal == dayOfMonth {
+		if schedule.Tanggal == ramadhanDay {
 			return &schedule, nil
 		}
 	}
 
-	return nil, fmt.Errorf("schedule not found for day %d", dayOfMonth)
+	return nil, fmt.Errorf("schedule not found for Ramadhan day %d", ramadhanDay)
 }
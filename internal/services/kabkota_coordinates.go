@@ -0,0 +1,135 @@
+package services
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// kabkota.csv lists every kabupaten/kota with the coordinates of its seat of
+// government, named as in the equran.id schedule API. Regenerate with:
+//
+//	go run ./cmd/kabkotagen
+//
+//go:embed kabkota.csv
+var kabkotaCSV string
+
+type KabkotaLocation struct {
+	Provinsi string
+	Kabkota  string
+	Coordinates
+}
+
+var kabkotaTable = mustParseKabkota(kabkotaCSV)
+
+// kabkotaIndex maps lookup keys (see kabkotaKey) to kabkotaTable entries
+var kabkotaIndex = indexKabkota(kabkotaTable)
+
+func mustParseKabkota(data string) []KabkotaLocation {
+	rows, err := ParseKabkotaCSV(strings.NewReader(data))
+	if err != nil {
+		panic("kabkota.csv: " + err.Error())
+	}
+	return rows
+}
+
+// ParseKabkotaCSV reads the provinsi,kabkota,latitude,longitude,elevation format
+func ParseKabkotaCSV(r io.Reader) ([]KabkotaLocation, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []KabkotaLocation
+	for i, rec := range records {
+		if i == 0 || len(rec) < 4 {
+			continue // header
+		}
+		loc := KabkotaLocation{Provinsi: rec[0], Kabkota: rec[1]}
+		loc.Latitude, err = strconv.ParseFloat(rec[2], 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", i+1, err)
+		}
+		loc.Longitude, err = strconv.ParseFloat(rec[3], 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", i+1, err)
+		}
+		if len(rec) > 4 && rec[4] != "" {
+			loc.Elevation, _ = strconv.ParseFloat(rec[4], 64)
+		}
+		loc.TimeZone = ProvinceTimeZone(loc.Provinsi)
+		rows = append(rows, loc)
+	}
+	return rows, nil
+}
+
+func indexKabkota(rows []KabkotaLocation) map[string]int {
+	index := make(map[string]int, 2*len(rows))
+	for i, row := range rows {
+		index[strings.ToLower(row.Kabkota)] = i
+		index[kabkotaKey(row.Kabkota)] = i
+	}
+	return index
+}
+
+// kabkotaKey tolerates spelling differences such as "Kab. Tanjung Jabung
+// Barat" vs "KABUPATEN TANJUNG JABUNG BARAT" or "Palangkaraya" vs "Palangka Raya",
+// while keeping "Kota Bogor" and "Kab. Bogor" apart
+func kabkotaKey(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	kind := "kab"
+	if strings.HasPrefix(name, "kota") {
+		kind = "kota"
+	}
+	for _, prefix := range []string{"kabupaten ", "kab. ", "kab ", "kota administrasi ", "kota adm. ", "kota "} {
+		name = strings.TrimPrefix(name, prefix)
+	}
+	var b strings.Builder
+	for _, r := range name {
+		if r >= 'a' && r <= 'z' {
+			b.WriteRune(r)
+		}
+	}
+	return kind + ":" + b.String()
+}
+
+// Kabkotas returns the embedded dataset
+func Kabkotas() []KabkotaLocation {
+	return kabkotaTable
+}
+
+// LookupKabkota resolves an equran.id kabkota name to the coordinates of its seat
+func LookupKabkota(provinsi, kabkota string) (Coordinates, bool) {
+	i, ok := kabkotaIndex[strings.ToLower(strings.TrimSpace(kabkota))]
+	if !ok {
+		i, ok = kabkotaIndex[kabkotaKey(kabkota)]
+	}
+	if !ok {
+		return Coordinates{}, false
+	}
+	return kabkotaTable[i].Coordinates, true
+}
+
+// NearestKabkota returns the kabupaten/kota whose seat is closest to the point,
+// with the distance in kilometres
+func NearestKabkota(lat, lng float64) (KabkotaLocation, float64) {
+	best, bestDist := KabkotaLocation{}, math.Inf(1)
+	for _, row := range kabkotaTable {
+		if d := distanceKm(lat, lng, row.Latitude, row.Longitude); d < bestDist {
+			best, bestDist = row, d
+		}
+	}
+	return best, bestDist
+}
+
+// distanceKm is the haversine great-circle distance
+func distanceKm(lat1, lng1, lat2, lng2 float64) float64 {
+	dLat := (lat2 - lat1) * math.Pi / 180
+	dLng := (lng2 - lng1) * math.Pi / 180
+	a := math.Pow(math.Sin(dLat/2), 2) + dcos(lat1)*dcos(lat2)*math.Pow(math.Sin(dLng/2), 2)
+	return 2 * 6371 * math.Asin(math.Sqrt(a))
+}
@@ -0,0 +1,48 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/ramadhan/amaliah-monitoring/internal/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKabkotaDataset(t *testing.T) {
+	rows := services.Kabkotas()
+	assert.Len(t, rows, 514)
+
+	provinces := map[string]bool{}
+	for _, row := range rows {
+		provinces[row.Provinsi] = true
+		assert.True(t, row.Latitude > -11.5 && row.Latitude < 6.5, row.Kabkota)
+		assert.True(t, row.Longitude > 94.5 && row.Longitude < 141.5, row.Kabkota)
+	}
+	assert.Len(t, provinces, 38)
+}
+
+func TestNearestKabkota(t *testing.T) {
+	loc, dist := services.NearestKabkota(-6.9147, 107.6098) // Alun-alun Bandung
+	assert.Equal(t, "Kota Bandung", loc.Kabkota)
+	assert.Equal(t, "Jawa Barat", loc.Provinsi)
+	assert.Less(t, dist, 5.0)
+
+	loc, _ = services.NearestKabkota(-6.4850, 106.8420) // Cibinong
+	assert.Equal(t, "Kab. Bogor", loc.Kabkota)
+
+	loc, _ = services.NearestKabkota(-2.5330, 140.7100)
+	assert.Equal(t, 9.0, loc.TimeZone)
+
+	_, err := services.NewShalatService().ResolveLocation(35.68, 139.69) // Tokyo
+	assert.Error(t, err)
+}
+
+func TestLookupKabkotaSpelling(t *testing.T) {
+	a, ok := services.LookupKabkota("Kalimantan Tengah", "KOTA PALANGKARAYA")
+	assert.True(t, ok)
+	b, _ := services.LookupKabkota("Kalimantan Tengah", "Kota Palangka Raya")
+	assert.Equal(t, a, b)
+
+	kota, _ := services.LookupKabkota("Jawa Barat", "Kota Bogor")
+	kab, _ := services.LookupKabkota("Jawa Barat", "Kabupaten Bogor")
+	assert.NotEqual(t, kota, kab)
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+	"github.com/ramadhan/amaliah-monitoring/internal/mushaf"
+	"github.com/ramadhan/amaliah-monitoring/internal/repository"
+)
+
+// KhatamService tracks which ayat a student has actually read. Readings are
+// replayed oldest first into a merged ayah set; once every ayah is in it the
+// khatam is recorded and a fresh cycle begins with whatever the completing
+// reading covered past that point. Nothing is stored, so deleting a reading
+// is reflected immediately.
+type KhatamService struct {
+	QuranRepo *repository.QuranRepository
+}
+
+func NewKhatamService(quranRepo *repository.QuranRepository) *KhatamService {
+	return &KhatamService{QuranRepo: quranRepo}
+}
+
+type KhatamRecord struct {
+	Cycle       int    `json:"cycle"`
+	CompletedOn string `json:"completed_on"`
+}
+
+// PartCoverage is the coverage of one juz or surah in the current cycle
+type PartCoverage struct {
+	Number  int `json:"number"`
+	Covered int `json:"covered"`
+	Total   int `json:"total"`
+	Percent int `json:"percent"`
+}
+
+func (p PartCoverage) Done() bool {
+	return p.Covered == p.Total
+}
+
+type KhatamProgress struct {
+	// Cycle is the number of the khatam in progress, starting at 1
+	Cycle     int            `json:"cycle"`
+	Completed []KhatamRecord `json:"completed"`
+	// Covered counts the distinct ayat read in the current cycle
+	Covered int            `json:"covered"`
+	Percent int            `json:"percent"`
+	Juz     []PartCoverage `json:"juz"`
+	Surah   []PartCoverage `json:"surah"`
+	// JuzDone counts fully read juz in the current cycle, TotalJuzDone adds
+	// 30 for every completed khatam
+	JuzDone      int `json:"juz_done"`
+	TotalJuzDone int `json:"total_juz_done"`
+}
+
+func (s *KhatamService) Progress(userID int) (*KhatamProgress, error) {
+	readings, err := s.QuranRepo.GetAllByUserChronological(userID)
+	if err != nil {
+		return nil, err
+	}
+	return BuildKhatamProgress(readings), nil
+}
+
+// BuildKhatamProgress replays readings, which must be ordered oldest first.
+// Readings whose range does not exist in the mushaf are ignored.
+func BuildKhatamProgress(readings []*models.QuranReading) *KhatamProgress {
+	p := &KhatamProgress{}
+	set := &mushaf.Set{}
+
+	for _, r := range readings {
+		rng := ReadingRange(r)
+		if rng.Validate() != nil {
+			continue
+		}
+		span := rng.Span()
+		for span.From <= span.To {
+			inside := span.Len() - set.CountIn(span)
+			if inside == 0 || inside < mushaf.AyahCount-set.Len() {
+				set.Add(span.From, span.To)
+				break
+			}
+			// This span fills the last gaps: close the cycle at the last
+			// missing ayah and carry the rest of the span into the next one
+			last := set.LastMissing(span)
+			set.Add(span.From, last)
+			p.Completed = append(p.Completed, KhatamRecord{Cycle: len(p.Completed) + 1, CompletedOn: r.Date})
+			set = &mushaf.Set{}
+			span.From = last + 1
+		}
+	}
+
+	p.Cycle = len(p.Completed) + 1
+	p.Covered = set.Len()
+	p.Percent = p.Covered * 100 / mushaf.AyahCount
+
+	p.Juz = make([]PartCoverage, mushaf.JuzCount)
+	for n := 1; n <= mushaf.JuzCount; n++ {
+		p.Juz[n-1] = partCoverage(set, n, mushaf.JuzSpan(n))
+		if p.Juz[n-1].Done() {
+			p.JuzDone++
+		}
+	}
+	p.TotalJuzDone = len(p.Completed)*mushaf.JuzCount + p.JuzDone
+
+	p.Surah = make([]PartCoverage, mushaf.SurahCount)
+	for n := 1; n <= mushaf.SurahCount; n++ {
+		p.Surah[n-1] = partCoverage(set, n, mushaf.SurahSpan(n))
+	}
+	return p
+}
+
+func partCoverage(set *mushaf.Set, number int, span mushaf.Span) PartCoverage {
+	covered := set.CountIn(span)
+	return PartCoverage{
+		Number:  number,
+		Covered: covered,
+		Total:   span.Len(),
+		Percent: covered * 100 / span.Len(),
+	}
+}
@@ -0,0 +1,58 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+	"github.com/ramadhan/amaliah-monitoring/internal/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func reading(date string, startSurah, startAyah, endSurah, endAyah int) *models.QuranReading {
+	return &models.QuranReading{Date: date, StartSurahID: startSurah, StartAyah: startAyah, EndSurahID: endSurah, EndAyah: endAyah}
+}
+
+func TestKhatamRereadingDoesNotCount(t *testing.T) {
+	var readings []*models.QuranReading
+	for i := 0; i < 600; i++ {
+		readings = append(readings, reading("2025-03-01", 1, 1, 1, 7))
+	}
+	p := services.BuildKhatamProgress(readings)
+
+	assert.Equal(t, 1, p.Cycle)
+	assert.Empty(t, p.Completed)
+	assert.Equal(t, 7, p.Covered)
+	assert.Equal(t, 100, p.Surah[0].Percent)
+	assert.Equal(t, 0, p.JuzDone)
+}
+
+func TestKhatamCompletesAndStartsNewCycle(t *testing.T) {
+	p := services.BuildKhatamProgress([]*models.QuranReading{
+		reading("2025-03-01", 1, 1, 2, 141), // juz 1
+		reading("2025-03-02", 2, 142, 77, 50),
+		reading("2025-03-03", 2, 100, 2, 120), // reread
+		// finishes juz 30 and carries on into the next khatam, logged as two
+		reading("2025-03-04", 78, 1, 114, 6),
+		reading("2025-03-04", 1, 1, 2, 5),
+	})
+
+	assert.Equal(t, []services.KhatamRecord{{Cycle: 1, CompletedOn: "2025-03-04"}}, p.Completed)
+	assert.Equal(t, 2, p.Cycle)
+	assert.Equal(t, 7+5, p.Covered)
+	assert.Equal(t, 0, p.JuzDone)
+	assert.Equal(t, 30, p.TotalJuzDone)
+}
+
+func TestKhatamJuzDone(t *testing.T) {
+	p := services.BuildKhatamProgress([]*models.QuranReading{
+		reading("2025-03-01", 78, 1, 114, 6),
+		reading("2025-03-02", 2, 142, 2, 200),
+		reading("2025-03-03", 2, 201, 2, 252),
+		reading("2025-03-04", 2, 300, 2, 1), // invalid, ignored
+	})
+
+	assert.Equal(t, 2, p.JuzDone)
+	assert.True(t, p.Juz[1].Done())
+	assert.True(t, p.Juz[29].Done())
+	assert.False(t, p.Juz[0].Done())
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"time"
+
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+	"github.com/ramadhan/amaliah-monitoring/internal/repository"
+)
+
+type LiveDashboardService struct {
+	Bus         *EventBus
+	UserRepo    *repository.UserRepository
+	PrayerRepo  *repository.PrayerRepository
+	FastingRepo *repository.FastingRepository
+	QuranRepo   *repository.QuranRepository
+	AmaliahRepo *repository.AmaliahRepository
+}
+
+func NewLiveDashboardService(
+	bus *EventBus,
+	userRepo *repository.UserRepository,
+	prayerRepo *repository.PrayerRepository,
+	fastingRepo *repository.FastingRepository,
+	quranRepo *repository.QuranRepository,
+	amaliahRepo *repository.AmaliahRepository,
+) *LiveDashboardService {
+	return &LiveDashboardService{
+		Bus:         bus,
+		UserRepo:    userRepo,
+		PrayerRepo:  prayerRepo,
+		FastingRepo: fastingRepo,
+		QuranRepo:   quranRepo,
+		AmaliahRepo: amaliahRepo,
+	}
+}
+
+// Record publishes an activity event for a student's save
+func (s *LiveDashboardService) Record(user *models.User, kind, summary string) {
+	s.Bus.Publish(models.ActivityEvent{
+		Kind:     kind,
+		UserID:   user.ID,
+		SchoolID: user.SchoolID,
+		FullName: user.FullName,
+		Class:    user.Class,
+		Summary:  summary,
+		At:       time.Now(),
+	})
+}
+
+// Snapshot builds today's counters for one school (0 for every school)
+func (s *LiveDashboardService) Snapshot(schoolID int) (*models.LiveDashboard, error) {
+	today := time.Now().Format("2006-01-02")
+	snap := &models.LiveDashboard{Date: today, GeneratedAt: time.Now()}
+
+	var err error
+	if snap.TotalStudents, err = s.UserRepo.CountStudents(schoolID); err != nil {
+		return nil, err
+	}
+	if snap.ActiveUsers, err = s.UserRepo.GetActiveUsersCountBySchool(today, schoolID); err != nil {
+		return nil, err
+	}
+
+	prayerStats, err := s.PrayerRepo.GetTodayStatsBySchool(today, schoolID)
+	if err != nil {
+		return nil, err
+	}
+	snap.PrayerUsers = prayerStats["total_users"]
+
+	fastingStats, err := s.FastingRepo.GetTodayStatsBySchool(today, schoolID)
+	if err != nil {
+		return nil, err
+	}
+	snap.FastingUsers = fastingStats["total_users"]
+	snap.Fasting = fastingStats["fasting"]
+
+	quranStats, err := s.QuranRepo.GetTodayStatsBySchool(today, schoolID)
+	if err != nil {
+		return nil, err
+	}
+	snap.QuranUsers = quranStats["total_users"].(int)
+	snap.QuranReadings = quranStats["total_readings"].(int)
+
+	amaliahStats, err := s.AmaliahRepo.GetTodayStatsBySchool(today, schoolID)
+	if err != nil {
+		return nil, err
+	}
+	snap.AmaliahUsers = amaliahStats["total_users"].(int)
+	snap.AmaliahPoints = amaliahStats["total_points"].(int)
+
+	if snap.Leaderboard, err = s.AmaliahRepo.GetLeaderboardBySchool(schoolID, 5); err != nil {
+		return nil, err
+	}
+	snap.RecentActivity = s.Bus.Recent(schoolID, 10)
+
+	return snap, nil
+}
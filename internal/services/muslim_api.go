@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	neturl "net/url"
 	"time"
 )
 
@@ -95,7 +96,7 @@ func (s *MuslimAPIService) GetAllDoa() ([]Doa, error) {
 func (s *MuslimAPIService) GetDoaBySource(source string) ([]Doa, error) {
 	url := BaseURL + "/v1/doa"
 	if source != "" {
-		url = fmt.Sprintf("%s?source=%s", url, source)
+		url = fmt.Sprintf("%s?source=%s", url, neturl.QueryEscape(source))
 	}
 
 	resp, err := s.client.Get(url)
@@ -118,7 +119,7 @@ func (s *MuslimAPIService) GetDoaBySource(source string) ([]Doa, error) {
 }
 
 func (s *MuslimAPIService) SearchDoa(query string) ([]Doa, error) {
-	url := fmt.Sprintf("%s/v1/doa/find?query=%s", BaseURL, query)
+	url := fmt.Sprintf("%s/v1/doa/find?query=%s", BaseURL, neturl.QueryEscape(query))
 
 	resp, err := s.client.Get(url)
 	if err != nil {
@@ -183,7 +184,7 @@ func (s *MuslimAPIService) GetHaditsByNumber(nomor int) (*Hadits, error) {
 }
 
 func (s *MuslimAPIService) SearchHadits(query string) ([]Hadits, error) {
-	url := fmt.Sprintf("%s/v1/hadits/find?query=%s", BaseURL, query)
+	url := fmt.Sprintf("%s/v1/hadits/find?query=%s", BaseURL, neturl.QueryEscape(query))
 
 	resp, err := s.client.Get(url)
 	if err != nil {
@@ -0,0 +1,292 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+)
+
+// Coordinates locates a kabupaten/kota for prayer-time calculation
+type Coordinates struct {
+	Latitude  float64
+	Longitude float64
+	Elevation float64 // metres above sea level
+	TimeZone  float64 // hours from UTC: 7 WIB, 8 WITA, 9 WIT
+}
+
+// CoordinateLookup resolves an equran.id provinsi/kabkota pair to coordinates
+type CoordinateLookup func(provinsi, kabkota string) (Coordinates, bool)
+
+// PrayerCalcParams are the angles and adjustments used by Kemenag RI
+type PrayerCalcParams struct {
+	FajrAngle   float64 // sun depression at Subuh
+	IshaAngle   float64 // sun depression at Isya
+	DhuhaAngle  float64 // sun altitude at the start of Dhuha
+	AsrFactor   float64 // shadow length factor, 1 = Syafi'i
+	Ihtiyat     int     // safety minutes added to each time (subtracted from Terbit)
+	ImsakOffset int     // minutes before Subuh
+}
+
+var KemenagParams = PrayerCalcParams{
+	FajrAngle:   20,
+	IshaAngle:   18,
+	DhuhaAngle:  4.5,
+	AsrFactor:   1,
+	Ihtiyat:     2,
+	ImsakOffset: 10,
+}
+
+type PrayerCalculator struct {
+	Params PrayerCalcParams
+	Locate CoordinateLookup
+}
+
+func NewPrayerCalculator(locate CoordinateLookup) *PrayerCalculator {
+	return &PrayerCalculator{Params: KemenagParams, Locate: locate}
+}
+
+// LocalPrayerTimesFirst reports whether PRAYER_TIME_SOURCE=local asks for
+// calculated times ahead of the equran.id API
+func LocalPrayerTimesFirst() bool {
+	return strings.EqualFold(os.Getenv("PRAYER_TIME_SOURCE"), "local")
+}
+
+// RamadhanStartFromEnv reads RAMADHAN_START (YYYY-MM-DD), which overrides the
+// Hijri calendar when the imsakiyah schedule has to be calculated locally
+func RamadhanStartFromEnv() time.Time {
+	start, err := time.Parse("2006-01-02", os.Getenv("RAMADHAN_START"))
+	if err != nil {
+		return time.Time{}
+	}
+	return start
+}
+
+var hariNames = []string{"Minggu", "Senin", "Selasa", "Rabu", "Kamis", "Jumat", "Sabtu"}
+var bulanNames = []string{"Januari", "Februari", "Maret", "April", "Mei", "Juni", "Juli", "Agustus", "September", "Oktober", "November", "Desember"}
+
+// Shalat calculates a monthly schedule in the same shape as the equran.id API
+func (pc *PrayerCalculator) Shalat(provinsi, kabkota string, bulan, tahun int) (*models.ShalatData, error) {
+	coord, err := pc.locate(provinsi, kabkota)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &models.ShalatData{
+		Provinsi:  provinsi,
+		Kabkota:   kabkota,
+		Bulan:     bulan,
+		Tahun:     tahun,
+		BulanNama: bulanNames[bulan-1],
+	}
+	day := time.Date(tahun, time.Month(bulan), 1, 0, 0, 0, 0, time.UTC)
+	for day.Month() == time.Month(bulan) {
+		data.Jadwal = append(data.Jadwal, pc.Day(day, coord))
+		day = day.AddDate(0, 0, 1)
+	}
+	return data, nil
+}
+
+// Imsakiyah calculates `days` consecutive schedules starting at start (Ramadhan day 1)
+func (pc *PrayerCalculator) Imsakiyah(provinsi, kabkota string, start time.Time, days int) (*models.ImsakiyahData, error) {
+	coord, err := pc.locate(provinsi, kabkota)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &models.ImsakiyahData{
+		Provinsi: provinsi,
+		Kabkota:  kabkota,
+		Masehi:   fmt.Sprint(start.Year()),
+	}
+	for i := 0; i < days; i++ {
+		s := pc.Day(start.AddDate(0, 0, i), coord)
+		data.Imsakiyah = append(data.Imsakiyah, models.ImsakiyahSchedule{
+			Tanggal: i + 1,
+			Imsak:   s.Imsak,
+			Subuh:   s.Subuh,
+			Terbit:  s.Terbit,
+			Dhuha:   s.Dhuha,
+			Dzuhur:  s.Dzuhur,
+			Ashar:   s.Ashar,
+			Maghrib: s.Maghrib,
+			Isya:    s.Isya,
+		})
+	}
+	return data, nil
+}
+
+func (pc *PrayerCalculator) locate(provinsi, kabkota string) (Coordinates, error) {
+	if pc.Locate == nil {
+		return Coordinates{}, fmt.Errorf("koordinat %s tidak diketahui", kabkota)
+	}
+	coord, ok := pc.Locate(provinsi, kabkota)
+	if !ok {
+		return Coordinates{}, fmt.Errorf("koordinat %s tidak diketahui", kabkota)
+	}
+	if coord.TimeZone == 0 {
+		coord.TimeZone = ProvinceTimeZone(provinsi)
+	}
+	return coord, nil
+}
+
+// Day calculates the schedule for one date. Only the year, month and day of date are used.
+func (pc *PrayerCalculator) Day(date time.Time, coord Coordinates) models.ShalatSchedule {
+	p := pc.Params
+	jd := julianDate(date.Year(), int(date.Month()), date.Day()) - coord.Longitude/(15*24)
+	lat := coord.Latitude
+
+	// Sunrise/sunset: refraction + solar semi-diameter, plus horizon dip for elevation
+	riseSetAngle := 0.833 + 0.0347*math.Sqrt(math.Max(coord.Elevation, 0))
+
+	// Local solar times (hours), each evaluated at its approximate time of day
+	fajr := sunAngleTime(jd, lat, p.FajrAngle, 5.0/24, true)
+	sunrise := sunAngleTime(jd, lat, riseSetAngle, 6.0/24, true)
+	dhuha := sunAngleTime(jd, lat, -p.DhuhaAngle, 6.5/24, true)
+	dhuhr := midDay(jd, 12.0/24)
+	asr := asrTime(jd, lat, p.AsrFactor, 13.0/24)
+	sunset := sunAngleTime(jd, lat, riseSetAngle, 18.0/24, false)
+	isha := sunAngleTime(jd, lat, p.IshaAngle, 18.0/24, false)
+
+	toLocal := func(t float64) float64 { return t + coord.TimeZone - coord.Longitude/15 }
+	ihtiyat := float64(p.Ihtiyat) / 60
+
+	subuh := toLocal(fajr) + ihtiyat
+
+	return models.ShalatSchedule{
+		Tanggal:        date.Day(),
+		TanggalLengkap: date.Format("2006-01-02"),
+		Hari:           hariNames[date.Weekday()],
+		Imsak:          formatClock(subuh-float64(p.ImsakOffset)/60, true),
+		Subuh:          formatClock(subuh, true),
+		Terbit:         formatClock(toLocal(sunrise)-ihtiyat, false),
+		Dhuha:          formatClock(toLocal(dhuha)+ihtiyat, true),
+		Dzuhur:         formatClock(toLocal(dhuhr)+ihtiyat, true),
+		Ashar:          formatClock(toLocal(asr)+ihtiyat, true),
+		Maghrib:        formatClock(toLocal(sunset)+ihtiyat, true),
+		Isya:           formatClock(toLocal(isha)+ihtiyat, true),
+	}
+}
+
+// ProvinceTimeZone returns the UTC offset of an Indonesian province
+func ProvinceTimeZone(provinsi string) float64 {
+	p := strings.ToLower(provinsi)
+	switch {
+	case strings.Contains(p, "maluku"), strings.Contains(p, "papua"):
+		return 9
+	case strings.Contains(p, "bali"), strings.Contains(p, "nusa tenggara"),
+		strings.Contains(p, "sulawesi"), strings.Contains(p, "gorontalo"),
+		strings.Contains(p, "kalimantan selatan"), strings.Contains(p, "kalimantan timur"),
+		strings.Contains(p, "kalimantan utara"):
+		return 8
+	}
+	return 7
+}
+
+// indonesianZones maps the UTC offsets of ProvinceTimeZone to their zones.
+// Without the tz database the fixed offsets are used; none of them has DST.
+var indonesianZones = map[float64]*time.Location{
+	7: loadZone("Asia/Jakarta", "WIB", 7),
+	8: loadZone("Asia/Makassar", "WITA", 8),
+	9: loadZone("Asia/Jayapura", "WIT", 9),
+}
+
+func loadZone(name, abbr string, hours int) *time.Location {
+	if loc, err := time.LoadLocation(name); err == nil {
+		return loc
+	}
+	return time.FixedZone(abbr, hours*3600)
+}
+
+// KabkotaZone returns the time zone of a kabupaten/kota: Asia/Jakarta,
+// Asia/Makassar or Asia/Jayapura
+func KabkotaZone(provinsi, kabkota string) *time.Location {
+	offset := ProvinceTimeZone(provinsi)
+	if coord, ok := LookupKabkota(provinsi, kabkota); ok && coord.TimeZone != 0 {
+		offset = coord.TimeZone
+	}
+	if loc, ok := indonesianZones[offset]; ok {
+		return loc
+	}
+	return indonesianZones[7]
+}
+
+// formatClock renders fractional hours as HH:MM, rounding up (or down) to the minute
+func formatClock(hours float64, roundUp bool) string {
+	minutes := hours * 60
+	if roundUp {
+		minutes = math.Ceil(minutes - 1e-9)
+	} else {
+		minutes = math.Floor(minutes + 1e-9)
+	}
+	m := int(minutes) % (24 * 60)
+	if m < 0 {
+		m += 24 * 60
+	}
+	return fmt.Sprintf("%02d:%02d", m/60, m%60)
+}
+
+// Solar position after the low-precision algorithm of the U.S. Naval Observatory,
+// as used by most prayer-time programs (accurate to about a minute).
+
+func julianDate(year, month, day int) float64 {
+	if month <= 2 {
+		year--
+		month += 12
+	}
+	a := math.Floor(float64(year) / 100)
+	b := 2 - a + math.Floor(a/4)
+	return math.Floor(365.25*float64(year+4716)) + math.Floor(30.6001*float64(month+1)) + float64(day) + b - 1524.5
+}
+
+// sunPosition returns the declination (degrees) and equation of time (hours)
+func sunPosition(jd float64) (float64, float64) {
+	d := jd - 2451545.0
+	g := fixAngle(357.529 + 0.98560028*d)
+	q := fixAngle(280.459 + 0.98564736*d)
+	l := fixAngle(q + 1.915*dsin(g) + 0.020*dsin(2*g))
+	e := 23.439 - 0.00000036*d
+
+	ra := darctan2(dcos(e)*dsin(l), dcos(l)) / 15
+	eqt := q/15 - fixHour(ra)
+	decl := darcsin(dsin(e) * dsin(l))
+	return decl, eqt
+}
+
+func midDay(jd, t float64) float64 {
+	_, eqt := sunPosition(jd + t)
+	return fixHour(12 - eqt)
+}
+
+// sunAngleTime is the time the sun is `angle` degrees below the horizon,
+// before noon when ccw is true
+func sunAngleTime(jd, lat, angle, t float64, ccw bool) float64 {
+	decl, _ := sunPosition(jd + t)
+	noon := midDay(jd, t)
+	cosH := (-dsin(angle) - dsin(decl)*dsin(lat)) / (dcos(decl) * dcos(lat))
+	h := darccos(math.Max(-1, math.Min(1, cosH))) / 15
+	if ccw {
+		return noon - h
+	}
+	return noon + h
+}
+
+func asrTime(jd, lat, factor, t float64) float64 {
+	decl, _ := sunPosition(jd + t)
+	angle := -darccot(factor + dtan(math.Abs(lat-decl)))
+	return sunAngleTime(jd, lat, angle, t, false)
+}
+
+func dsin(d float64) float64        { return math.Sin(d * math.Pi / 180) }
+func dcos(d float64) float64        { return math.Cos(d * math.Pi / 180) }
+func dtan(d float64) float64        { return math.Tan(d * math.Pi / 180) }
+func darcsin(x float64) float64     { return math.Asin(x) * 180 / math.Pi }
+func darccos(x float64) float64     { return math.Acos(x) * 180 / math.Pi }
+func darccot(x float64) float64     { return math.Atan(1/x) * 180 / math.Pi }
+func darctan2(y, x float64) float64 { return math.Atan2(y, x) * 180 / math.Pi }
+
+func fixAngle(a float64) float64 { return a - 360*math.Floor(a/360) }
+func fixHour(a float64) float64  { return a - 24*math.Floor(a/24) }
@@ -0,0 +1,201 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+	"github.com/ramadhan/amaliah-monitoring/internal/repository"
+)
+
+// PrayerNames lists the five daily prayers in order
+var PrayerNames = []string{"subuh", "dzuhur", "ashar", "maghrib", "isya"}
+
+// PrayerTimings lists the classifications of a prayer marked done
+var PrayerTimings = []struct {
+	Value string
+	Label string
+}{
+	{"on_time", "Tepat waktu"},
+	{"late", "Akhir waktu"},
+	{"outside_window", "Di luar waktu"},
+}
+
+// PrayerOnTimeLimit caps how long after a window opens a prayer still counts
+// as on time; short windows such as Maghrib use their first half instead
+const PrayerOnTimeLimit = time.Hour
+
+var ErrPrayerFutureDate = errors.New("shalat untuk tanggal yang akan datang belum bisa diisi")
+
+// PrayerTooEarlyError rejects marking a prayer done before its window opens
+type PrayerTooEarlyError struct {
+	Prayer string
+	Start  time.Time
+}
+
+func (e *PrayerTooEarlyError) Error() string {
+	return fmt.Sprintf("waktu shalat %s belum masuk (mulai pukul %s)", e.Prayer, e.Start.Format("15:04"))
+}
+
+func PrayerTimingLabel(timing string) string {
+	for _, t := range PrayerTimings {
+		if t.Value == timing {
+			return t.Label
+		}
+	}
+	return ""
+}
+
+// PrayerStatuses returns the status of each prayer; a nil prayer is all belum
+func PrayerStatuses(p *models.Prayer) map[string]models.PrayerStatus {
+	if p == nil {
+		p = &models.Prayer{Subuh: models.PrayerPending, Dzuhur: models.PrayerPending, Ashar: models.PrayerPending, Maghrib: models.PrayerPending, Isya: models.PrayerPending}
+	}
+	return map[string]models.PrayerStatus{"subuh": p.Subuh, "dzuhur": p.Dzuhur, "ashar": p.Ashar, "maghrib": p.Maghrib, "isya": p.Isya}
+}
+
+// PrayerWindow is the time a prayer may be performed in
+type PrayerWindow struct {
+	Start time.Time
+	End   time.Time
+}
+
+// PrayerWindows derives each prayer's window from the schedule of day: a
+// prayer lasts until the next one begins, Subuh until sunrise and Isya until
+// Subuh of the following day.
+func PrayerWindows(day time.Time, s models.ShalatSchedule) (map[string]PrayerWindow, error) {
+	clock := func(v string) (time.Time, error) {
+		t, err := time.ParseInLocation("15:04", v, day.Location())
+		if err != nil {
+			return time.Time{}, fmt.Errorf("jam jadwal tidak valid: %q", v)
+		}
+		return time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), 0, 0, day.Location()), nil
+	}
+
+	times := make([]time.Time, 0, 6)
+	for _, v := range []string{s.Subuh, s.Terbit, s.Dzuhur, s.Ashar, s.Maghrib, s.Isya} {
+		t, err := clock(v)
+		if err != nil {
+			return nil, err
+		}
+		times = append(times, t)
+	}
+	subuh, terbit, dzuhur, ashar, maghrib, isya := times[0], times[1], times[2], times[3], times[4], times[5]
+
+	return map[string]PrayerWindow{
+		"subuh":   {subuh, terbit},
+		"dzuhur":  {dzuhur, ashar},
+		"ashar":   {ashar, maghrib},
+		"maghrib": {maghrib, isya},
+		"isya":    {isya, subuh.AddDate(0, 0, 1)},
+	}, nil
+}
+
+// ClassifyPrayerTime places at within w. The caller rejects times before the
+// window opens.
+func ClassifyPrayerTime(w PrayerWindow, at time.Time) string {
+	onTime := w.End.Sub(w.Start) / 2
+	if onTime > PrayerOnTimeLimit {
+		onTime = PrayerOnTimeLimit
+	}
+	switch {
+	case at.Before(w.Start.Add(onTime)):
+		return "on_time"
+	case at.Before(w.End):
+		return "late"
+	}
+	return "outside_window"
+}
+
+// UserLocation returns the time zone of the user's kabupaten/kota, or the
+// server's zone when the user has no location
+func UserLocation(user *models.User) *time.Location {
+	if user == nil || user.Provinsi == "" || user.Kabkota == "" {
+		return time.Local
+	}
+	return KabkotaZone(user.Provinsi, user.Kabkota)
+}
+
+type PrayerTimingService struct {
+	Repo   *repository.PrayerTimingRepository
+	Shalat *ShalatService
+}
+
+func NewPrayerTimingService(repo *repository.PrayerTimingRepository, shalat *ShalatService) *PrayerTimingService {
+	return &PrayerTimingService{Repo: repo, Shalat: shalat}
+}
+
+// Windows returns the user's prayer windows for date in the time zone of
+// their kabupaten/kota, or nil when the user has no location or the schedule
+// cannot be loaded
+func (s *PrayerTimingService) Windows(user *models.User, date string) map[string]PrayerWindow {
+	if user == nil || user.Provinsi == "" || user.Kabkota == "" {
+		return nil
+	}
+	day, err := time.ParseInLocation("2006-01-02", date, UserLocation(user))
+	if err != nil {
+		return nil
+	}
+	schedule, err := s.Shalat.GetSchedule(user.Provinsi, user.Kabkota, day)
+	if err != nil {
+		return nil
+	}
+	windows, err := PrayerWindows(day, *schedule)
+	if err != nil {
+		return nil
+	}
+	return windows
+}
+
+// Evaluate checks a change of the prayers on date made at the given time and
+// returns a timing for every prayer whose status changed. Marking a prayer
+// done before its window opens is an error; without a schedule only future
+// dates are refused and the change is left unclassified.
+func (s *PrayerTimingService) Evaluate(user *models.User, date string, before, after *models.Prayer, at time.Time) ([]*models.PrayerTiming, error) {
+	old, updated := PrayerStatuses(before), PrayerStatuses(after)
+
+	var changed []string
+	for _, name := range PrayerNames {
+		if updated[name] != old[name] {
+			changed = append(changed, name)
+		}
+	}
+	if len(changed) == 0 {
+		return nil, nil
+	}
+
+	var windows map[string]PrayerWindow
+	for _, name := range changed {
+		if updated[name].Done() {
+			windows = s.Windows(user, date)
+			break
+		}
+	}
+
+	timings := make([]*models.PrayerTiming, 0, len(changed))
+	for _, name := range changed {
+		t := &models.PrayerTiming{UserID: user.ID, Date: date, Prayer: name, Status: updated[name], MarkedAt: at}
+		if t.Status.Done() {
+			if w, ok := windows[name]; ok {
+				if at.Before(w.Start) {
+					return nil, &PrayerTooEarlyError{Prayer: name, Start: w.Start}
+				}
+				t.Timing = ClassifyPrayerTime(w, at)
+			} else if date > at.In(UserLocation(user)).Format("2006-01-02") {
+				return nil, ErrPrayerFutureDate
+			}
+		}
+		timings = append(timings, t)
+	}
+	return timings, nil
+}
+
+func (s *PrayerTimingService) Record(timings []*models.PrayerTiming) error {
+	for _, t := range timings {
+		if err := s.Repo.Save(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+	"github.com/ramadhan/amaliah-monitoring/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyPrayerTime(t *testing.T) {
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local)
+	windows, err := services.PrayerWindows(day, models.ShalatSchedule{
+		Subuh: "04:40", Terbit: "05:55", Dzuhur: "12:05", Ashar: "15:15", Maghrib: "18:10", Isya: "19:20",
+	})
+	require.NoError(t, err)
+
+	at := func(clock string) time.Time {
+		c, _ := time.Parse("15:04", clock)
+		return time.Date(2026, 3, 1, c.Hour(), c.Minute(), 0, 0, time.Local)
+	}
+
+	// Dzuhur's long window is on time for the first hour only
+	assert.Equal(t, "on_time", services.ClassifyPrayerTime(windows["dzuhur"], at("13:00")))
+	assert.Equal(t, "late", services.ClassifyPrayerTime(windows["dzuhur"], at("13:10")))
+	assert.Equal(t, "outside_window", services.ClassifyPrayerTime(windows["dzuhur"], at("15:20")))
+
+	// Maghrib lasts 70 minutes, so only its first 35 count
+	assert.Equal(t, "on_time", services.ClassifyPrayerTime(windows["maghrib"], at("18:40")))
+	assert.Equal(t, "late", services.ClassifyPrayerTime(windows["maghrib"], at("18:50")))
+
+	// Isya runs past midnight until the next Subuh
+	assert.Equal(t, "late", services.ClassifyPrayerTime(windows["isya"], at("23:30")))
+	assert.Equal(t, at("04:40").AddDate(0, 0, 1), windows["isya"].End)
+
+	_, err = services.PrayerWindows(day, models.ShalatSchedule{Subuh: "-"})
+	assert.Error(t, err)
+}
+
+func TestEvaluatePrayerChange(t *testing.T) {
+	s := &services.PrayerTimingService{}
+	user := &models.User{ID: 7}
+	now := time.Now()
+	today := now.Format("2006-01-02")
+
+	before := &models.Prayer{Subuh: "jamaah", Dzuhur: "belum", Ashar: "belum", Maghrib: "belum", Isya: "belum"}
+	after := &models.Prayer{Subuh: "jamaah", Dzuhur: "sendiri", Ashar: "tidak", Maghrib: "belum", Isya: "belum"}
+
+	// Without a location only the changed prayers are recorded, unclassified
+	timings, err := s.Evaluate(user, today, before, after, now)
+	require.NoError(t, err)
+	require.Len(t, timings, 2)
+	assert.Equal(t, "dzuhur", timings[0].Prayer)
+	assert.Equal(t, "", timings[0].Timing)
+	assert.Equal(t, models.PrayerMissed, timings[1].Status)
+
+	tomorrow := now.AddDate(0, 0, 1).Format("2006-01-02")
+	_, err = s.Evaluate(user, tomorrow, nil, after, now)
+	assert.Equal(t, services.ErrPrayerFutureDate, err)
+}
+
+func TestPrayerWindowsInKabkotaZone(t *testing.T) {
+	assert.Equal(t, 7*3600, zoneOffset(services.KabkotaZone("DKI Jakarta", "Kota Jakarta Pusat")))
+	assert.Equal(t, 8*3600, zoneOffset(services.KabkotaZone("Sulawesi Selatan", "Kota Makassar")))
+	assert.Equal(t, 9*3600, zoneOffset(services.KabkotaZone("Papua", "Kota Jayapura")))
+
+	// The same clock is an hour earlier in Makassar than in Jakarta
+	schedule := models.ShalatSchedule{Subuh: "04:30", Terbit: "05:45", Dzuhur: "12:00", Ashar: "15:15", Maghrib: "18:00", Isya: "19:10"}
+	wib, err := services.PrayerWindows(time.Date(2026, 3, 1, 0, 0, 0, 0, services.KabkotaZone("DKI Jakarta", "Kota Jakarta Pusat")), schedule)
+	require.NoError(t, err)
+	wita, err := services.PrayerWindows(time.Date(2026, 3, 1, 0, 0, 0, 0, services.KabkotaZone("Sulawesi Selatan", "Kota Makassar")), schedule)
+	require.NoError(t, err)
+	assert.Equal(t, time.Hour, wib["maghrib"].Start.Sub(wita["maghrib"].Start))
+}
+
+func zoneOffset(loc *time.Location) int {
+	_, offset := time.Date(2026, 3, 1, 12, 0, 0, 0, loc).Zone()
+	return offset
+}
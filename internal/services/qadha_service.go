@@ -0,0 +1,364 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ramadhan/amaliah-monitoring/internal/hijri"
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+	"github.com/ramadhan/amaliah-monitoring/internal/repository"
+)
+
+// QadhaReasons groups the reasons for not fasting. Every missed day has to be
+// made up, except chronic illness without hope of recovery which is paid
+// with fidyah instead.
+var QadhaReasons = []struct {
+	Value  string
+	Label  string
+	Fidyah bool
+}{
+	{"sakit", "Sakit", false},
+	{"perjalanan", "Perjalanan", false},
+	{"haid", "Haid", false},
+	{"nifas", "Nifas", false},
+	{"sakit_menahun", "Sakit Menahun", true},
+	{"lainnya", "Lainnya", false},
+}
+
+// FidyahGramsPerDay is one mud of staple food, the fidyah for one day
+const FidyahGramsPerDay = 675
+
+var (
+	ErrQadhaInRamadhan   = errors.New("puasa qadha dilakukan di luar bulan Ramadhan")
+	ErrQadhaForbiddenDay = errors.New("tanggal ini termasuk hari yang diharamkan berpuasa")
+	ErrQadhaExcused      = errors.New("tanggal ini tercatat udzur")
+	ErrQadhaDuplicate    = errors.New("puasa qadha tanggal ini sudah dicatat")
+	ErrQadhaNoDebt       = errors.New("tidak ada hutang puasa yang bisa diganti pada tanggal ini")
+)
+
+// QadhaReason maps a free-text fasting reason onto one of QadhaReasons;
+// anything unknown counts as lainnya
+func QadhaReason(reason string) string {
+	r := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(reason)), " ", "_")
+	for _, q := range QadhaReasons {
+		if q.Value == r {
+			return r
+		}
+	}
+	return "lainnya"
+}
+
+// ForbiddenFastingDay reports the days no fast is allowed on: Idul Fitri,
+// Idul Adha and the three tasyrik days
+func ForbiddenFastingDay(t time.Time, offset int) bool {
+	d := hijri.FromTime(t, offset)
+	return (d.Month == 10 && d.Day == 1) || (d.Month == 12 && d.Day >= 10 && d.Day <= 13)
+}
+
+// FidyahRateFromEnv reads FIDYAH_PER_DAY, the rupiah value of one day of
+// fidyah set by the local BAZNAS; 0 leaves the amount in staple food only
+func FidyahRateFromEnv() int {
+	rate, err := strconv.Atoi(os.Getenv("FIDYAH_PER_DAY"))
+	if err != nil || rate < 0 {
+		return 0
+	}
+	return rate
+}
+
+// FidyahLabel describes the fidyah for days, e.g. "2 mud (±1,35 kg) · Rp 90.000"
+func FidyahLabel(days, rate int) string {
+	kg := strings.Replace(fmt.Sprintf("%.2f", float64(days*FidyahGramsPerDay)/1000), ".", ",", 1)
+	label := fmt.Sprintf("%d mud (±%s kg)", days, kg)
+	if rate > 0 {
+		label += " · " + formatRupiah(days*rate)
+	}
+	return label
+}
+
+func formatRupiah(n int) string {
+	s := strconv.Itoa(n)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "." + s[i:]
+	}
+	return "Rp " + s
+}
+
+type QadhaReasonCount struct {
+	Value  string
+	Label  string
+	Days   int
+	Fidyah bool
+}
+
+// QadhaDebt is what one Ramadhan left to make up
+type QadhaDebt struct {
+	Ramadhan hijri.Period
+	Reasons  []QadhaReasonCount
+	Owed     int // days to make up
+	Paid     int
+	// FidyahDays counts chronic illness days plus, every time a following
+	// Ramadhan began, the days still owed (the fidyah of a delayed qadha)
+	FidyahDays int
+	// Deadline is the start of the next Ramadhan
+	Deadline time.Time
+}
+
+func (d *QadhaDebt) Outstanding() int {
+	if d.Paid >= d.Owed {
+		return 0
+	}
+	return d.Owed - d.Paid
+}
+
+// MissedRamadhanDays tallies why the days of ramadhan up to today were not
+// fasted. Udzur days count under their kind whatever the fasting entry says.
+func MissedRamadhanDays(ramadhan hijri.Period, fastings []*models.Fasting, periods []*models.UdzurPeriod, today string) *QadhaDebt {
+	debt := &QadhaDebt{Ramadhan: ramadhan}
+	start := ramadhan.Start.Format("2006-01-02")
+	end := ramadhan.End().Format("2006-01-02")
+	if today < end {
+		end = today
+	}
+
+	reasons := make(map[string]string)
+	for _, f := range fastings {
+		date := f.Date
+		if len(date) > 10 {
+			date = date[:10]
+		}
+		if date < start || date > end || f.Status.Done() {
+			continue
+		}
+		reasons[date] = QadhaReason(f.Reason)
+	}
+	for _, p := range periods {
+		for date := range ExcusedDates([]*models.UdzurPeriod{p}, start, end) {
+			reasons[date] = p.Kind
+		}
+	}
+
+	counts := make(map[string]int)
+	for _, r := range reasons {
+		counts[r]++
+	}
+	for _, q := range QadhaReasons {
+		n := counts[q.Value]
+		if n == 0 {
+			continue
+		}
+		debt.Reasons = append(debt.Reasons, QadhaReasonCount{Value: q.Value, Label: q.Label, Days: n, Fidyah: q.Fidyah})
+		if q.Fidyah {
+			debt.FidyahDays += n
+		} else {
+			debt.Owed += n
+		}
+	}
+	return debt
+}
+
+// lateDays counts, for every Ramadhan after year that began by today, the
+// days of owed not yet made up when it began. paid holds the make-up dates.
+func lateDays(owed int, paid []string, year, offset int, today string) int {
+	late := 0
+	for next := hijri.RamadhanOf(year+1, offset); next.Start.Format("2006-01-02") <= today; next = hijri.RamadhanOf(next.Year+1, offset) {
+		start := next.Start.Format("2006-01-02")
+		before := 0
+		for _, p := range paid {
+			if p < start {
+				before++
+			}
+		}
+		if before >= owed {
+			break
+		}
+		late += owed - before
+	}
+	return late
+}
+
+// QadhaSummary is a student's qadha balance over every Ramadhan with missed days
+type QadhaSummary struct {
+	Debts       []*QadhaDebt // newest Ramadhan first
+	Fasts       []*models.QadhaFast
+	Owed        int
+	Paid        int
+	Outstanding int
+	FidyahDays  int
+}
+
+// QadhaStudent is one row of a class report. It only carries totals, as
+// the reasons could reveal a student's udzur.
+type QadhaStudent struct {
+	User        *models.User
+	Owed        int
+	Paid        int
+	Outstanding int
+	FidyahDays  int
+}
+
+type QadhaService struct {
+	Repo        *repository.QadhaRepository
+	FastingRepo *repository.FastingRepository
+	UdzurRepo   *repository.UdzurRepository
+	UserRepo    *repository.UserRepository
+}
+
+func NewQadhaService(repo *repository.QadhaRepository, fastingRepo *repository.FastingRepository, udzurRepo *repository.UdzurRepository, userRepo *repository.UserRepository) *QadhaService {
+	return &QadhaService{Repo: repo, FastingRepo: fastingRepo, UdzurRepo: udzurRepo, UserRepo: userRepo}
+}
+
+// Summary computes the balance of every Ramadhan from the user's first missed
+// day up to now
+func (s *QadhaService) Summary(userID, offset int, now time.Time) (*QadhaSummary, error) {
+	today := now.Format("2006-01-02")
+	first, err := s.Repo.GetFirstMissedDate(userID)
+	if err != nil {
+		return nil, err
+	}
+	fasts, err := s.Repo.GetByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	years := make(map[int]bool)
+	if t, err := time.ParseInLocation("2006-01-02", first, now.Location()); err == nil {
+		for y := hijri.FromTime(t, offset).Year; y <= hijri.FromTime(now, offset).Year; y++ {
+			years[y] = true
+		}
+	}
+	paid := make(map[int][]string)
+	for _, q := range fasts {
+		years[q.RamadhanYear] = true
+		paid[q.RamadhanYear] = append(paid[q.RamadhanYear], q.Date)
+	}
+	order := make([]int, 0, len(years))
+	for y := range years {
+		order = append(order, y)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(order)))
+
+	summary := &QadhaSummary{Fasts: fasts}
+	for _, y := range order {
+		ramadhan := hijri.RamadhanOf(y, offset)
+		start, end := ramadhan.Start.Format("2006-01-02"), ramadhan.End().Format("2006-01-02")
+		if start > today {
+			continue
+		}
+		fastings, err := s.FastingRepo.GetByUserAndDateRange(userID, start, end)
+		if err != nil {
+			return nil, err
+		}
+		periods, err := s.UdzurRepo.GetInRange(userID, start, end)
+		if err != nil {
+			return nil, err
+		}
+
+		debt := MissedRamadhanDays(ramadhan, fastings, periods, today)
+		debt.Paid = len(paid[y])
+		debt.FidyahDays += lateDays(debt.Owed, paid[y], y, offset, today)
+		debt.Deadline = hijri.RamadhanOf(y+1, offset).Start
+		if debt.Owed == 0 && debt.Paid == 0 && debt.FidyahDays == 0 {
+			continue
+		}
+
+		summary.Debts = append(summary.Debts, debt)
+		summary.Owed += debt.Owed
+		summary.Paid += debt.Paid
+		summary.Outstanding += debt.Outstanding()
+		summary.FidyahDays += debt.FidyahDays
+	}
+	return summary, nil
+}
+
+// Log records a make-up fast on date against the oldest Ramadhan before it
+// that still has days to make up
+func (s *QadhaService) Log(user *models.User, date string, offset int, now time.Time) (*models.QadhaFast, error) {
+	t, err := time.ParseInLocation("2006-01-02", date, now.Location())
+	if err != nil {
+		return nil, ErrEntryInvalidDate
+	}
+	if date > now.Format("2006-01-02") {
+		return nil, ErrEntryFutureDate
+	}
+	if _, ok := hijri.RamadhanDay(t, offset); ok {
+		return nil, ErrQadhaInRamadhan
+	}
+	if ForbiddenFastingDay(t, offset) {
+		return nil, ErrQadhaForbiddenDay
+	}
+	if periods, err := s.UdzurRepo.GetInRange(user.ID, date, date); err != nil {
+		return nil, err
+	} else if len(periods) > 0 {
+		return nil, ErrQadhaExcused
+	}
+
+	summary, err := s.Summary(user.ID, offset, now)
+	if err != nil {
+		return nil, err
+	}
+	for _, q := range summary.Fasts {
+		if q.Date == date {
+			return nil, ErrQadhaDuplicate
+		}
+	}
+	q := &models.QadhaFast{UserID: user.ID, Date: date}
+	for i := len(summary.Debts) - 1; i >= 0; i-- {
+		d := summary.Debts[i]
+		if d.Outstanding() > 0 && d.Ramadhan.End().Format("2006-01-02") < date {
+			q.RamadhanYear = d.Ramadhan.Year
+			break
+		}
+	}
+	if q.RamadhanYear == 0 {
+		return nil, ErrQadhaNoDebt
+	}
+
+	if err := s.Repo.Create(q); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// ClassReport lists the students of a class who missed fasts, with their
+// balance; schoolID 0 covers every school
+func (s *QadhaService) ClassReport(schoolID int, class string, offset int, now time.Time) ([]QadhaStudent, error) {
+	var users []*models.User
+	var err error
+	if schoolID != 0 {
+		users, err = s.UserRepo.GetStudentsBySchool(schoolID, class)
+	} else if class != "" {
+		users, err = s.UserRepo.GetByClass(class)
+	} else {
+		users, err = s.UserRepo.GetAll()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []QadhaStudent
+	for _, u := range users {
+		if u.Role != "user" {
+			continue
+		}
+		summary, err := s.Summary(u.ID, offset, now)
+		if err != nil {
+			return nil, err
+		}
+		if summary.Owed == 0 && summary.FidyahDays == 0 {
+			continue
+		}
+		rows = append(rows, QadhaStudent{
+			User:        u,
+			Owed:        summary.Owed,
+			Paid:        summary.Paid,
+			Outstanding: summary.Outstanding,
+			FidyahDays:  summary.FidyahDays,
+		})
+	}
+	return rows, nil
+}
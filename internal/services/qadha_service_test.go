@@ -0,0 +1,62 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/ramadhan/amaliah-monitoring/internal/hijri"
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+	"github.com/ramadhan/amaliah-monitoring/internal/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMissedRamadhanDays(t *testing.T) {
+	ramadhan := hijri.RamadhanOf(1447, 0)
+	day := func(n int) string { return ramadhan.Date(n).Format("2006-01-02") }
+
+	fastings := []*models.Fasting{
+		{Date: day(1), Status: models.FastingDone},
+		{Date: day(2), Status: models.FastingSkipped, Reason: "sakit"},
+		{Date: day(3) + "T00:00:00Z", Status: models.FastingSkipped, Reason: "Perjalanan "},
+		{Date: day(4), Status: models.FastingSkipped, Reason: "malas"},
+		{Date: day(5), Status: models.FastingSkipped, Reason: "sakit_menahun"},
+		// Covered by the udzur period below
+		{Date: day(10), Status: models.FastingSkipped, Reason: "lainnya"},
+		// Before Ramadhan
+		{Date: ramadhan.Date(0).Format("2006-01-02"), Status: models.FastingSkipped},
+	}
+	periods := []*models.UdzurPeriod{{Kind: "haid", StartDate: day(10), EndDate: day(15)}}
+
+	debt := services.MissedRamadhanDays(ramadhan, fastings, periods, day(ramadhan.Days))
+	assert.Equal(t, 1+1+1+6, debt.Owed)
+	assert.Equal(t, 1, debt.FidyahDays)
+
+	labels := make(map[string]int)
+	for _, r := range debt.Reasons {
+		labels[r.Value] = r.Days
+	}
+	assert.Equal(t, map[string]int{"sakit": 1, "perjalanan": 1, "lainnya": 1, "haid": 6, "sakit_menahun": 1}, labels)
+
+	// Days after today are not owed yet
+	debt = services.MissedRamadhanDays(ramadhan, fastings, periods, day(12))
+	assert.Equal(t, 1+1+1+3, debt.Owed)
+
+	debt.Paid = 10
+	assert.Equal(t, 0, debt.Outstanding())
+}
+
+func TestForbiddenFastingDay(t *testing.T) {
+	ramadhan := hijri.RamadhanOf(1447, 0)
+	idulFitri := ramadhan.End().AddDate(0, 0, 1)
+	assert.True(t, services.ForbiddenFastingDay(idulFitri, 0))
+	assert.False(t, services.ForbiddenFastingDay(idulFitri.AddDate(0, 0, 1), 0))
+
+	idulAdha := hijri.Date{Year: 1447, Month: 12, Day: 10}.Time(0)
+	assert.True(t, services.ForbiddenFastingDay(idulAdha.AddDate(0, 0, 3), 0))
+	assert.False(t, services.ForbiddenFastingDay(idulAdha.AddDate(0, 0, 4), 0))
+}
+
+func TestFidyahLabel(t *testing.T) {
+	assert.Equal(t, "2 mud (±1,35 kg)", services.FidyahLabel(2, 0))
+	assert.Equal(t, "4 mud (±2,70 kg) · Rp 180.000", services.FidyahLabel(4, 45000))
+	assert.Equal(t, "30 mud (±20,25 kg) · Rp 1.350.000", services.FidyahLabel(30, 45000))
+}
@@ -0,0 +1,34 @@
+package services
+
+import (
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+	"github.com/ramadhan/amaliah-monitoring/internal/mushaf"
+)
+
+// ReadingRange returns the mushaf range a reading covers
+func ReadingRange(r *models.QuranReading) mushaf.Range {
+	return mushaf.Range{
+		StartSurah: r.StartSurahID,
+		StartAyah:  r.StartAyah,
+		EndSurah:   r.EndSurahID,
+		EndAyah:    r.EndAyah,
+	}
+}
+
+// ErrReadingReversed rejects a reading that ends in a surah before the one it
+// starts in. Such a range is almost always swapped fields; a reading past
+// An-Nas is logged as two.
+var ErrReadingReversed = mushaf.ErrReversed
+
+// FillQuranCoverage validates the ayah range of r and sets its page count and
+// page/juz span from the Mushaf Madinah index, overriding any typed value.
+func FillQuranCoverage(r *models.QuranReading) error {
+	c, err := ReadingRange(r).Cover()
+	if err != nil {
+		return err
+	}
+	r.Pages = c.Pages
+	r.StartPage, r.EndPage = c.StartPage, c.EndPage
+	r.StartJuz, r.EndJuz = c.StartJuz, c.EndJuz
+	return nil
+}
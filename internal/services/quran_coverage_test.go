@@ -0,0 +1,23 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+	"github.com/ramadhan/amaliah-monitoring/internal/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFillQuranCoverage(t *testing.T) {
+	r := &models.QuranReading{StartSurahID: 2, StartAyah: 1, EndSurahID: 2, EndAyah: 16, Pages: 40}
+	assert.NoError(t, services.FillQuranCoverage(r))
+	assert.Equal(t, 2, r.Pages)
+
+	// Swapped surahs must not count as reading past An-Nas
+	r = &models.QuranReading{StartSurahID: 3, StartAyah: 1, EndSurahID: 2, EndAyah: 5}
+	assert.ErrorIs(t, services.FillQuranCoverage(r), services.ErrReadingReversed)
+	assert.Zero(t, r.Pages)
+
+	r = &models.QuranReading{StartSurahID: 2, StartAyah: 10, EndSurahID: 2, EndAyah: 5}
+	assert.Error(t, services.FillQuranCoverage(r))
+}
@@ -0,0 +1,230 @@
+package services
+
+import (
+	"container/list"
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+	"github.com/ramadhan/amaliah-monitoring/internal/repository"
+)
+
+// TTLs for equran.id data. Published schedules rarely change, the location
+// lists almost never do.
+const (
+	locationCacheTTL = 7 * 24 * time.Hour
+	scheduleCacheTTL = 24 * time.Hour
+)
+
+const scheduleCacheSize = 512
+
+// ScheduleCache is a two-level cache (in-memory LRU in front of the api_cache
+// table) for upstream API responses. Concurrent misses for the same key share
+// one upstream request, and an expired entry is served if upstream fails.
+type ScheduleCache struct {
+	Repo *repository.CacheRepository
+
+	mu       sync.Mutex
+	capacity int
+	lru      *list.List
+	items    map[string]*list.Element
+	inflight map[string]*cacheCall
+	stats    map[string]*cacheCounters
+}
+
+type cacheCall struct {
+	wg      sync.WaitGroup
+	payload []byte
+	err     error
+}
+
+type cacheCounters struct {
+	memoryHits, dbHits, misses, stale, errors atomic.Int64
+}
+
+func NewScheduleCache(repo *repository.CacheRepository) *ScheduleCache {
+	return &ScheduleCache{
+		Repo:     repo,
+		capacity: scheduleCacheSize,
+		lru:      list.New(),
+		items:    make(map[string]*list.Element),
+		inflight: make(map[string]*cacheCall),
+		stats:    make(map[string]*cacheCounters),
+	}
+}
+
+// Fetch returns the cached payload for key, calling load when it is missing or expired
+func (c *ScheduleCache) Fetch(key string, ttl time.Duration, load func() ([]byte, error)) ([]byte, error) {
+	counters := c.counters(key)
+	now := time.Now()
+
+	cached := c.memoryGet(key)
+	if cached != nil && now.Before(cached.ExpiresAt) {
+		counters.memoryHits.Add(1)
+		return cached.Payload, nil
+	}
+	if cached == nil && c.Repo != nil {
+		if entry, err := c.Repo.Get(key); err == nil {
+			c.memoryPut(entry)
+			cached = entry
+			if now.Before(entry.ExpiresAt) {
+				counters.dbHits.Add(1)
+				return entry.Payload, nil
+			}
+		}
+	}
+
+	payload, err := c.coalesce(key, func() ([]byte, error) {
+		counters.misses.Add(1)
+		payload, err := load()
+		if err != nil {
+			return nil, err
+		}
+		fetched := time.Now()
+		entry := &models.CacheEntry{Key: key, Payload: payload, Size: len(payload), FetchedAt: fetched, ExpiresAt: fetched.Add(ttl)}
+		c.memoryPut(entry)
+		if c.Repo != nil {
+			c.Repo.Save(entry)
+		}
+		return payload, nil
+	})
+	if err != nil {
+		if cached != nil {
+			counters.stale.Add(1)
+			return cached.Payload, nil
+		}
+		counters.errors.Add(1)
+		return nil, err
+	}
+	return payload, nil
+}
+
+// Expire marks every entry whose key starts with prefix as expired ("" for all).
+// Entries stay available as stale fallback until they are refetched.
+func (c *ScheduleCache) Expire(prefix string) (int64, error) {
+	now := time.Now()
+
+	c.mu.Lock()
+	for key, el := range c.items {
+		if strings.HasPrefix(key, prefix) {
+			el.Value.(*models.CacheEntry).ExpiresAt = now
+		}
+	}
+	c.mu.Unlock()
+
+	if c.Repo == nil {
+		return 0, nil
+	}
+	return c.Repo.ExpirePrefix(prefix, now)
+}
+
+// Stats returns request counters per namespace (the part of the key before the first ':')
+func (c *ScheduleCache) Stats() []models.CacheStat {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := make([]models.CacheStat, 0, len(c.stats))
+	for ns, counters := range c.stats {
+		st := models.CacheStat{
+			Namespace:  ns,
+			MemoryHits: counters.memoryHits.Load(),
+			DBHits:     counters.dbHits.Load(),
+			Misses:     counters.misses.Load(),
+			Stale:      counters.stale.Load(),
+			Errors:     counters.errors.Load(),
+		}
+		if total := st.MemoryHits + st.DBHits + st.Misses; total > 0 {
+			st.HitRate = float64(st.MemoryHits+st.DBHits) * 100 / float64(total)
+		}
+		stats = append(stats, st)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Namespace < stats[j].Namespace })
+	return stats
+}
+
+func (c *ScheduleCache) counters(key string) *cacheCounters {
+	ns, _, _ := strings.Cut(key, ":")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	counters, ok := c.stats[ns]
+	if !ok {
+		counters = &cacheCounters{}
+		c.stats[ns] = counters
+	}
+	return counters
+}
+
+func (c *ScheduleCache) coalesce(key string, fn func() ([]byte, error)) ([]byte, error) {
+	c.mu.Lock()
+	if call, ok := c.inflight[key]; ok {
+		c.mu.Unlock()
+		call.wg.Wait()
+		return call.payload, call.err
+	}
+	call := &cacheCall{}
+	call.wg.Add(1)
+	c.inflight[key] = call
+	c.mu.Unlock()
+
+	call.payload, call.err = fn()
+	call.wg.Done()
+
+	c.mu.Lock()
+	delete(c.inflight, key)
+	c.mu.Unlock()
+
+	return call.payload, call.err
+}
+
+func (c *ScheduleCache) memoryGet(key string) *models.CacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[key]
+	if !ok {
+		return nil
+	}
+	c.lru.MoveToFront(el)
+	entry := *el.Value.(*models.CacheEntry)
+	return &entry
+}
+
+func (c *ScheduleCache) memoryPut(entry *models.CacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[entry.Key]; ok {
+		el.Value = entry
+		c.lru.MoveToFront(el)
+		return
+	}
+	c.items[entry.Key] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.capacity {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.items, oldest.Value.(*models.CacheEntry).Key)
+	}
+}
+
+// cachedJSON wraps an upstream call with the cache; a nil cache calls load directly
+func cachedJSON[T any](c *ScheduleCache, key string, ttl time.Duration, load func() (T, error)) (T, error) {
+	if c == nil {
+		return load()
+	}
+	var value T
+	payload, err := c.Fetch(key, ttl, func() ([]byte, error) {
+		v, err := load()
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(v)
+	})
+	if err != nil {
+		return value, err
+	}
+	err = json.Unmarshal(payload, &value)
+	return value, err
+}
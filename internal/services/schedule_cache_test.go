@@ -0,0 +1,88 @@
+package services
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduleCacheServesFreshAndStale(t *testing.T) {
+	cache := NewScheduleCache(nil)
+	calls := 0
+	load := func() ([]byte, error) {
+		calls++
+		return []byte(`"v1"`), nil
+	}
+
+	payload, err := cache.Fetch("shalat:a", time.Hour, load)
+	assert.NoError(t, err)
+	assert.Equal(t, `"v1"`, string(payload))
+
+	cache.Fetch("shalat:a", time.Hour, load)
+	assert.Equal(t, 1, calls, "second fetch is a memory hit")
+
+	// Expired entry + upstream failure falls back to the stale copy
+	cache.Expire("shalat:")
+	payload, err = cache.Fetch("shalat:a", time.Hour, func() ([]byte, error) {
+		return nil, errors.New("upstream down")
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, `"v1"`, string(payload))
+
+	_, err = cache.Fetch("shalat:b", time.Hour, func() ([]byte, error) {
+		return nil, errors.New("upstream down")
+	})
+	assert.Error(t, err)
+
+	stats := cache.Stats()
+	assert.Len(t, stats, 1)
+	assert.Equal(t, int64(1), stats[0].MemoryHits)
+	assert.Equal(t, int64(3), stats[0].Misses)
+	assert.Equal(t, int64(1), stats[0].Stale)
+	assert.Equal(t, int64(1), stats[0].Errors)
+}
+
+func TestScheduleCacheCoalescesConcurrentMisses(t *testing.T) {
+	cache := NewScheduleCache(nil)
+	var calls atomic.Int32
+	release := make(chan struct{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			payload, err := cache.Fetch("imsakiyah:x", time.Hour, func() ([]byte, error) {
+				calls.Add(1)
+				<-release
+				return []byte(`1`), nil
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, "1", string(payload))
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestScheduleCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := NewScheduleCache(nil)
+	cache.capacity = 2
+	load := func() ([]byte, error) { return []byte(`1`), nil }
+
+	cache.Fetch("k:1", time.Hour, load)
+	cache.Fetch("k:2", time.Hour, load)
+	cache.Fetch("k:1", time.Hour, load)
+	cache.Fetch("k:3", time.Hour, load)
+
+	assert.NotNil(t, cache.memoryGet("k:1"))
+	assert.Nil(t, cache.memoryGet("k:2"))
+	assert.NotNil(t, cache.memoryGet("k:3"))
+}
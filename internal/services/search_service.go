@@ -0,0 +1,281 @@
+package services
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"log"
+	"net/url"
+	"strings"
+	"unicode"
+
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+	"github.com/ramadhan/amaliah-monitoring/internal/mushaf"
+	"github.com/ramadhan/amaliah-monitoring/internal/repository"
+)
+
+// SearchKinds lists the content types that can be searched, in display order
+var SearchKinds = []struct {
+	Value string
+	Label string
+}{
+	{"ayah", "Ayat"},
+	{"surah", "Surah"},
+	{"doa", "Doa"},
+	{"hadits", "Hadits"},
+}
+
+// arabicFolds maps letter variants onto the form stored in the index
+var arabicFolds = map[rune]rune{
+	'أ': 'ا', 'إ': 'ا', 'آ': 'ا', 'ٱ': 'ا',
+	'ى': 'ي', 'ئ': 'ي',
+	'ؤ': 'و',
+	'ة': 'ه',
+}
+
+// NormalizeArabic strips harakat, Quranic annotation marks and tatweel and
+// folds alef, ya and ta marbuta variants, so "بِسْمِ ٱللَّهِ" and "بسم الله"
+// index and match the same way. Other scripts pass through unchanged.
+func NormalizeArabic(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		switch {
+		case r >= 0x064B && r <= 0x065F, r == 0x0670, r >= 0x06D6 && r <= 0x06ED, r == 0x0640:
+			continue
+		}
+		if f, ok := arabicFolds[r]; ok {
+			r = f
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// BuildMatchQuery turns user input into an FTS5 expression: every word must
+// appear, as a prefix, in any column. Quotes and operators in the input are
+// dropped, so the result is always a valid expression; "" means nothing to search.
+func BuildMatchQuery(input string) string {
+	words := strings.FieldsFunc(NormalizeArabic(input), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.Is(unicode.Mn, r)
+	})
+	if len(words) > 8 {
+		words = words[:8]
+	}
+	terms := make([]string, len(words))
+	for i, w := range words {
+		terms[i] = `"` + w + `"*`
+	}
+	return strings.Join(terms, " ")
+}
+
+type SearchResult struct {
+	Kind    string        `json:"kind"`
+	Title   string        `json:"title"`
+	Snippet template.HTML `json:"snippet"`
+	URL     string        `json:"url"`
+}
+
+// SearchService keeps an FTS5 index of the content the app can show. Sources
+// are indexed once, either by Warm at startup or the first time a provider
+// call returns them through IndexingContentProvider.
+type SearchService struct {
+	Repo    *repository.SearchRepository
+	Content ContentProvider
+}
+
+func NewSearchService(repo *repository.SearchRepository, content ContentProvider) *SearchService {
+	return &SearchService{Repo: repo, Content: content}
+}
+
+// Search returns up to limit matches for query; kind restricts the results to
+// one of SearchKinds
+func (s *SearchService) Search(query, kind string, limit int) ([]SearchResult, error) {
+	match := BuildMatchQuery(query)
+	if match == "" {
+		return []SearchResult{}, nil
+	}
+	hits, err := s.Repo.Search(match, kind, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]SearchResult, 0, len(hits))
+	for _, h := range hits {
+		results = append(results, SearchResult{
+			Kind:    h.Kind,
+			Title:   h.Title,
+			Snippet: highlightSnippet(h.Snippet),
+			URL:     searchURL(h),
+		})
+	}
+	return results, nil
+}
+
+// highlightSnippet escapes a snippet and turns the match markers into <mark>
+func highlightSnippet(snippet string) template.HTML {
+	escaped := html.EscapeString(snippet)
+	escaped = strings.ReplaceAll(escaped, "\x02", "<mark>")
+	escaped = strings.ReplaceAll(escaped, "\x03", "</mark>")
+	return template.HTML(escaped)
+}
+
+func searchURL(h *models.SearchHit) string {
+	return ContentURL(h.Kind, h.Ref, h.Title)
+}
+
+// ContentURL links to a piece of content on its reader page. ref is
+// "surah:ayah" for an ayah, the surah number, the doa ID or the hadits number;
+// doa are found by title because the doa page has no detail view.
+func ContentURL(kind, ref, title string) string {
+	switch kind {
+	case "ayah":
+		surah, ayah, _ := strings.Cut(ref, ":")
+		return "/user/quran-indonesia?surah=" + surah + "#ayat-" + ayah
+	case "surah":
+		return "/user/quran-indonesia?surah=" + ref
+	case "doa":
+		return "/user/doa?search=" + url.QueryEscape(title)
+	case "hadits":
+		return "/user/hadits?nomor=" + ref
+	}
+	return "/user/search"
+}
+
+func (s *SearchService) IndexSurahList(list []Surah) error {
+	docs := make([]models.SearchDocument, 0, len(list))
+	for _, su := range list {
+		docs = append(docs, models.SearchDocument{
+			Kind:  "surah",
+			Ref:   su.Number,
+			Title: fmt.Sprintf("%s. %s", su.Number, su.NameID),
+			Body:  su.TranslationID,
+			Latin: su.NameEN,
+			Arab:  NormalizeArabic(su.NameShort),
+		})
+	}
+	return s.Repo.ReplaceSource("surah", docs)
+}
+
+func (s *SearchService) IndexAyat(surahID int, ayat []Ayah) error {
+	name := fmt.Sprintf("Surah %d", surahID)
+	if su, err := s.Content.GetSurahByID(surahID); err == nil {
+		name = su.NameID
+	}
+
+	docs := make([]models.SearchDocument, 0, len(ayat))
+	for _, a := range ayat {
+		docs = append(docs, models.SearchDocument{
+			Kind:  "ayah",
+			Ref:   fmt.Sprintf("%d:%s", surahID, a.Ayah),
+			Title: fmt.Sprintf("%s: %s", name, a.Ayah),
+			Body:  a.Text,
+			Latin: a.Latin,
+			Arab:  NormalizeArabic(a.Arab),
+		})
+	}
+	return s.Repo.ReplaceSource(fmt.Sprintf("ayah:%d", surahID), docs)
+}
+
+func (s *SearchService) IndexDoa(list []Doa) error {
+	docs := make([]models.SearchDocument, 0, len(list))
+	for _, d := range list {
+		docs = append(docs, models.SearchDocument{
+			Kind:  "doa",
+			Ref:   d.ID,
+			Title: d.Judul,
+			Body:  d.Indo,
+			Arab:  NormalizeArabic(d.Arab),
+		})
+	}
+	return s.Repo.ReplaceSource("doa", docs)
+}
+
+func (s *SearchService) IndexHadits(list []Hadits) error {
+	docs := make([]models.SearchDocument, 0, len(list))
+	for _, h := range list {
+		docs = append(docs, models.SearchDocument{
+			Kind:  "hadits",
+			Ref:   h.No,
+			Title: fmt.Sprintf("Hadits %s: %s", h.No, h.Judul),
+			Body:  h.Indo,
+			Arab:  NormalizeArabic(h.Arab),
+		})
+	}
+	return s.Repo.ReplaceSource("hadits", docs)
+}
+
+// Warm indexes every source that is not indexed yet. Sources the provider
+// cannot supply right now are skipped and retried on the next start or when
+// a reader page loads them.
+func (s *SearchService) Warm() {
+	if !s.Repo.IsIndexed("surah") {
+		if list, err := s.Content.GetAllSurah(); err == nil && len(list) > 0 {
+			s.logIndex("surah", s.IndexSurahList(list))
+		}
+	}
+	if !s.Repo.IsIndexed("doa") {
+		if list, err := s.Content.GetAllDoa(); err == nil && len(list) > 0 {
+			s.logIndex("doa", s.IndexDoa(list))
+		}
+	}
+	if !s.Repo.IsIndexed("hadits") {
+		if list, err := s.Content.GetAllHadits(); err == nil && len(list) > 0 {
+			s.logIndex("hadits", s.IndexHadits(list))
+		}
+	}
+	for id := 1; id <= mushaf.SurahCount; id++ {
+		source := fmt.Sprintf("ayah:%d", id)
+		if s.Repo.IsIndexed(source) {
+			continue
+		}
+		ayat, err := s.Content.GetAyahBySurah(id)
+		if err != nil || len(ayat) == 0 {
+			// Neither the dataset nor the API has it; don't wait out a
+			// timeout for every remaining surah. Surahs read later are
+			// indexed by IndexingContentProvider.
+			log.Printf("Warning: search index stopped at surah %d: %v", id, err)
+			return
+		}
+		s.logIndex(source, s.IndexAyat(id, ayat))
+	}
+}
+
+func (s *SearchService) logIndex(source string, err error) {
+	if err != nil {
+		log.Printf("Warning: failed to index %s for search: %v", source, err)
+	}
+}
+
+// IndexingContentProvider adds whatever the wrapped provider returns to the
+// search index the first time it is seen, so content read while Warm could
+// not reach it still becomes searchable
+type IndexingContentProvider struct {
+	ContentProvider
+	Search *SearchService
+}
+
+func (p *IndexingContentProvider) GetAllDoa() ([]Doa, error) {
+	list, err := p.ContentProvider.GetAllDoa()
+	if err == nil && len(list) > 0 && !p.Search.Repo.IsIndexed("doa") {
+		p.Search.logIndex("doa", p.Search.IndexDoa(list))
+	}
+	return list, err
+}
+
+func (p *IndexingContentProvider) GetAllHadits() ([]Hadits, error) {
+	list, err := p.ContentProvider.GetAllHadits()
+	if err == nil && len(list) > 0 && !p.Search.Repo.IsIndexed("hadits") {
+		p.Search.logIndex("hadits", p.Search.IndexHadits(list))
+	}
+	return list, err
+}
+
+func (p *IndexingContentProvider) GetAyahBySurah(surahID int) ([]Ayah, error) {
+	ayat, err := p.ContentProvider.GetAyahBySurah(surahID)
+	source := fmt.Sprintf("ayah:%d", surahID)
+	if err == nil && len(ayat) > 0 && !p.Search.Repo.IsIndexed(source) {
+		p.Search.logIndex(source, p.Search.IndexAyat(surahID, ayat))
+	}
+	return ayat, err
+}
@@ -0,0 +1,22 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/ramadhan/amaliah-monitoring/internal/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeArabic(t *testing.T) {
+	assert.Equal(t, "بسم الله الرحمن الرحيم", services.NormalizeArabic("بِسْمِ ٱللَّهِ ٱلرَّحْمَٰنِ ٱلرَّحِيمِ"))
+	assert.Equal(t, "الي الصلاه", services.NormalizeArabic("إِلَى الصَّلَاةِ"))
+	assert.Equal(t, "Maha Pengasih", services.NormalizeArabic("Maha Pengasih"))
+}
+
+func TestBuildMatchQuery(t *testing.T) {
+	assert.Equal(t, `"Maha"* "Pengasih"*`, services.BuildMatchQuery("Maha  Pengasih"))
+	assert.Equal(t, `"الرحمن"*`, services.BuildMatchQuery("ٱلرَّحْمَٰنِ"))
+	// FTS5 syntax in the input is not interpreted
+	assert.Equal(t, `"a"* "OR"* "b"* "c"*`, services.BuildMatchQuery(`a OR "b" c*`))
+	assert.Equal(t, "", services.BuildMatchQuery(`"*"`))
+}
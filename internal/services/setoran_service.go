@@ -0,0 +1,138 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+	"github.com/ramadhan/amaliah-monitoring/internal/mushaf"
+	"github.com/ramadhan/amaliah-monitoring/internal/repository"
+)
+
+// SetoranGrades lists the grades a teacher can give and the points each one
+// awards. Only passing grades earn points.
+var SetoranGrades = []struct {
+	Value  string
+	Label  string
+	Points int
+}{
+	{"lancar", "Lancar", 20},
+	{"kurang_lancar", "Kurang Lancar", 10},
+	{"ulang", "Ulang", 0},
+}
+
+var (
+	ErrInvalidSetoranGrade = errors.New("nilai setoran tidak valid")
+	ErrSetoranForbidden    = errors.New("setoran ini bukan dari siswa sekolah Anda")
+	ErrSetoranNoSchool     = errors.New("bergabunglah dengan sekolah terlebih dahulu agar guru dapat menilai setoran")
+)
+
+// SetoranPoints returns the points awarded for grade
+func SetoranPoints(grade string) (int, error) {
+	for _, g := range SetoranGrades {
+		if g.Value == grade {
+			return g.Points, nil
+		}
+	}
+	return 0, ErrInvalidSetoranGrade
+}
+
+// SetoranGradeLabel returns the display label of grade
+func SetoranGradeLabel(grade string) string {
+	for _, g := range SetoranGrades {
+		if g.Value == grade {
+			return g.Label
+		}
+	}
+	return grade
+}
+
+// IsTeacher reports whether a role may grade setoran
+func IsTeacher(role string) bool {
+	return role == "guru" || role == "admin" || role == "superadmin"
+}
+
+// HasSchoolScope reports whether a teacher's pages cover their own school or,
+// for the superadmin, every school. A teacher without a school has neither.
+func HasSchoolScope(teacher *models.User) bool {
+	return teacher.Role == "superadmin" || teacher.SchoolID != 0
+}
+
+// CanGradeSetoran reports whether teacher may grade s. Teachers and school
+// admins are limited to their own school; the superadmin may grade any.
+func CanGradeSetoran(teacher *models.User, s *models.Setoran) bool {
+	if teacher.Role == "superadmin" {
+		return true
+	}
+	return IsTeacher(teacher.Role) && teacher.SchoolID != 0 && teacher.SchoolID == s.SchoolID
+}
+
+type SetoranService struct {
+	Repo     *repository.SetoranRepository
+	UserRepo *repository.UserRepository
+}
+
+func NewSetoranService(repo *repository.SetoranRepository, userRepo *repository.UserRepository) *SetoranService {
+	return &SetoranService{Repo: repo, UserRepo: userRepo}
+}
+
+// Request files a setoran for the student to recite to a teacher
+func (s *SetoranService) Request(student *models.User, r mushaf.Range) (*models.Setoran, error) {
+	if student.SchoolID == 0 {
+		return nil, ErrSetoranNoSchool
+	}
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+
+	item := &models.Setoran{
+		StudentID:    student.ID,
+		SchoolID:     student.SchoolID,
+		StartSurahID: r.StartSurah,
+		StartAyah:    r.StartAyah,
+		EndSurahID:   r.EndSurah,
+		EndAyah:      r.EndAyah,
+	}
+	if err := s.Repo.Create(item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// Grade records the teacher's assessment. A setoran can be graded again to
+// correct it; the student's points are adjusted by the difference.
+func (s *SetoranService) Grade(teacher *models.User, id int, grade, notes string, date time.Time) (*models.Setoran, error) {
+	item, err := s.Repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !CanGradeSetoran(teacher, item) {
+		return nil, ErrSetoranForbidden
+	}
+	points, err := SetoranPoints(grade)
+	if err != nil {
+		return nil, err
+	}
+
+	previous := item.Points
+	item.TeacherID = teacher.ID
+	item.Grade = grade
+	item.TajwidNotes = notes
+	item.SetoranDate = date.Format("2006-01-02")
+	item.Points = points
+	if err := s.Repo.Grade(item); err != nil {
+		return nil, err
+	}
+	item.Status = "graded"
+
+	if delta := points - previous; delta != 0 {
+		if err := s.UserRepo.UpdatePoints(item.StudentID, delta); err != nil {
+			return nil, err
+		}
+	}
+	return item, nil
+}
+
+func SetoranRange(s *models.Setoran) mushaf.Range {
+	return mushaf.Range{StartSurah: s.StartSurahID, StartAyah: s.StartAyah, EndSurah: s.EndSurahID, EndAyah: s.EndAyah}
+}
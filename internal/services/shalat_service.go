@@ -15,7 +15,10 @@ import (
 const shalatBaseURL = "https://equran.id/api/v2/shalat"
 
 type ShalatService struct {
-	client *http.Client
+	client     *http.Client
+	Cache      *ScheduleCache    // optional
+	Calculator *PrayerCalculator // optional; used when the API is unreachable
+	LocalFirst bool              // prefer Calculator over the API
 }
 
 func NewShalatService() *ShalatService {
@@ -27,6 +30,10 @@ func NewShalatService() *ShalatService {
 }
 
 func (s *ShalatService) GetProvinsi() ([]string, error) {
+	return cachedJSON(s.Cache, "shalat-provinsi:", locationCacheTTL, s.fetchProvinsi)
+}
+
+func (s *ShalatService) fetchProvinsi() ([]string, error) {
 	resp, err := s.client.Get(shalatBaseURL + "/provinsi")
 	if err != nil {
 		return nil, err
@@ -42,11 +49,21 @@ func (s *ShalatService) GetProvinsi() ([]string, error) {
 	if err := json.Unmarshal(body, &result); err != nil {
 		return nil, err
 	}
+	if len(result.Data) == 0 {
+		// Don't let an error body be cached as an empty list
+		return nil, fmt.Errorf("API error: %s", result.Message)
+	}
 
 	return result.Data, nil
 }
 
 func (s *ShalatService) GetKabkota(provinsi string) ([]string, error) {
+	return cachedJSON(s.Cache, "shalat-kabkota:"+provinsi, locationCacheTTL, func() ([]string, error) {
+		return s.fetchKabkota(provinsi)
+	})
+}
+
+func (s *ShalatService) fetchKabkota(provinsi string) ([]string, error) {
 	payload := map[string]string{"provinsi": provinsi}
 	jsonPayload, _ := json.Marshal(payload)
 
@@ -65,11 +82,42 @@ func (s *ShalatService) GetKabkota(provinsi string) ([]string, error) {
 	if err := json.Unmarshal(body, &result); err != nil {
 		return nil, err
 	}
+	if len(result.Data) == 0 {
+		// Don't let an error body be cached as an empty list
+		return nil, fmt.Errorf("API error: %s", result.Message)
+	}
 
 	return result.Data, nil
 }
 
 func (s *ShalatService) GetShalat(provinsi, kabkota string, bulan, tahun int) (*models.ShalatData, error) {
+	// Pin "current month" so the cache key names the month actually returned
+	now := time.Now()
+	if bulan <= 0 {
+		bulan = int(now.Month())
+	}
+	if tahun <= 0 {
+		tahun = now.Year()
+	}
+	if s.Calculator != nil && s.LocalFirst {
+		if data, err := s.Calculator.Shalat(provinsi, kabkota, bulan, tahun); err == nil {
+			return data, nil
+		}
+	}
+
+	key := fmt.Sprintf("shalat:%s:%s:%d:%d", provinsi, kabkota, tahun, bulan)
+	data, err := cachedJSON(s.Cache, key, scheduleCacheTTL, func() (*models.ShalatData, error) {
+		return s.fetchShalat(provinsi, kabkota, bulan, tahun)
+	})
+	if err != nil && s.Calculator != nil && !s.LocalFirst {
+		if local, calcErr := s.Calculator.Shalat(provinsi, kabkota, bulan, tahun); calcErr == nil {
+			return local, nil
+		}
+	}
+	return data, err
+}
+
+func (s *ShalatService) fetchShalat(provinsi, kabkota string, bulan, tahun int) (*models.ShalatData, error) {
 	payload := map[string]interface{}{
 		"provinsi": provinsi,
 		"kabkota":  kabkota,
@@ -106,13 +154,17 @@ func (s *ShalatService) GetShalat(provinsi, kabkota string, bulan, tahun int) (*
 }
 
 func (s *ShalatService) GetTodaySchedule(provinsi, kabkota string) (*models.ShalatSchedule, error) {
-	now := time.Now()
-	data, err := s.GetShalat(provinsi, kabkota, int(now.Month()), now.Year())
+	return s.GetSchedule(provinsi, kabkota, time.Now())
+}
+
+// GetSchedule returns the schedule of one day
+func (s *ShalatService) GetSchedule(provinsi, kabkota string, day time.Time) (*models.ShalatSchedule, error) {
+	data, err := s.GetShalat(provinsi, kabkota, int(day.Month()), day.Year())
 	if err != nil {
 		return nil, err
 	}
 
-	dayOfMonth := now.Day()
+	dayOfMonth := day.Day()
 	for _, schedule := range data.Jadwal {
 		if schedule.Tanggal == dayOfMonth {
 			return &schedule, nil
@@ -122,58 +174,46 @@ func (s *ShalatService) GetTodaySchedule(provinsi, kabkota string) (*models.Shal
 	return nil, fmt.Errorf("schedule not found for day %d", dayOfMonth)
 }
 
-// Nominatim Reverse Geocoding Response
-type NominatimResponse struct {
-	Address struct {
-		City        string `json:"city"`
-		Town        string `json:"town"`
-		Village     string `json:"village"`
-		County      string `json:"county"`
-		State       string `json:"state"` // Province
-		Country     string `json:"country"`
-	} `json:"address"`
-	DisplayName string `json:"display_name"`
+// maxDetectDistanceKm rejects points too far from any kabupaten/kota seat,
+// i.e. outside Indonesia
+const maxDetectDistanceKm = 150
+
+// LocationMatch is the result of resolving GPS coordinates to a schedule location
+type LocationMatch struct {
+	DetectedProv string  // from the embedded dataset
+	DetectedCity string  // from the embedded dataset
+	Provinsi     string  // as named by equran.id
+	Kabkota      string  // as named by equran.id
+	DistanceKm   float64 // to the kabupaten/kota seat
+	Exact        bool    // dataset and API names agree
 }
 
-func (s *ShalatService) ReverseGeocode(lat, long float64) (string, string, error) {
-	url := fmt.Sprintf("https://nominatim.openstreetmap.org/reverse?format=json&lat=%f&lon=%f&zoom=10", lat, long)
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return "", "", err
+// ResolveLocation finds the nearest kabupaten/kota in the embedded dataset,
+// without calling any geocoding service. When the equran.id location lists
+// are reachable the names are aligned with them.
+func (s *ShalatService) ResolveLocation(lat, long float64) (*LocationMatch, error) {
+	loc, dist := NearestKabkota(lat, long)
+	if dist > maxDetectDistanceKm {
+		return nil, fmt.Errorf("lokasi berada di luar wilayah Indonesia")
 	}
-	// Nominatim requires a User-Agent identify your application
-	req.Header.Set("User-Agent", "AmaliahRamadhanApp/1.0")
 
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return "", "", err
+	match := &LocationMatch{
+		DetectedProv: loc.Provinsi,
+		DetectedCity: loc.Kabkota,
+		Provinsi:     loc.Provinsi,
+		Kabkota:      loc.Kabkota,
+		DistanceKm:   dist,
+		Exact:        true,
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	prov, city, err := s.MatchLocation(loc.Provinsi, loc.Kabkota)
 	if err != nil {
-		return "", "", err
+		// API unreachable: the dataset already uses equran.id naming
+		return match, nil
 	}
-
-	var result NominatimResponse
-	if err := json.Unmarshal(body, &result); err != nil {
-		return "", "", err
-	}
-
-	// Extract city/kabupaten and province
-	city := result.Address.City
-	if city == "" {
-		city = result.Address.Town
-	}
-	if city == "" {
-		city = result.Address.County // Kabupaten often maps to county
-	}
-	// Fallback to village if really needed, but usually not for Shalat API matching
-	
-	province := result.Address.State
-
-	return province, city, nil
+	match.Provinsi, match.Kabkota = prov, city
+	match.Exact = kabkotaKey(city) == kabkotaKey(loc.Kabkota)
+	return match, nil
 }
 
 func (s *ShalatService) MatchLocation(detectedProv, detectedCity string) (string, string, error) {
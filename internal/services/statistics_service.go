@@ -12,6 +12,7 @@ type StatisticsService struct {
 	AmaliahRepo *repository.AmaliahRepository
 	FastingRepo *repository.FastingRepository
 	UserRepo    *repository.UserRepository
+	SunnahRepo  *repository.SunnahPrayerRepository // optional
 }
 
 func NewStatisticsService(
@@ -61,6 +62,19 @@ func (s *StatisticsService) GetDashboardStats() (map[string]interface{}, error)
 		data = append(data, stat["percentage"].(float64))
 	}
 
+	// Tarawih share per day, aligned with the prayer chart labels
+	var tarawihData []float64
+	if s.SunnahRepo != nil {
+		sunnahStats, _ := s.SunnahRepo.GetDailyCompletionStats(startDate, endDate)
+		byDate := make(map[string]float64, len(sunnahStats))
+		for _, stat := range sunnahStats {
+			byDate[stat["date"].(string)] = stat["percentage"].(float64)
+		}
+		for _, stat := range prayerStats {
+			tarawihData = append(tarawihData, byDate[stat["date"].(string)])
+		}
+	}
+
 	// 4. Leaderboard (Top 10)
 	topStudents, err := s.UserRepo.GetTopStudents(10)
 	if err != nil {
@@ -71,6 +85,7 @@ func (s *StatisticsService) GetDashboardStats() (map[string]interface{}, error)
 	return map[string]interface{}{
 		"prayer_labels": labels,
 		"prayer_data":   data,
+		"tarawih_data":  tarawihData,
 		"amaliah_stats": amaliahStats,
 		"top_students":  topStudents,
 	}, nil
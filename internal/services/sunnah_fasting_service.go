@@ -0,0 +1,221 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/ramadhan/amaliah-monitoring/internal/hijri"
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+	"github.com/ramadhan/amaliah-monitoring/internal/repository"
+)
+
+// SunnahFastingKinds lists the tracked sunnah fasts. Streak marks the ones
+// that recur often enough for a streak.
+var SunnahFastingKinds = []struct {
+	Value       string
+	Label       string
+	Description string
+	Streak      bool
+}{
+	{"senin_kamis", "Senin-Kamis", "Setiap hari Senin dan Kamis", true},
+	{"ayyamul_bidh", "Ayyamul Bidh", "Tanggal 13, 14 dan 15 bulan Hijriah", true},
+	{"syawal", "Syawal", "Enam hari di bulan Syawal", false},
+	{"arafah", "Arafah", "9 Dzulhijjah", false},
+}
+
+const (
+	syawal      = 10
+	dzulhijjah  = 12
+	syawalDays  = 6
+	streakRange = 400 // days of history a streak looks back on
+)
+
+var (
+	ErrSunnahFastNotEligible = errors.New("tanggal ini bukan hari puasa sunnah")
+	ErrSunnahFastExcused     = errors.New("tanggal ini tercatat udzur")
+)
+
+// SunnahFastingKindsOn returns the sunnah fasts falling on t. Ramadhan and
+// the days fasting is forbidden have none.
+func SunnahFastingKindsOn(t time.Time, offset int) []string {
+	d := hijri.FromTime(t, offset)
+	if d.Month == hijri.Ramadhan || ForbiddenFastingDay(t, offset) {
+		return nil
+	}
+	var kinds []string
+	if wd := t.Weekday(); wd == time.Monday || wd == time.Thursday {
+		kinds = append(kinds, "senin_kamis")
+	}
+	if d.Day >= 13 && d.Day <= 15 {
+		kinds = append(kinds, "ayyamul_bidh")
+	}
+	if d.Month == syawal {
+		kinds = append(kinds, "syawal")
+	}
+	if d.Month == dzulhijjah && d.Day == 9 {
+		kinds = append(kinds, "arafah")
+	}
+	return kinds
+}
+
+// SunnahFastingLabels renders stored kinds for pages and reports
+func SunnahFastingLabels(kinds string) string {
+	var labels []string
+	for _, k := range SunnahFastingKinds {
+		for _, v := range strings.Split(kinds, ",") {
+			if v == k.Value {
+				labels = append(labels, k.Label)
+			}
+		}
+	}
+	return strings.Join(labels, ", ")
+}
+
+// SunnahFastingDay is one day of the calendar
+type SunnahFastingDay struct {
+	Date  string
+	Day   int
+	Hijri hijri.Date
+	Kinds []string
+}
+
+// Has reports whether kind falls on the day, for templates
+func (d SunnahFastingDay) Has(kind string) bool {
+	for _, k := range d.Kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// SunnahFastingCalendar lays out a Gregorian month with the sunnah fasts of
+// each day
+func SunnahFastingCalendar(year int, month time.Month, offset int) []SunnahFastingDay {
+	var days []SunnahFastingDay
+	for t := time.Date(year, month, 1, 0, 0, 0, 0, time.Local); t.Month() == month; t = t.AddDate(0, 0, 1) {
+		days = append(days, SunnahFastingDay{
+			Date:  t.Format("2006-01-02"),
+			Day:   t.Day(),
+			Hijri: hijri.FromTime(t, offset),
+			Kinds: SunnahFastingKindsOn(t, offset),
+		})
+	}
+	return days
+}
+
+// EligibleSunnahFastingDates lists the days between start and end on which
+// kind can be fasted, newest first
+func EligibleSunnahFastingDates(kind string, start, end time.Time, offset int) []string {
+	var dates []string
+	for t := end; !t.Before(start); t = t.AddDate(0, 0, -1) {
+		for _, k := range SunnahFastingKindsOn(t, offset) {
+			if k == kind {
+				dates = append(dates, t.Format("2006-01-02"))
+				break
+			}
+		}
+	}
+	return dates
+}
+
+// SunnahFastingStreak counts consecutive eligible days that were fasted.
+// eligible is newest first; today does not break the current streak as it
+// can still be filled in, and excused days are skipped.
+func SunnahFastingStreak(eligible []string, fasted, excused map[string]bool, today string) Streak {
+	var s Streak
+	run := 0
+	current := true
+	for _, d := range eligible {
+		if excused[d] {
+			continue
+		}
+		if fasted[d] {
+			run++
+			s.Best = max(s.Best, run)
+			if current {
+				s.Current = run
+			}
+			continue
+		}
+		if d == today && run == 0 {
+			continue
+		}
+		current = false
+		run = 0
+	}
+	return s
+}
+
+type SunnahFastingService struct {
+	Repo      *repository.SunnahFastRepository
+	UdzurRepo *repository.UdzurRepository
+}
+
+func NewSunnahFastingService(repo *repository.SunnahFastRepository, udzurRepo *repository.UdzurRepository) *SunnahFastingService {
+	return &SunnahFastingService{Repo: repo, UdzurRepo: udzurRepo}
+}
+
+// Log records a sunnah fast on date with the kinds the calendar gives it
+func (s *SunnahFastingService) Log(user *models.User, date string, offset int, now time.Time) (*models.SunnahFast, error) {
+	t, err := time.ParseInLocation("2006-01-02", date, now.Location())
+	if err != nil {
+		return nil, ErrEntryInvalidDate
+	}
+	if date > now.Format("2006-01-02") {
+		return nil, ErrEntryFutureDate
+	}
+	kinds := SunnahFastingKindsOn(t, offset)
+	if len(kinds) == 0 {
+		return nil, ErrSunnahFastNotEligible
+	}
+	if periods, err := s.UdzurRepo.GetInRange(user.ID, date, date); err != nil {
+		return nil, err
+	} else if len(periods) > 0 {
+		return nil, ErrSunnahFastExcused
+	}
+
+	f := &models.SunnahFast{UserID: user.ID, Date: date, Kinds: strings.Join(kinds, ",")}
+	if err := s.Repo.Save(f); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Streaks returns the streak of every kind that has one
+func (s *SunnahFastingService) Streaks(userID, offset int, now time.Time) (map[string]Streak, error) {
+	start := now.AddDate(0, 0, -streakRange)
+	startStr, today := start.Format("2006-01-02"), now.Format("2006-01-02")
+	list, err := s.Repo.GetByUserAndDateRange(userID, startStr, today)
+	if err != nil {
+		return nil, err
+	}
+	fasted := make(map[string]bool, len(list))
+	for _, f := range list {
+		fasted[f.Date] = true
+	}
+	periods, err := s.UdzurRepo.GetInRange(userID, startStr, today)
+	if err != nil {
+		return nil, err
+	}
+	excused := ExcusedDates(periods, startStr, today)
+
+	streaks := make(map[string]Streak)
+	for _, k := range SunnahFastingKinds {
+		if k.Streak {
+			streaks[k.Value] = SunnahFastingStreak(EligibleSunnahFastingDates(k.Value, start, now, offset), fasted, excused, today)
+		}
+	}
+	return streaks, nil
+}
+
+// SyawalProgress counts the Syawal fasts of the current Hijri year against
+// the six asked for
+func (s *SunnahFastingService) SyawalProgress(userID, offset int, now time.Time) (done, target int) {
+	year := hijri.FromTime(now, offset).Year
+	start := hijri.Date{Year: year, Month: syawal, Day: 1}.Time(offset)
+	end := start.AddDate(0, 0, hijri.MonthLength(year, syawal)-1)
+	list, _ := s.Repo.GetByUserAndDateRange(userID, start.Format("2006-01-02"), end.Format("2006-01-02"))
+	return min(len(list), syawalDays), syawalDays
+}
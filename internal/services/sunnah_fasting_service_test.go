@@ -0,0 +1,50 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ramadhan/amaliah-monitoring/internal/hijri"
+	"github.com/ramadhan/amaliah-monitoring/internal/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSunnahFastingKindsOn(t *testing.T) {
+	// 2026-10-19 is a Monday outside Ramadhan
+	monday := time.Date(2026, 10, 19, 0, 0, 0, 0, time.Local)
+	assert.Contains(t, services.SunnahFastingKindsOn(monday, 0), "senin_kamis")
+	assert.NotContains(t, services.SunnahFastingKindsOn(monday.AddDate(0, 0, 1), 0), "senin_kamis")
+
+	bidh := hijri.Date{Year: 1448, Month: 4, Day: 14}.Time(0)
+	assert.Contains(t, services.SunnahFastingKindsOn(bidh, 0), "ayyamul_bidh")
+
+	arafah := hijri.Date{Year: 1447, Month: 12, Day: 9}.Time(0)
+	assert.Contains(t, services.SunnahFastingKindsOn(arafah, 0), "arafah")
+
+	// Ramadhan, Idul Fitri and the days of tasyrik have no sunnah fasts
+	ramadhan := hijri.RamadhanOf(1447, 0)
+	assert.Empty(t, services.SunnahFastingKindsOn(ramadhan.Date(13), 0))
+	assert.Empty(t, services.SunnahFastingKindsOn(ramadhan.End().AddDate(0, 0, 1), 0))
+	assert.Empty(t, services.SunnahFastingKindsOn(arafah.AddDate(0, 0, 4), 0))
+	assert.Contains(t, services.SunnahFastingKindsOn(ramadhan.End().AddDate(0, 0, 2), 0), "syawal")
+}
+
+func TestSunnahFastingStreak(t *testing.T) {
+	eligible := []string{"2026-10-19", "2026-10-15", "2026-10-12", "2026-10-08", "2026-10-05", "2026-10-01"}
+	fasted := map[string]bool{"2026-10-15": true, "2026-10-12": true, "2026-10-05": true, "2026-10-01": true}
+	excused := map[string]bool{"2026-10-08": true}
+
+	// Today is still open and the excused day is skipped
+	s := services.SunnahFastingStreak(eligible, fasted, excused, "2026-10-19")
+	assert.Equal(t, 4, s.Current)
+	assert.Equal(t, 4, s.Best)
+
+	// A missed day ends the current streak
+	s = services.SunnahFastingStreak(eligible, fasted, nil, "2026-10-19")
+	assert.Equal(t, 2, s.Current)
+	assert.Equal(t, 2, s.Best)
+
+	s = services.SunnahFastingStreak(eligible, fasted, excused, "2026-10-20")
+	assert.Equal(t, 0, s.Current)
+	assert.Equal(t, 4, s.Best)
+}
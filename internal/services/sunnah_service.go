@@ -0,0 +1,166 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+	"github.com/ramadhan/amaliah-monitoring/internal/repository"
+)
+
+// SunnahKinds lists the tracked sunnah prayers and the column recording each
+var SunnahKinds = []struct {
+	Value  string
+	Label  string
+	Column string
+}{
+	{"tarawih", "Tarawih", "tarawih_rakaat"},
+	{"witir", "Witir", "witir_rakaat"},
+	{"dhuha", "Dhuha", "dhuha_rakaat"},
+	{"tahajud", "Tahajud", "tahajud_rakaat"},
+	{"rawatib", "Rawatib", "rawatib"},
+}
+
+// RawatibPrayers lists the rawatib muakkadah in the order of the day
+var RawatibPrayers = []struct {
+	Value string
+	Label string
+}{
+	{"qabliyah_subuh", "Qabliyah Subuh"},
+	{"qabliyah_dzuhur", "Qabliyah Dzuhur"},
+	{"badiyah_dzuhur", "Ba'diyah Dzuhur"},
+	{"badiyah_maghrib", "Ba'diyah Maghrib"},
+	{"badiyah_isya", "Ba'diyah Isya"},
+}
+
+// NormalizeRawatib validates rawatib keys and joins them in canonical order
+func NormalizeRawatib(keys []string) (string, error) {
+	chosen := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		chosen[k] = true
+	}
+	var out []string
+	for _, r := range RawatibPrayers {
+		if chosen[r.Value] {
+			out = append(out, r.Value)
+			delete(chosen, r.Value)
+		}
+	}
+	for k := range chosen {
+		return "", fmt.Errorf("shalat rawatib tidak dikenal: %s", k)
+	}
+	return strings.Join(out, ","), nil
+}
+
+// RawatibLabels renders a stored rawatib list for reports
+func RawatibLabels(rawatib string) string {
+	var labels []string
+	for _, r := range RawatibPrayers {
+		for _, k := range strings.Split(rawatib, ",") {
+			if k == r.Value {
+				labels = append(labels, r.Label)
+			}
+		}
+	}
+	return strings.Join(labels, ", ")
+}
+
+// ValidateSunnahPrayer checks the rakaat counts of a day; tarawih details are
+// cleared when tarawih was not prayed
+func ValidateSunnahPrayer(p *models.SunnahPrayer) error {
+	even := func(name string, n, max int) error {
+		if n != 0 && (n < 2 || n > max || n%2 != 0) {
+			return fmt.Errorf("rakaat %s harus genap antara 2-%d", name, max)
+		}
+		return nil
+	}
+	if err := even("tarawih", p.TarawihRakaat, 20); err != nil {
+		return err
+	}
+	if err := even("dhuha", p.DhuhaRakaat, 12); err != nil {
+		return err
+	}
+	if err := even("tahajud", p.TahajudRakaat, 12); err != nil {
+		return err
+	}
+	if p.WitirRakaat != 0 && (p.WitirRakaat < 1 || p.WitirRakaat > 11 || p.WitirRakaat%2 == 0) {
+		return fmt.Errorf("rakaat witir harus ganjil antara 1-11")
+	}
+
+	if p.TarawihRakaat == 0 {
+		p.TarawihPlace, p.TarawihMode = "", ""
+		return nil
+	}
+	if p.TarawihPlace != "masjid" && p.TarawihPlace != "rumah" {
+		return fmt.Errorf("pilih tempat tarawih: masjid atau rumah")
+	}
+	if p.TarawihMode != "jamaah" && p.TarawihMode != "sendiri" {
+		return fmt.Errorf("pilih tarawih berjamaah atau sendiri")
+	}
+	return nil
+}
+
+// Streak is a run of consecutive days
+type Streak struct {
+	Current int `json:"current"`
+	Best    int `json:"best"`
+}
+
+// SunnahStreak computes streaks from distinct dates, newest first. The
+// current streak may end yesterday since today can still be filled in.
+func SunnahStreak(dates []string, today time.Time) Streak {
+	var s Streak
+	run := 0
+	var prev time.Time
+	for i, d := range dates {
+		day, err := time.ParseInLocation("2006-01-02", d, today.Location())
+		if err != nil {
+			continue
+		}
+		if run > 0 && prev.AddDate(0, 0, -1).Format("2006-01-02") == d {
+			run++
+		} else {
+			run = 1
+		}
+		prev = day
+		s.Best = max(s.Best, run)
+
+		if i == run-1 {
+			// still in the run that starts at the newest date
+			gap := int(today.Sub(day).Hours()/24) - (run - 1)
+			if gap <= 1 {
+				s.Current = run
+			}
+		}
+	}
+	return s
+}
+
+type SunnahService struct {
+	Repo *repository.SunnahPrayerRepository
+}
+
+func NewSunnahService(repo *repository.SunnahPrayerRepository) *SunnahService {
+	return &SunnahService{Repo: repo}
+}
+
+func (s *SunnahService) Save(p *models.SunnahPrayer) error {
+	if err := ValidateSunnahPrayer(p); err != nil {
+		return err
+	}
+	return s.Repo.Save(p)
+}
+
+// Streaks returns the streak of every sunnah prayer keyed by kind
+func (s *SunnahService) Streaks(userID int, today time.Time) (map[string]Streak, error) {
+	streaks := make(map[string]Streak, len(SunnahKinds))
+	for _, k := range SunnahKinds {
+		dates, err := s.Repo.GetDates(userID, k.Column, 400)
+		if err != nil {
+			return nil, err
+		}
+		streaks[k.Value] = SunnahStreak(dates, today)
+	}
+	return streaks, nil
+}
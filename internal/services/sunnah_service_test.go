@@ -0,0 +1,39 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+	"github.com/ramadhan/amaliah-monitoring/internal/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSunnahStreak(t *testing.T) {
+	today := time.Date(2026, 3, 10, 15, 0, 0, 0, time.Local)
+
+	s := services.SunnahStreak([]string{"2026-03-09", "2026-03-08", "2026-03-07", "2026-03-03", "2026-03-02", "2026-03-01", "2026-02-28"}, today)
+	assert.Equal(t, 3, s.Current, "streak ending yesterday still counts")
+	assert.Equal(t, 4, s.Best)
+
+	s = services.SunnahStreak([]string{"2026-03-07", "2026-03-06"}, today)
+	assert.Equal(t, 0, s.Current)
+	assert.Equal(t, 2, s.Best)
+
+	assert.Equal(t, services.Streak{}, services.SunnahStreak(nil, today))
+}
+
+func TestValidateSunnahPrayer(t *testing.T) {
+	valid := &models.SunnahPrayer{TarawihRakaat: 8, TarawihPlace: "masjid", TarawihMode: "jamaah", WitirRakaat: 3, DhuhaRakaat: 4}
+	assert.NoError(t, services.ValidateSunnahPrayer(valid))
+
+	assert.Error(t, services.ValidateSunnahPrayer(&models.SunnahPrayer{TarawihRakaat: 7, TarawihPlace: "masjid", TarawihMode: "jamaah"}))
+	assert.Error(t, services.ValidateSunnahPrayer(&models.SunnahPrayer{WitirRakaat: 4}))
+	assert.Error(t, services.ValidateSunnahPrayer(&models.SunnahPrayer{TahajudRakaat: 14}))
+	assert.Error(t, services.ValidateSunnahPrayer(&models.SunnahPrayer{TarawihRakaat: 20}), "tarawih needs a place")
+
+	p := &models.SunnahPrayer{TarawihPlace: "rumah", TarawihMode: "sendiri"}
+	assert.NoError(t, services.ValidateSunnahPrayer(p))
+	assert.Empty(t, p.TarawihPlace)
+	assert.Empty(t, p.TarawihMode)
+}
@@ -0,0 +1,400 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+	"github.com/ramadhan/amaliah-monitoring/internal/repository"
+)
+
+// MaxSyncBatch limits how many queued entries one request may carry
+const MaxSyncBatch = 100
+
+// Client clocks may run slightly ahead of the server; anything further in the future is rejected
+const syncClockSkew = 5 * time.Minute
+
+// An entry reaching the server within syncOfflineGrace of its client timestamp
+// is judged by the policy as of that timestamp; older ones are judged as of
+// their arrival and, for past days, wait for a teacher's approval
+const syncOfflineGrace = time.Hour
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+type SyncService struct {
+	SyncRepo    *repository.SyncRepository
+	UserRepo    *repository.UserRepository
+	PrayerRepo  *repository.PrayerRepository
+	FastingRepo *repository.FastingRepository
+	QuranRepo   *repository.QuranRepository
+	AmaliahRepo *repository.AmaliahRepository
+	// PrayerTiming, when set, classifies prayer changes by the time the
+	// server received them
+	PrayerTiming *PrayerTimingService
+	// Policy, when set, applies the school's backdating window, locks and
+	// approval rule to every entry
+	Policy *EntryPolicyService
+}
+
+func NewSyncService(
+	syncRepo *repository.SyncRepository,
+	userRepo *repository.UserRepository,
+	prayerRepo *repository.PrayerRepository,
+	fastingRepo *repository.FastingRepository,
+	quranRepo *repository.QuranRepository,
+	amaliahRepo *repository.AmaliahRepository,
+) *SyncService {
+	return &SyncService{
+		SyncRepo:    syncRepo,
+		UserRepo:    userRepo,
+		PrayerRepo:  prayerRepo,
+		FastingRepo: fastingRepo,
+		QuranRepo:   quranRepo,
+		AmaliahRepo: amaliahRepo,
+	}
+}
+
+// syncRejection is an item-level validation failure; it is reported to the client
+// and recorded so a retry of the same client_id gets the same answer.
+type syncRejection struct {
+	msg string
+}
+
+func (e *syncRejection) Error() string { return e.msg }
+
+func reject(format string, args ...interface{}) error {
+	return &syncRejection{msg: fmt.Sprintf(format, args...)}
+}
+
+// Apply processes a batch in order. Every item gets exactly one result; items are
+// idempotent on client_id and resolved last-writer-wins on client_timestamp.
+func (s *SyncService) Apply(userID int, items []models.SyncItem) []models.SyncResult {
+	results := make([]models.SyncResult, 0, len(items))
+	for _, item := range items {
+		results = append(results, s.applyItem(userID, item))
+	}
+	return results
+}
+
+func (s *SyncService) applyItem(userID int, item models.SyncItem) models.SyncResult {
+	result := models.SyncResult{ClientID: item.ClientID}
+	receivedAt := time.Now()
+
+	if !uuidPattern.MatchString(item.ClientID) {
+		result.Status = "rejected"
+		result.Message = "client_id harus berupa UUID"
+		return result
+	}
+
+	// Idempotency: a client_id we have already seen returns its original outcome
+	if prev, err := s.SyncRepo.GetByClientID(userID, item.ClientID); err == nil {
+		result.Status = "duplicate"
+		result.Message = prev.Status
+		result.ServerTimestamp = prev.CreatedAt.UTC().Format(time.RFC3339)
+		return result
+	}
+
+	clientTs, err := time.Parse(time.RFC3339, item.ClientTimestamp)
+	if err != nil {
+		result.Status = "rejected"
+		result.Message = "client_timestamp tidak valid (RFC3339)"
+		return result
+	}
+	clientTs = clientTs.UTC()
+	if clientTs.After(time.Now().Add(syncClockSkew)) {
+		result.Status = "rejected"
+		result.Message = "client_timestamp berada di masa depan"
+		return result
+	}
+
+	date := item.Date
+	if date == "" {
+		date = clientTs.In(time.Local).Format("2006-01-02")
+	}
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		result.Status = "rejected"
+		result.Message = "format tanggal tidak valid"
+		return result
+	}
+
+	entityKey, serverTs, err := s.entityState(userID, item, date)
+	if err != nil {
+		result.Status = "rejected"
+		result.Message = err.Error()
+		s.record(userID, item, entityKey, clientTs, result)
+		return result
+	}
+
+	if latest, _ := s.SyncRepo.GetLatestApplied(userID, item.Type, entityKey); latest != "" {
+		if t, err := time.Parse(time.RFC3339, latest); err == nil && t.After(serverTs) {
+			serverTs = t
+		}
+	}
+
+	// Last writer wins: an older offline edit never overwrites a newer one
+	if !serverTs.IsZero() && clientTs.Before(serverTs) {
+		result.Status = "conflict"
+		result.Message = "data di server lebih baru"
+		result.ServerTimestamp = serverTs.Format(time.RFC3339)
+		s.record(userID, item, entityKey, clientTs, result)
+		return result
+	}
+
+	if s.Policy != nil {
+		user, err := s.UserRepo.GetByID(userID)
+		if err != nil {
+			result.Status = "error"
+			result.Message = "gagal menyimpan data"
+			return result
+		}
+		// A client clock cannot backdate an entry past the offline grace
+		judgedAt := clientTs
+		stale := receivedAt.Sub(clientTs) > syncOfflineGrace
+		if stale {
+			judgedAt = receivedAt
+		}
+		late, err := s.Policy.Check(user, date, judgedAt.In(time.Local))
+		if err == nil && stale && date < receivedAt.In(time.Local).Format("2006-01-02") {
+			late = true
+		}
+		if err != nil {
+			result.Status = "rejected"
+			result.Message = err.Error()
+			s.record(userID, item, entityKey, clientTs, result)
+			return result
+		}
+		if late {
+			if err := s.Policy.Queue(user, item.Type, date, item.Data); err != nil {
+				result.Status = "error"
+				result.Message = "gagal menyimpan data"
+				return result
+			}
+			result.Status = "queued"
+			result.Message = "menunggu persetujuan guru"
+			s.record(userID, item, entityKey, clientTs, result)
+			return result
+		}
+	}
+
+	if err := s.write(userID, item, date, clientTs, receivedAt); err != nil {
+		if rej, ok := err.(*syncRejection); ok {
+			result.Status = "rejected"
+			result.Message = rej.msg
+			s.record(userID, item, entityKey, clientTs, result)
+			return result
+		}
+		// Storage errors are not recorded so the client can retry the same entry
+		result.Status = "error"
+		result.Message = "gagal menyimpan data"
+		return result
+	}
+
+	result.Status = "applied"
+	result.ServerTimestamp = clientTs.Format(time.RFC3339)
+	s.record(userID, item, entityKey, clientTs, result)
+	return result
+}
+
+// entityState returns the key used for conflict detection and the time the server
+// copy of that entity was last modified (zero if it does not exist).
+func (s *SyncService) entityState(userID int, item models.SyncItem, date string) (string, time.Time, error) {
+	switch item.Type {
+	case "prayer":
+		if p, err := s.PrayerRepo.GetByUserAndDate(userID, date); err == nil {
+			return date, p.UpdatedAt.UTC(), nil
+		}
+		return date, time.Time{}, nil
+	case "fasting":
+		if t, err := s.FastingRepo.GetUpdatedAt(userID, date); err == nil {
+			return date, t.UTC(), nil
+		}
+		return date, time.Time{}, nil
+	case "quran":
+		// Readings are append-only, each client entry is its own entity
+		return item.ClientID, time.Time{}, nil
+	case "amaliah":
+		var data models.SyncAmaliahData
+		if err := json.Unmarshal(item.Data, &data); err != nil {
+			return "", time.Time{}, reject("data amaliah tidak valid")
+		}
+		key := strconv.Itoa(data.AmaliahTypeID) + ":" + date
+		if da, err := s.AmaliahRepo.GetDailyAmaliahByType(userID, data.AmaliahTypeID, date); err == nil {
+			return key, da.CreatedAt.UTC(), nil
+		}
+		return key, time.Time{}, nil
+	}
+	return "", time.Time{}, reject("tipe data tidak dikenal: %s", item.Type)
+}
+
+// write stores the item. clientTs orders it against other edits; receivedAt
+// is when the server got it, which a client clock cannot move.
+func (s *SyncService) write(userID int, item models.SyncItem, date string, clientTs, receivedAt time.Time) error {
+	switch item.Type {
+	case "prayer":
+		if err := s.writePrayer(userID, item.Data, date, receivedAt); err != nil {
+			return err
+		}
+		return s.PrayerRepo.SetUpdatedAt(userID, date, clientTs)
+	case "fasting":
+		if err := s.writeFasting(userID, item.Data, date); err != nil {
+			return err
+		}
+		return s.FastingRepo.SetUpdatedAt(userID, date, clientTs)
+	case "quran":
+		return s.writeQuran(userID, item.Data, date, clientTs)
+	case "amaliah":
+		return s.writeAmaliah(userID, item.Data, date)
+	}
+	return reject("tipe data tidak dikenal: %s", item.Type)
+}
+
+func (s *SyncService) writePrayer(userID int, raw json.RawMessage, date string, receivedAt time.Time) error {
+	var data models.SyncPrayerData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return reject("data shalat tidak valid")
+	}
+
+	pending := models.PrayerPending
+	current := &models.Prayer{Subuh: pending, Dzuhur: pending, Ashar: pending, Maghrib: pending, Isya: pending}
+	var before *models.Prayer
+	if existing, err := s.PrayerRepo.GetByUserAndDate(userID, date); err == nil {
+		saved := *existing
+		before, current = &saved, existing
+	}
+
+	// Only fields present in the payload are changed
+	fields := []struct {
+		value  *string
+		target *models.PrayerStatus
+		name   string
+	}{
+		{data.Subuh, &current.Subuh, "subuh"},
+		{data.Dzuhur, &current.Dzuhur, "dzuhur"},
+		{data.Ashar, &current.Ashar, "ashar"},
+		{data.Maghrib, &current.Maghrib, "maghrib"},
+		{data.Isya, &current.Isya, "isya"},
+	}
+	for _, f := range fields {
+		if f.value == nil {
+			continue
+		}
+		status, err := models.ParsePrayerStatus(f.name, *f.value)
+		if err != nil {
+			return reject("%v", err)
+		}
+		*f.target = status
+	}
+
+	if s.PrayerTiming == nil {
+		return s.PrayerRepo.CreateOrUpdate(userID, date, current.Subuh, current.Dzuhur, current.Ashar, current.Maghrib, current.Isya)
+	}
+
+	user, err := s.UserRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	timings, err := s.PrayerTiming.Evaluate(user, date, before, current, receivedAt)
+	if err != nil {
+		return reject("%v", err)
+	}
+	if err := s.PrayerRepo.CreateOrUpdate(userID, date, current.Subuh, current.Dzuhur, current.Ashar, current.Maghrib, current.Isya); err != nil {
+		return err
+	}
+	return s.PrayerTiming.Record(timings)
+}
+
+func (s *SyncService) writeFasting(userID int, raw json.RawMessage, date string) error {
+	var data models.SyncFastingData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return reject("data puasa tidak valid")
+	}
+	status, err := models.ParseFastingStatus(data.Status)
+	if err != nil {
+		return reject("%v", err)
+	}
+	return s.FastingRepo.CreateOrUpdate(userID, date, status, data.Reason)
+}
+
+func (s *SyncService) writeQuran(userID int, raw json.RawMessage, date string, clientTs time.Time) error {
+	var data models.SyncQuranData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return reject("data bacaan tidak valid")
+	}
+
+	reading := &models.QuranReading{
+		UserID:         userID,
+		Date:           date,
+		StartSurahID:   data.StartSurahID,
+		StartSurahName: data.StartSurahName,
+		StartAyah:      data.StartAyah,
+		EndSurahID:     data.EndSurahID,
+		EndSurahName:   data.EndSurahName,
+		EndAyah:        data.EndAyah,
+		Notes:          data.Notes,
+	}
+	// data.Pages from older clients is ignored in favour of the computed count
+	if err := FillQuranCoverage(reading); err != nil {
+		return reject("%v", err)
+	}
+	if err := s.QuranRepo.Create(reading); err != nil {
+		return err
+	}
+	return s.QuranRepo.SaveBookmark(&models.QuranBookmark{
+		UserID: userID, SurahID: data.EndSurahID, Ayah: data.EndAyah, Source: "reading", UpdatedAt: clientTs,
+	})
+}
+
+func (s *SyncService) writeAmaliah(userID int, raw json.RawMessage, date string) error {
+	var data models.SyncAmaliahData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return reject("data amaliah tidak valid")
+	}
+
+	amaliahType, err := s.AmaliahRepo.GetTypeByID(data.AmaliahTypeID)
+	if err != nil {
+		return reject("jenis amaliah tidak ditemukan")
+	}
+
+	existing, _ := s.AmaliahRepo.GetDailyAmaliahByType(userID, data.AmaliahTypeID, date)
+
+	switch data.Action {
+	case "remove":
+		if existing == nil {
+			return nil
+		}
+		if err := s.AmaliahRepo.DeleteDailyAmaliah(existing.ID); err != nil {
+			return err
+		}
+		return s.UserRepo.UpdatePoints(userID, -amaliahType.Points)
+	case "add", "":
+		if existing != nil {
+			return nil
+		}
+		err := s.AmaliahRepo.CreateDailyAmaliah(&models.DailyAmaliah{
+			UserID:        userID,
+			AmaliahTypeID: data.AmaliahTypeID,
+			Date:          date,
+			Notes:         data.Notes,
+		})
+		if err != nil {
+			return err
+		}
+		return s.UserRepo.UpdatePoints(userID, amaliahType.Points)
+	}
+	return reject("aksi amaliah tidak valid: %s", data.Action)
+}
+
+func (s *SyncService) record(userID int, item models.SyncItem, entityKey string, clientTs time.Time, result models.SyncResult) {
+	s.SyncRepo.Create(&models.SyncEntry{
+		UserID:          userID,
+		ClientID:        item.ClientID,
+		EntityType:      item.Type,
+		EntityKey:       entityKey,
+		ClientTimestamp: clientTs.Format(time.RFC3339),
+		Status:          result.Status,
+		Message:         result.Message,
+	})
+}
@@ -0,0 +1,115 @@
+package services_test
+
+import (
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ramadhan/amaliah-monitoring/internal/config"
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+	"github.com/ramadhan/amaliah-monitoring/internal/repository"
+	"github.com/ramadhan/amaliah-monitoring/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newSyncService migrates an in-memory database and returns a sync engine
+// with the default entry policy, plus the id of a student
+func newSyncService(t *testing.T) (*services.SyncService, int) {
+	db, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	// Every connection to :memory: is a new database
+	db.SetMaxOpenConns(1)
+	require.NoError(t, config.RunMigrations(db))
+
+	res, err := db.Exec(`INSERT INTO users (username, email, password_hash, full_name, class)
+			  VALUES ('siswa', 'siswa@example.com', 'x', 'Siswa', '7A')`)
+	require.NoError(t, err)
+	userID, _ := res.LastInsertId()
+
+	userRepo := repository.NewUserRepository(db)
+	s := services.NewSyncService(repository.NewSyncRepository(db), userRepo, repository.NewPrayerRepository(db),
+		repository.NewFastingRepository(db), repository.NewQuranRepository(db), repository.NewAmaliahRepository(db))
+	policy := services.NewEntryPolicyService(repository.NewEntryPolicyRepository(db))
+	policy.Sync = s
+	s.Policy = policy
+	return s, int(userID)
+}
+
+func fastingItem(clientID, date string, at time.Time, status string) models.SyncItem {
+	data, _ := json.Marshal(models.SyncFastingData{Status: status})
+	return models.SyncItem{
+		ClientID:        clientID,
+		Type:            "fasting",
+		Date:            date,
+		ClientTimestamp: at.UTC().Format(time.RFC3339),
+		Data:            data,
+	}
+}
+
+func TestSyncApply(t *testing.T) {
+	now := time.Now()
+	today := now.Format("2006-01-02")
+	yesterday := now.AddDate(0, 0, -1).Format("2006-01-02")
+	const (
+		idA = "5b0a3c1e-8f4d-4c2a-9e57-1d2f3a4b5c6d"
+		idB = "7c1b4d2f-9a5e-4d3b-8f68-2e3a4b5c6d7e"
+	)
+
+	tests := []struct {
+		name  string
+		items []models.SyncItem
+		want  []string
+	}{
+		{
+			name: "replaying a client_id returns the first outcome",
+			items: []models.SyncItem{
+				fastingItem(idA, today, now.Add(-time.Minute), "puasa"),
+				fastingItem(idA, today, now.Add(-time.Minute), "puasa"),
+			},
+			want: []string{"applied", "duplicate"},
+		},
+		{
+			name: "an older edit loses to a newer one",
+			items: []models.SyncItem{
+				fastingItem(idA, today, now.Add(-10*time.Minute), "puasa"),
+				fastingItem(idB, today, now.Add(-20*time.Minute), "tidak"),
+			},
+			want: []string{"applied", "conflict"},
+		},
+		{
+			name: "a stale entry for a past day is queued",
+			items: []models.SyncItem{
+				fastingItem(idA, yesterday, now.Add(-25*time.Hour), "puasa"),
+			},
+			want: []string{"queued"},
+		},
+		{
+			name: "an entry within the offline grace keeps its day",
+			items: []models.SyncItem{
+				fastingItem(idA, yesterday, now.Add(-30*time.Minute), "puasa"),
+			},
+			want: []string{"applied"},
+		},
+		{
+			name: "a timestamp far in the future is rejected",
+			items: []models.SyncItem{
+				fastingItem(idA, today, now.Add(time.Hour), "puasa"),
+			},
+			want: []string{"rejected"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, userID := newSyncService(t)
+			results := s.Apply(userID, tt.items)
+			require.Len(t, results, len(tt.want))
+			for i, want := range tt.want {
+				assert.Equal(t, want, results[i].Status, results[i].Message)
+			}
+		})
+	}
+}
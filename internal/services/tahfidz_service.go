@@ -0,0 +1,178 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+	"github.com/ramadhan/amaliah-monitoring/internal/mushaf"
+	"github.com/ramadhan/amaliah-monitoring/internal/repository"
+)
+
+// murajaahIntervals[level] is the number of days until a range at that
+// level is reviewed again (a Leitner-style spaced repetition)
+var murajaahIntervals = []int{1, 2, 4, 7, 14, 30}
+
+// MaxHafalanLevel is the strongest level a memorized range can reach
+var MaxHafalanLevel = len(murajaahIntervals) - 1
+
+// HafalanResults lists the review outcomes a student can log
+var HafalanResults = []struct {
+	Value string
+	Label string
+}{
+	{"lancar", "Lancar"},
+	{"ragu", "Ragu-ragu"},
+	{"lupa", "Lupa"},
+}
+
+var ErrInvalidHafalanResult = errors.New("hasil murajaah tidak valid")
+
+// NextHafalanLevel applies a review result: lancar moves a level up, ragu a
+// level down and lupa starts the range over
+func NextHafalanLevel(level int, result string) (int, error) {
+	switch result {
+	case "lancar":
+		return min(level+1, MaxHafalanLevel), nil
+	case "ragu":
+		return max(level-1, 0), nil
+	case "lupa":
+		return 0, nil
+	}
+	return level, ErrInvalidHafalanResult
+}
+
+// NextReviewDate returns the date a range at level is due after reviewing it on day
+func NextReviewDate(level int, day time.Time) string {
+	level = min(max(level, 0), MaxHafalanLevel)
+	return day.AddDate(0, 0, murajaahIntervals[level]).Format("2006-01-02")
+}
+
+type TahfidzService struct {
+	Repo *repository.HafalanRepository
+}
+
+func NewTahfidzService(repo *repository.HafalanRepository) *TahfidzService {
+	return &TahfidzService{Repo: repo}
+}
+
+// Add records a newly memorized range; its first murajaah is the next day
+func (s *TahfidzService) Add(userID int, r mushaf.Range, today time.Time) (*models.Hafalan, error) {
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+
+	h := &models.Hafalan{
+		UserID:       userID,
+		StartSurahID: r.StartSurah,
+		StartAyah:    r.StartAyah,
+		EndSurahID:   r.EndSurah,
+		EndAyah:      r.EndAyah,
+		NextReview:   NextReviewDate(0, today),
+	}
+	if err := s.Repo.Create(h); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// Review logs a murajaah result and reschedules the range
+func (s *TahfidzService) Review(userID, hafalanID int, result string, today time.Time) (*models.Hafalan, error) {
+	h, err := s.Repo.GetByID(userID, hafalanID)
+	if err != nil {
+		return nil, err
+	}
+	level, err := NextHafalanLevel(h.Level, result)
+	if err != nil {
+		return nil, err
+	}
+
+	h.Level = level
+	h.LastReviewed = today.Format("2006-01-02")
+	h.NextReview = NextReviewDate(level, today)
+	if err := s.Repo.UpdateSchedule(h); err != nil {
+		return nil, err
+	}
+
+	err = s.Repo.CreateReview(&models.HafalanReview{
+		HafalanID: h.ID,
+		UserID:    userID,
+		Date:      h.LastReviewed,
+		Result:    result,
+		Level:     level,
+	})
+	return h, err
+}
+
+// Due returns today's murajaah list: every range due today or overdue
+func (s *TahfidzService) Due(userID int, today time.Time) ([]*models.Hafalan, error) {
+	return s.Repo.GetDue(userID, today.Format("2006-01-02"))
+}
+
+// JuzHafalan is the memorization of one juz. Level is the weakest level among
+// the ranges touching it, or -1 when nothing in the juz is memorized.
+type JuzHafalan struct {
+	PartCoverage
+	Level int `json:"level"`
+}
+
+// HafalanMap summarises memorized ayat per juz
+type HafalanMap struct {
+	Ayahs int          `json:"ayahs"`
+	Juz   []JuzHafalan `json:"juz"`
+}
+
+func BuildHafalanMap(items []*models.Hafalan) HafalanMap {
+	set := &mushaf.Set{}
+	weakest := make([]int, mushaf.JuzCount)
+	for i := range weakest {
+		weakest[i] = -1
+	}
+
+	for _, h := range items {
+		r := mushaf.Range{StartSurah: h.StartSurahID, StartAyah: h.StartAyah, EndSurah: h.EndSurahID, EndAyah: h.EndAyah}
+		if r.Validate() != nil {
+			continue
+		}
+		from, to := mushaf.Index(r.StartSurah, r.StartAyah), mushaf.Index(r.EndSurah, r.EndAyah)
+		set.Add(from, to)
+		for j := mushaf.JuzOf(r.StartSurah, r.StartAyah); j <= mushaf.JuzOf(r.EndSurah, r.EndAyah); j++ {
+			if weakest[j-1] < 0 || h.Level < weakest[j-1] {
+				weakest[j-1] = h.Level
+			}
+		}
+	}
+
+	m := HafalanMap{Ayahs: set.Len(), Juz: make([]JuzHafalan, mushaf.JuzCount)}
+	for n := 1; n <= mushaf.JuzCount; n++ {
+		m.Juz[n-1] = JuzHafalan{PartCoverage: partCoverage(set, n, mushaf.JuzSpan(n)), Level: weakest[n-1]}
+	}
+	return m
+}
+
+// SurahNames maps surah numbers to their Indonesian names
+func SurahNames(list []Surah) map[int]string {
+	names := make(map[int]string, len(list))
+	for _, s := range list {
+		if n, err := strconv.Atoi(s.Number); err == nil {
+			names[n] = s.NameID
+		}
+	}
+	return names
+}
+
+// RangeLabel renders a range as "Al-Mulk 1-15" or "An-Naba 1 → An-Nazi'at 10"
+func RangeLabel(names map[int]string, r mushaf.Range) string {
+	name := func(id int) string {
+		if n, ok := names[id]; ok {
+			return n
+		}
+		return fmt.Sprintf("Surah %d", id)
+	}
+	if r.StartSurah == r.EndSurah {
+		return fmt.Sprintf("%s %d-%d", name(r.StartSurah), r.StartAyah, r.EndAyah)
+	}
+	return fmt.Sprintf("%s %d → %s %d", name(r.StartSurah), r.StartAyah, name(r.EndSurah), r.EndAyah)
+}
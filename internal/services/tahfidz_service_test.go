@@ -0,0 +1,64 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+	"github.com/ramadhan/amaliah-monitoring/internal/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHafalanSchedule(t *testing.T) {
+	day := time.Date(2025, 3, 10, 0, 0, 0, 0, time.Local)
+
+	level := 0
+	var dates []string
+	for i := 0; i < 7; i++ {
+		level, _ = services.NextHafalanLevel(level, "lancar")
+		dates = append(dates, services.NextReviewDate(level, day))
+	}
+	assert.Equal(t, services.MaxHafalanLevel, level)
+	assert.Equal(t, []string{"2025-03-12", "2025-03-14", "2025-03-17", "2025-03-24", "2025-04-09", "2025-04-09", "2025-04-09"}, dates)
+
+	level, _ = services.NextHafalanLevel(3, "ragu")
+	assert.Equal(t, 2, level)
+	level, _ = services.NextHafalanLevel(3, "lupa")
+	assert.Equal(t, 0, level)
+	assert.Equal(t, "2025-03-11", services.NextReviewDate(level, day))
+
+	_, err := services.NextHafalanLevel(3, "hafal")
+	assert.ErrorIs(t, err, services.ErrInvalidHafalanResult)
+}
+
+func TestHafalanMap(t *testing.T) {
+	m := services.BuildHafalanMap([]*models.Hafalan{
+		{StartSurahID: 78, StartAyah: 1, EndSurahID: 114, EndAyah: 6, Level: 4},
+		{StartSurahID: 67, StartAyah: 1, EndSurahID: 67, EndAyah: 30, Level: 1},
+		{StartSurahID: 67, StartAyah: 10, EndSurahID: 67, EndAyah: 20, Level: 3}, // overlaps
+	})
+
+	assert.True(t, m.Juz[29].Done())
+	assert.Equal(t, 4, m.Juz[29].Level)
+	assert.Equal(t, 30, m.Juz[28].Covered)
+	assert.Equal(t, 1, m.Juz[28].Level)
+	assert.Equal(t, -1, m.Juz[0].Level)
+	assert.Equal(t, m.Juz[29].Total+30, m.Ayahs)
+}
+
+func TestSetoranGrading(t *testing.T) {
+	points, err := services.SetoranPoints("lancar")
+	assert.NoError(t, err)
+	assert.Equal(t, 20, points)
+	points, _ = services.SetoranPoints("ulang")
+	assert.Equal(t, 0, points)
+	_, err = services.SetoranPoints("bagus")
+	assert.ErrorIs(t, err, services.ErrInvalidSetoranGrade)
+
+	setoran := &models.Setoran{SchoolID: 3}
+	assert.True(t, services.CanGradeSetoran(&models.User{Role: "guru", SchoolID: 3}, setoran))
+	assert.True(t, services.CanGradeSetoran(&models.User{Role: "superadmin"}, setoran))
+	assert.False(t, services.CanGradeSetoran(&models.User{Role: "guru", SchoolID: 4}, setoran))
+	assert.False(t, services.CanGradeSetoran(&models.User{Role: "user", SchoolID: 3}, setoran))
+	assert.False(t, services.CanGradeSetoran(&models.User{Role: "admin"}, &models.Setoran{}))
+}
@@ -0,0 +1,215 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+	"github.com/ramadhan/amaliah-monitoring/internal/repository"
+)
+
+// UdzurKinds lists the udzur that excuse both prayer and fasting, with the
+// longest each may last following the Syafi'i maxima
+var UdzurKinds = []struct {
+	Value   string
+	Label   string
+	MaxDays int
+}{
+	{"haid", "Haid", 15},
+	{"nifas", "Nifas", 60},
+}
+
+// UdzurLabel is what teachers see for an excused day instead of the entries
+const UdzurLabel = "udzur"
+
+var (
+	ErrUdzurKind       = errors.New("jenis udzur tidak dikenal")
+	ErrUdzurOngoing    = errors.New("masih ada udzur yang belum diakhiri")
+	ErrUdzurOverlap    = errors.New("tanggal bertabrakan dengan udzur yang sudah dicatat")
+	ErrUdzurEndsEarly  = errors.New("tanggal selesai tidak boleh sebelum tanggal mulai")
+	ErrUdzurNotOngoing = errors.New("udzur ini sudah diakhiri")
+)
+
+// UdzurTooLongError rejects a period longer than its kind allows
+type UdzurTooLongError struct {
+	Label   string
+	MaxDays int
+}
+
+func (e *UdzurTooLongError) Error() string {
+	return fmt.Sprintf("%s paling lama %d hari", e.Label, e.MaxDays)
+}
+
+func udzurKind(kind string) (label string, maxDays int, ok bool) {
+	for _, k := range UdzurKinds {
+		if k.Value == kind {
+			return k.Label, k.MaxDays, true
+		}
+	}
+	return "", 0, false
+}
+
+// UdzurKindLabel returns the display label of a kind
+func UdzurKindLabel(kind string) string {
+	if label, _, ok := udzurKind(kind); ok {
+		return label
+	}
+	return kind
+}
+
+// TeacherFastingReason is the reason of a missed fast as teachers see it:
+// haid and nifas read as UdzurLabel, like a day inside an udzur period
+func TeacherFastingReason(reason string) string {
+	if _, _, ok := udzurKind(reason); ok {
+		return UdzurLabel
+	}
+	return reason
+}
+
+// ExcusedDates expands periods into the set of excused dates between start
+// and end
+func ExcusedDates(periods []*models.UdzurPeriod, start, end string) map[string]bool {
+	excused := make(map[string]bool)
+	for _, p := range periods {
+		from, to := p.StartDate, p.EndDate
+		if from < start {
+			from = start
+		}
+		if to > end {
+			to = end
+		}
+		day, err := time.Parse("2006-01-02", from)
+		if err != nil {
+			continue
+		}
+		for d := day.Format("2006-01-02"); d <= to; d = day.Format("2006-01-02") {
+			excused[d] = true
+			day = day.AddDate(0, 0, 1)
+		}
+	}
+	return excused
+}
+
+type UdzurService struct {
+	Repo *repository.UdzurRepository
+	// Policy, when set, applies the school's backdating window and locks to
+	// new periods
+	Policy *EntryPolicyService
+}
+
+func NewUdzurService(repo *repository.UdzurRepository) *UdzurService {
+	return &UdzurService{Repo: repo}
+}
+
+// Add records a period starting on start. An empty end leaves it ongoing
+// until the student ends it or its kind's longest duration runs out.
+func (s *UdzurService) Add(user *models.User, kind, start, end string, now time.Time) (*models.UdzurPeriod, error) {
+	label, maxDays, ok := udzurKind(kind)
+	if !ok {
+		return nil, ErrUdzurKind
+	}
+	first, err := time.ParseInLocation("2006-01-02", start, now.Location())
+	if err != nil {
+		return nil, ErrEntryInvalidDate
+	}
+	today := now.Format("2006-01-02")
+	if start > today {
+		return nil, ErrEntryFutureDate
+	}
+
+	p := &models.UdzurPeriod{
+		UserID:    user.ID,
+		Kind:      kind,
+		StartDate: start,
+		EndDate:   first.AddDate(0, 0, maxDays-1).Format("2006-01-02"),
+		Ongoing:   end == "",
+	}
+	if end != "" {
+		if err := checkUdzurEnd(p, end, today, label, maxDays); err != nil {
+			return nil, err
+		}
+		p.EndDate = end
+	}
+
+	if s.Policy != nil {
+		// Periods have no approval queue, so a late start is simply refused
+		late, err := s.Policy.CheckRange(user, p.StartDate, p.EndDate, now)
+		if err == nil && late {
+			err = ErrEntryNeedsApproval
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	existing, err := s.Repo.GetInRange(user.ID, p.StartDate, p.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range existing {
+		if e.Ongoing && e.EndDate >= today {
+			return nil, ErrUdzurOngoing
+		}
+	}
+	if len(existing) > 0 {
+		return nil, ErrUdzurOverlap
+	}
+
+	if err := s.Repo.Create(p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// End closes an ongoing period on end, the last excused day
+func (s *UdzurService) End(user *models.User, id int, end string, now time.Time) error {
+	p, err := s.Repo.GetByID(id, user.ID)
+	if err != nil {
+		return err
+	}
+	if !p.Ongoing {
+		return ErrUdzurNotOngoing
+	}
+	label, maxDays, _ := udzurKind(p.Kind)
+	if err := checkUdzurEnd(p, end, now.Format("2006-01-02"), label, maxDays); err != nil {
+		return err
+	}
+	return s.Repo.End(id, user.ID, end)
+}
+
+func checkUdzurEnd(p *models.UdzurPeriod, end, today, label string, maxDays int) error {
+	if _, err := time.Parse("2006-01-02", end); err != nil {
+		return ErrEntryInvalidDate
+	}
+	if end < p.StartDate {
+		return ErrUdzurEndsEarly
+	}
+	if end > today {
+		return ErrEntryFutureDate
+	}
+	// EndDate still holds the latest day the kind allows
+	if maxDays > 0 && end > p.EndDate {
+		return &UdzurTooLongError{Label: label, MaxDays: maxDays}
+	}
+	return nil
+}
+
+// Current returns the user's period covering today, if any
+func (s *UdzurService) Current(userID int, now time.Time) *models.UdzurPeriod {
+	today := now.Format("2006-01-02")
+	periods, err := s.Repo.GetInRange(userID, today, today)
+	if err != nil || len(periods) == 0 {
+		return nil
+	}
+	return periods[0]
+}
+
+// Excused returns the user's excused dates between start and end
+func (s *UdzurService) Excused(userID int, start, end string) map[string]bool {
+	periods, err := s.Repo.GetInRange(userID, start, end)
+	if err != nil {
+		return map[string]bool{}
+	}
+	return ExcusedDates(periods, start, end)
+}
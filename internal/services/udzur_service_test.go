@@ -0,0 +1,32 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/ramadhan/amaliah-monitoring/internal/models"
+	"github.com/ramadhan/amaliah-monitoring/internal/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExcusedDates(t *testing.T) {
+	periods := []*models.UdzurPeriod{
+		{StartDate: "2026-02-27", EndDate: "2026-03-03"},
+		{StartDate: "2026-03-30", EndDate: "2026-04-05", Ongoing: true},
+	}
+
+	excused := services.ExcusedDates(periods, "2026-03-01", "2026-03-31")
+	assert.Len(t, excused, 5)
+	assert.True(t, excused["2026-03-01"])
+	assert.True(t, excused["2026-03-03"])
+	assert.False(t, excused["2026-03-04"])
+	assert.True(t, excused["2026-03-31"])
+	assert.False(t, excused["2026-02-28"])
+
+	assert.Empty(t, services.ExcusedDates(periods, "2026-03-10", "2026-03-20"))
+}
+
+func TestTeacherFastingReason(t *testing.T) {
+	assert.Equal(t, services.UdzurLabel, services.TeacherFastingReason("haid"))
+	assert.Equal(t, services.UdzurLabel, services.TeacherFastingReason("nifas"))
+	assert.Equal(t, "sakit", services.TeacherFastingReason("sakit"))
+}
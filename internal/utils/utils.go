@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"os"
 	"time"
 
@@ -80,3 +82,12 @@ func GenerateRandomString(n int) string {
 	}
 	return string(b)
 }
+
+// GenerateSecureToken returns a random hex token of n bytes, for URLs that act as credentials
+func GenerateSecureToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,23 @@
+// Star buttons on the doa, hadits and Quran pages call toggleFavorite
+function toggleFavorite(type, id, button) {
+  const body = new URLSearchParams({ type: type, id: id });
+  fetch('/user/favorites/toggle', { method: 'POST', body: body })
+    .then(res => res.ok ? res.json() : Promise.reject())
+    .then(data => {
+      button.textContent = data.favorited ? '★' : '☆';
+      button.title = data.favorited ? 'Hapus dari favorit' : 'Simpan ke favorit';
+      button.classList.toggle('text-accent', data.favorited);
+      button.classList.toggle('text-gray-400', !data.favorited);
+    })
+    .catch(() => alert('Gagal menyimpan favorit'));
+}
+
+// Ask the service worker to keep the given pages for offline reading
+function cacheForOffline(urls) {
+  if (!('serviceWorker' in navigator) || urls.length === 0) {
+    return;
+  }
+  navigator.serviceWorker.ready.then(registration => {
+    registration.active.postMessage({ type: 'cache-urls', urls: urls });
+  });
+}
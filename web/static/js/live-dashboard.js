@@ -0,0 +1,82 @@
+// Live dashboard updates over Server-Sent Events.
+// The page marks a container with data-live-stream="<url>" and the values to
+// refresh with data-live="<field>" / data-live-bar="<field>/<total field>".
+(function () {
+    const root = document.querySelector('[data-live-stream]');
+    if (!root || !window.EventSource) return;
+
+    const status = document.querySelector('[data-live-status]');
+    const kindLabels = { prayer: 'Shalat', fasting: 'Puasa', quran: 'Tilawah', amaliah: 'Amaliah' };
+
+    function escapeHtml(text) {
+        const div = document.createElement('div');
+        div.textContent = text == null ? '' : String(text);
+        return div.innerHTML;
+    }
+
+    function timeLabel(iso) {
+        const d = new Date(iso);
+        return d.toLocaleTimeString('id-ID', { hour: '2-digit', minute: '2-digit' });
+    }
+
+    function renderActivity(events) {
+        const list = document.querySelector('[data-live-activity]');
+        if (!list) return;
+        if (!events || events.length === 0) {
+            list.innerHTML = '<p class="text-sm text-gray-400 text-center py-2">Belum ada aktivitas baru</p>';
+            return;
+        }
+        list.innerHTML = events.map(ev => `
+            <div class="flex items-center justify-between p-2 bg-warm-100 rounded-lg">
+                <div>
+                    <p class="text-sm font-medium text-gray-800">${escapeHtml(ev.full_name)}</p>
+                    <p class="text-xs text-gray-500">${escapeHtml(kindLabels[ev.kind] || ev.kind)} · ${escapeHtml(ev.summary)}</p>
+                </div>
+                <span class="text-xs text-gray-400">${timeLabel(ev.at)}</span>
+            </div>`).join('');
+    }
+
+    function renderLeaderboard(rows) {
+        const list = document.querySelector('[data-live-leaderboard]');
+        if (!list) return;
+        list.innerHTML = (rows || []).map((row, i) => `
+            <div class="flex items-center justify-between p-3 ${i === 0 ? 'bg-accent/10' : 'bg-warm-100'} rounded-xl">
+                <div class="flex items-center space-x-3">
+                    <div class="w-10 h-10 ${i === 0 ? 'gradient-accent' : i === 1 ? 'bg-gray-400' : i === 2 ? 'bg-orange-500' : 'gradient-primary'} rounded-full flex items-center justify-center">
+                        <span class="text-white font-bold text-sm">${i + 1}</span>
+                    </div>
+                    <div>
+                        <p class="font-medium text-gray-800 text-sm">${escapeHtml(row.full_name)}</p>
+                        <p class="text-xs text-gray-500">${escapeHtml(row.class)}</p>
+                    </div>
+                </div>
+                <span class="font-bold ${i === 0 ? 'text-accent' : 'text-gray-600'}">${row.points} pts</span>
+            </div>`).join('');
+    }
+
+    function applyStats(stats) {
+        document.querySelectorAll('[data-live]').forEach(el => {
+            const value = stats[el.dataset.live];
+            if (value !== undefined) el.textContent = value;
+        });
+        document.querySelectorAll('[data-live-bar]').forEach(el => {
+            const [field, totalField] = el.dataset.liveBar.split('/');
+            const total = stats[totalField] || 0;
+            const pct = total > 0 ? Math.round((stats[field] || 0) * 100 / total) : 0;
+            el.style.width = Math.min(pct, 100) + '%';
+        });
+        renderActivity(stats.recent_activity);
+        renderLeaderboard(stats.leaderboard);
+    }
+
+    const source = new EventSource(root.dataset.liveStream);
+    source.addEventListener('open', () => {
+        if (status) status.classList.remove('hidden');
+    });
+    source.addEventListener('error', () => {
+        // EventSource reconnects by itself
+        if (status) status.classList.add('hidden');
+    });
+    source.addEventListener('stats', e => applyStats(JSON.parse(e.data)));
+    window.addEventListener('beforeunload', () => source.close());
+})();
@@ -1,4 +1,4 @@
-const CACHE_NAME = 'amaliah-ramadhan-v1';
+const CACHE_NAME = 'amaliah-ramadhan-v3';
 const urlsToCache = [
   '/',
   '/css/output.css',
@@ -84,11 +84,52 @@ self.addEventListener('fetch', (event) => {
 self.addEventListener('sync', (event) => {
   console.log('[Service Worker] Background sync:', event.tag);
   
-  if (event.tag === 'sync-prayers') {
-    event.waitUntil(syncPrayers());
+  if (event.tag === 'sync-entries' || event.tag === 'sync-prayers') {
+    event.waitUntil(syncEntries());
   }
 });
 
+// Pages queue offline entries here: { type: 'queue-sync', item: { type, date, data } }
+self.addEventListener('message', (event) => {
+  if (!event.data || event.data.type !== 'queue-sync') {
+    return;
+  }
+
+  const item = Object.assign({}, event.data.item, {
+    client_id: event.data.item.client_id || self.crypto.randomUUID(),
+    client_timestamp: event.data.item.client_timestamp || new Date().toISOString()
+  });
+
+  event.waitUntil(
+    queueEntry(item).then(() => {
+      if (self.registration.sync) {
+        return self.registration.sync.register('sync-entries');
+      }
+      return syncEntries();
+    })
+  );
+});
+
+// The favorites page sends its items here: { type: 'cache-urls', urls: [...] }
+self.addEventListener('message', (event) => {
+  if (!event.data || event.data.type !== 'cache-urls') {
+    return;
+  }
+
+  // Fragments such as #ayat-5 point into the same page, so cache each page once
+  const urls = [...new Set(event.data.urls.map((url) => url.split('#')[0]))]
+    .filter((url) => new URL(url, self.location.origin).origin === self.location.origin);
+  event.waitUntil(
+    caches.open(CACHE_NAME).then((cache) =>
+      Promise.all(urls.map((url) =>
+        fetch(url, { credentials: 'same-origin' })
+          .then((response) => response.ok ? cache.put(url, response) : null)
+          .catch(() => null)
+      ))
+    )
+  );
+});
+
 // Handle push notifications
 self.addEventListener('push', (event) => {
   console.log('[Service Worker] Push received');
@@ -117,40 +158,65 @@ self.addEventListener('notificationclick', (event) => {
   );
 });
 
-// Helper function for syncing prayers
-async function syncPrayers() {
-  // Get pending sync data from IndexedDB
+// Send every queued entry to the batch sync API in one request.
+// Entries the server has answered for (applied, queued, duplicate, conflict,
+// rejected) are dropped from the queue; storage errors stay queued for the next sync.
+async function syncEntries() {
   const db = await openDB();
-  const pendingData = await db.getAll('pending-sync');
-  
-  for (const data of pendingData) {
-    try {
-      await fetch(data.url, {
-        method: data.method,
-        headers: data.headers,
-        body: JSON.stringify(data.body)
-      });
-      
-      // Remove from pending after successful sync
-      await db.delete('pending-sync', data.id);
-    } catch (error) {
-      console.error('[Service Worker] Sync failed:', error);
+  const pending = await idbRequest(db.transaction('sync-queue').objectStore('sync-queue').getAll());
+  if (pending.length === 0) {
+    return;
+  }
+
+  const BATCH_SIZE = 100;
+  for (let i = 0; i < pending.length; i += BATCH_SIZE) {
+    const batch = pending.slice(i, i + BATCH_SIZE);
+    const response = await fetch('/user/api/sync', {
+      method: 'POST',
+      credentials: 'same-origin',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ items: batch })
+    });
+
+    if (!response.ok) {
+      // Let Background Sync retry the whole queue later
+      throw new Error('Sync failed with status ' + response.status);
+    }
+
+    const result = await response.json();
+    const store = db.transaction('sync-queue', 'readwrite').objectStore('sync-queue');
+    for (const r of result.results) {
+      if (r.status !== 'error') {
+        store.delete(r.client_id);
+      }
     }
   }
 }
 
+async function queueEntry(item) {
+  const db = await openDB();
+  return idbRequest(db.transaction('sync-queue', 'readwrite').objectStore('sync-queue').put(item));
+}
+
+function idbRequest(request) {
+  return new Promise((resolve, reject) => {
+    request.onsuccess = () => resolve(request.result);
+    request.onerror = () => reject(request.error);
+  });
+}
+
 // Simple IndexedDB helper
 function openDB() {
   return new Promise((resolve, reject) => {
-    const request = indexedDB.open('amaliah-db', 1);
+    const request = indexedDB.open('amaliah-db', 2);
     
     request.onerror = () => reject(request.error);
     request.onsuccess = () => resolve(request.result);
     
     request.onupgradeneeded = (event) => {
       const db = event.target.result;
-      if (!db.objectStoreNames.contains('pending-sync')) {
-        db.createObjectStore('pending-sync', { keyPath: 'id', autoIncrement: true });
+      if (!db.objectStoreNames.contains('sync-queue')) {
+        db.createObjectStore('sync-queue', { keyPath: 'client_id' });
       }
     };
   });